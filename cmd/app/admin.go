@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	adminRepo "social_media/internal/admin/repository"
+	adminUsecase "social_media/internal/admin/usecase"
+)
+
+func runCreateAdmin(a *app, flags *flag.FlagSet, args []string) error {
+	username := flags.String("username", "", "name of the new admin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		flags.Usage()
+		return fmt.Errorf("create-admin needs --username")
+	}
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := a.openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	admins := adminUsecase.NewAdminUsecase(adminRepo.NewAdminRepository(database))
+	admin, key, err := admins.CreateAdmin(context.Background(), *username)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created admin %s (ID %d)\n", admin.Username, admin.ID)
+	fmt.Printf("API key: %s\n", key)
+	fmt.Println("Store the key now, it cannot be shown again. Send it as \"Authorization: Bearer <key>\".")
+	if !cfg.AdminAuthEnabled() {
+		fmt.Println("server.AdminAuth is disabled, /admin routes are not served.")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"social_media/internal/bulk/models"
+	"social_media/internal/bulk/usecase"
+	memberRepo "social_media/internal/member/repository"
+	productRepo "social_media/internal/product/repository"
+	"strings"
+)
+
+// formatsByExtension picks the format of a file from its name.
+var formatsByExtension = map[string]string{
+	".csv":    models.FormatCSV,
+	".ndjson": models.FormatNDJSON,
+	".jsonl":  models.FormatNDJSON,
+}
+
+func runImport(a *app, flags *flag.FlagSet, args []string) error {
+	format := flags.String("format", "", "csv or ndjson, defaults to the extension of FILE")
+	key := flags.String("key", "", "column matching rows to stored ones: username or id for members, id or productName for products")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: import [flags] members|products FILE, - reads stdin")
+	}
+	entity, file := flags.Arg(0), flags.Arg(1)
+
+	if *format == "" {
+		*format = formatsByExtension[strings.ToLower(filepath.Ext(file))]
+		if *format == "" {
+			return fmt.Errorf("cannot tell the format of %s, set --format", file)
+		}
+	}
+
+	var input io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	bulk, err := a.newBulkUsecase()
+	if err != nil {
+		return err
+	}
+
+	opts := models.ImportOptions{Format: *format, Key: *key, DryRun: *dryRun}
+	var report *models.ImportReport
+	switch entity {
+	case "members":
+		report, err = bulk.ImportMembers(context.Background(), bufio.NewReader(input), opts)
+	case "products":
+		report, err = bulk.ImportProducts(context.Background(), bufio.NewReader(input), opts)
+	default:
+		return fmt.Errorf("unknown entity %q, want members or products", entity)
+	}
+
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", report.Failed, report.Rows)
+	}
+	return nil
+}
+
+func runExport(a *app, flags *flag.FlagSet, args []string) error {
+	format := flags.String("format", "", "csv or ndjson, defaults to the extension of --output, else csv")
+	output := flags.String("output", "", "file to write, defaults to stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: export [flags] members|products")
+	}
+	entity := flags.Arg(0)
+	if entity != "members" && entity != "products" {
+		return fmt.Errorf("unknown entity %q, want members or products", entity)
+	}
+
+	if *format == "" {
+		*format = formatsByExtension[strings.ToLower(filepath.Ext(*output))]
+		if *format == "" {
+			*format = models.FormatCSV
+		}
+	}
+
+	bulk, err := a.newBulkUsecase()
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	var exported int
+	if entity == "members" {
+		exported, err = bulk.ExportMembers(context.Background(), out, *format)
+	} else {
+		exported, err = bulk.ExportProducts(context.Background(), out, *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		if err := out.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d %s to %s\n", exported, entity, *output)
+	}
+	return nil
+}
+
+// newBulkUsecase works on the configured database without the request
+// timeouts of the server, so files of any size can be imported or exported.
+func (a *app) newBulkUsecase() (*usecase.BulkUsecase, error) {
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	database, err := a.openDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return usecase.NewBulkUsecase(memberRepo.NewMemberRepository(database), productRepo.NewMySQLProductRepository(database)), nil
+}
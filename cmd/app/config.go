@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+func runConfig(a *app, flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.Arg(0) != "validate" {
+		flags.Usage()
+		return fmt.Errorf("config needs a subcommand: validate")
+	}
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Config %s is valid (profile %q, driver %q)\n", a.configFile, a.profile, cfg.MySQL.Driver)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"social_media/internal/seed"
+	"time"
+)
+
+func runGenerate(a *app, flags *flag.FlagSet, args []string) error {
+	var opts seed.GenerateOptions
+	flags.IntVar(&opts.Members, "members", 10000, "members to insert")
+	flags.IntVar(&opts.Products, "products", 1000, "products to insert")
+	flags.IntVar(&opts.Reviews, "reviews", 50000, "reviews to insert")
+	flags.IntVar(&opts.Likes, "likes", 200000, "likes to insert")
+	flags.IntVar(&opts.BatchSize, "batch-size", seed.DefaultBatchSize, "rows per INSERT statement")
+	flags.Int64Var(&opts.Seed, "seed", 1, "random seed, the same seed generates the same rows")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := a.openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	reported := make(map[string]time.Time)
+	opts.Progress = func(table string, inserted int, total int) {
+		if inserted < total && time.Since(reported[table]) < 5*time.Second {
+			return
+		}
+		reported[table] = time.Now()
+		fmt.Printf("%-16s %d/%d\n", table, inserted, total)
+	}
+
+	result, err := seed.Generate(context.Background(), database, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Generated %d members, %d products, %d reviews and %d likes in %s\n",
+		result.Members, result.Products, result.Reviews, result.Likes, time.Since(started).Round(time.Second))
+	if result.Reviews > 0 {
+		fmt.Printf("Product %d has the most reviews\n", result.HottestProductID)
+	}
+	return nil
+}
@@ -1,46 +1,137 @@
 package main
 
 import (
-	"context"
-	"log"
+	"flag"
+	"fmt"
+	"os"
 	"social_media/config"
 	"social_media/config/db"
-	"social_media/internal/server"
 	"social_media/pkg/zap"
-	"time"
+
+	"gorm.io/gorm"
 )
 
 const (
-	ctxTimeout = 10
+	defaultConfigFile = "./config/config"
+	// profileEnv selects the profile when --profile is not given, as set in
+	// docker-compose.yml
+	profileEnv = "APP_ENV"
 )
 
+// command is a subcommand of the binary. run receives a flag set printing
+// the summary on -h and the arguments after the command name.
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, flags *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{"serve", "start the HTTP server (the default)", runServe},
+	{"migrate", "apply or revert database migrations: up [N], down [N], status, force VERSION", runMigrate},
+	{"seed", "insert the sample data into an empty database", runSeed},
+	{"generate", "insert a synthetic dataset: --members, --products, --reviews, --likes", runGenerate},
+	{"import", "create or update rows from a CSV or NDJSON file: [--key K] [--dry-run] members|products FILE", runImport},
+	{"export", "write every row as CSV or NDJSON: [--format F] [--output FILE] members|products", runExport},
+	{"create-admin", "create an admin and print its API key: --username NAME", runCreateAdmin},
+	{"config", "check the configuration: validate", runConfig},
+}
+
+// app holds the global flags shared by every command.
+type app struct {
+	configFile string
+	profile    string
+}
+
 func main() {
-	log.Println("Starting app server")
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	a := &app{}
+
+	flags := flag.NewFlagSet("social_media", flag.ContinueOnError)
+	flags.StringVar(&a.configFile, "config", defaultConfigFile, "config file, with or without its extension")
+	flags.StringVar(&a.profile, "profile", os.Getenv(profileEnv), "environment profile loading .env.<profile> and <config>.<profile>, defaults to $"+profileEnv)
+	flags.Usage = func() { usage(flags) }
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	name, rest := "serve", flags.Args()
+	if len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
 
-	cfgFile, err := config.LoadConfig("./config/config")
+	for _, cmd := range commands {
+		if cmd.name == name {
+			err := cmd.run(a, cmd.newFlagSet(), rest)
+			if err == flag.ErrHelp {
+				return nil
+			}
+			return err
+		}
+	}
+
+	usage(flags)
+	return fmt.Errorf("unknown command %q", name)
+}
+
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command] [args]\n\nCommands:\n", flags.Name())
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flags.PrintDefaults()
+}
+
+// loadConfig reads and validates the configuration for the selected profile.
+func (a *app) loadConfig() (*config.Config, error) {
+	cfgFile, err := config.LoadProfile(a.configFile, a.profile)
 	if err != nil {
-		log.Fatalf("Load config: %v", err)
+		return nil, fmt.Errorf("load config: %w", err)
 	}
 
 	cfg, err := config.ParseConfigDefault(cfgFile)
 	if err != nil {
-		log.Fatalf("Parse config: %v", err)
+		return nil, fmt.Errorf("parse config: %w", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (a *app) newLogger(cfg *config.Config) zap.Logger {
 	logger := zap.NewAppLogger(cfg)
 	logger.InitLogger()
-	logger.Infof("App Version: 1.0.0")
-
-	_, cancel := context.WithTimeout(context.Background(), ctxTimeout*time.Second)
-	defer cancel()
+	return logger
+}
 
-	db, err := db.InitDatabase(cfg)
-	if err != nil {
-		logger.Fatal(err)
+// openDatabase connects to MySQL, commands working on the database cannot
+// run with the in-memory driver.
+func (a *app) openDatabase(cfg *config.Config) (*gorm.DB, error) {
+	if cfg.MySQL.Driver == config.DriverMemory {
+		return nil, fmt.Errorf("mysql.Driver is %q, this command needs a database", config.DriverMemory)
 	}
+	return db.InitDatabase(cfg)
+}
 
-	s := server.NewServer(cfg, logger, db)
-	if err := s.Run(); err != nil {
-		logger.Fatal(err.Error())
+// newFlagSet returns the flag set of the command, printing its summary on -h.
+func (cmd command) newFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s: %s\n", cmd.name, cmd.summary)
+		flags.PrintDefaults()
 	}
+	return flags
 }
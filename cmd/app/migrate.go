@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"social_media/config/db"
+	"strconv"
+)
+
+func runMigrate(a *app, flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("migrate needs a subcommand")
+	}
+	action, rest := flags.Arg(0), flags.Args()[1:]
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := a.openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	migrator, err := db.NewDefaultMigrator(database)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		steps, err := optionalCount(rest, 0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(ctx, steps)
+		printMigrations("Applied", applied)
+		return err
+
+	case "down":
+		// Reverting everything by accident loses data, so one step is the default
+		steps, err := optionalCount(rest, 1)
+		if err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		printMigrations("Reverted", reverted)
+		return err
+
+	case "status":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%06d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		if dirty {
+			fmt.Printf("Version %d is dirty, fix the database and run migrate force\n", version)
+		}
+		return nil
+
+	case "force":
+		if len(rest) != 1 {
+			return fmt.Errorf("migrate force needs a version")
+		}
+		version, err := strconv.ParseUint(rest[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		if err := migrator.Force(ctx, uint(version)); err != nil {
+			return err
+		}
+		fmt.Printf("Version forced to %d\n", version)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q", action)
+	}
+}
+
+// optionalCount parses the optional step count of migrate up and down.
+func optionalCount(args []string, defaultCount int) (int, error) {
+	if len(args) == 0 {
+		return defaultCount, nil
+	}
+	count, err := strconv.Atoi(args[0])
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("invalid step count %q", args[0])
+	}
+	return count, nil
+}
+
+func printMigrations(verb string, migrations []db.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println("No migrations to run")
+		return
+	}
+	for _, migration := range migrations {
+		fmt.Printf("%s %06d_%s\n", verb, migration.Version, migration.Name)
+	}
+}
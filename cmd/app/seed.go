@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"social_media/internal/seed"
+)
+
+func runSeed(a *app, flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := a.openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := seed.InsertSample(context.Background(), database)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		fmt.Println("Database already has members, nothing seeded")
+		return nil
+	}
+	fmt.Printf("Seeded %d members, %d products, %d reviews and %d likes\n",
+		len(seed.SampleMembers), len(seed.SampleProducts), len(seed.SampleReviews), len(seed.SampleLikes))
+	return nil
+}
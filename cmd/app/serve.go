@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"social_media/config"
+	"social_media/internal/server"
+
+	"gorm.io/gorm"
+)
+
+func runServe(a *app, flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	logger := a.newLogger(cfg)
+	logger.Infof("Starting app server, version %s, profile %q", cfg.Server.AppVersion, a.profile)
+
+	// The in-memory driver serves seeded sample data without a database
+	var database *gorm.DB
+	if cfg.MySQL.Driver != config.DriverMemory {
+		database, err = a.openDatabase(cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return server.NewServer(cfg, logger, database).Run()
+}
@@ -2,17 +2,29 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server  ServerConfig
-	Logger  LoggerConfig
-	Swagger SwaggerConfig
-	MySQL   MySQLConfig
+	Server        ServerConfig
+	Logger        LoggerConfig
+	Swagger       SwaggerConfig
+	MySQL         MySQLConfig
+	SoftDelete    SoftDeleteConfig
+	Idempotency   IdempotencyConfig
+	RateLimit     RateLimitConfig
+	RequestLogger RequestLoggerConfig
+	DataExport    DataExportConfig
+	Search        SearchConfig
+	ProductCache  ProductCacheConfig
 }
 
 type ServerConfig struct {
@@ -25,8 +37,30 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	SSL          bool
-	Debug        bool
-	CSRF         bool
+	CertFile     string
+	KeyFile      string
+	// RedirectHTTP is the address of a plain HTTP listener that redirects
+	// to HTTPS when SSL is enabled, e.g. ":80". Empty disables it.
+	RedirectHTTP    string
+	ShutdownTimeout time.Duration
+	Debug           bool
+	CSRF            bool
+	// SessionCookie names the cookie that marks a browser session, only
+	// requests carrying it are subject to CSRF checks
+	SessionCookie string
+	AllowOrigins  []string
+	// TrustedProxies are the CIDRs of reverse proxies whose
+	// X-Forwarded-For is believed. Without any, the client IP is the
+	// address of the connection.
+	TrustedProxies []string
+	// QueryTimeout bounds every request context; RouteTimeouts overrides it
+	// per route path, e.g. "/api/v1/products/:id": 2s
+	QueryTimeout  time.Duration
+	RouteTimeouts map[string]time.Duration
+	// AdminAuth requires an admin API key, issued by the create-admin
+	// command, on every /admin route. Unset, it is on unless the memory
+	// driver is used. The /admin routes are only served with it on.
+	AdminAuth *bool
 }
 
 type LoggerConfig struct {
@@ -35,6 +69,15 @@ type LoggerConfig struct {
 	DisableStacktrace bool
 	Encoding          string
 	Level             string
+	// Outputs lists the sinks: "stdout", "stderr" and "file"
+	Outputs []string
+	// File is the log file path, rotated once it grows past MaxSize MB,
+	// keeping MaxBackups old files for at most MaxAge days
+	File            string
+	MaxSize         int
+	MaxBackups      int
+	MaxAge          int
+	DisableCompress bool
 }
 
 type SwaggerConfig struct {
@@ -45,32 +88,238 @@ type SwaggerConfig struct {
 	Host        string
 }
 
+// Storage drivers accepted in MySQLConfig.Driver
+const (
+	DriverMySQL  = "mysql"
+	DriverMemory = "memory"
+)
+
 type MySQLConfig struct {
 	Host     string
 	Port     string
 	User     string
 	Password string
 	DBName   string
-	Driver   string
-	SSLMode  bool
+	// Driver selects the repositories: "mysql", or "memory" to serve
+	// seeded in-memory data without a database for demos and tests
+	Driver  string
+	SSLMode bool
+}
+
+// SoftDeleteConfig controls how long soft deleted members, products and
+// reviews can be restored before they are purged. A zero Retention disables
+// purging.
+type SoftDeleteConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// IdempotencyConfig sets how long responses to requests carrying an
+// Idempotency-Key are kept for replay. Requests with a body over
+// MaxBodySize bytes are refused rather than buffered to be fingerprinted.
+type IdempotencyConfig struct {
+	TTL         time.Duration
+	MaxBodySize int
+}
+
+// DataExportConfig controls the personal data exports of members. Accounts
+// with more than SyncLimit reviews and likes are exported in the background,
+// by at most Workers jobs at a time cut after Timeout, and their archive can
+// be downloaded for TTL.
+type DataExportConfig struct {
+	SyncLimit int
+	Workers   int
+	Timeout   time.Duration
+	TTL       time.Duration
+}
+
+// Indexes accepted in SearchConfig.Index
+const (
+	SearchIndexFullText = "fulltext"
+	SearchIndexMemory   = "memory"
+)
+
+// SearchConfig selects the index behind GET /search: "fulltext" queries the
+// MySQL FULLTEXT indexes, "memory" keeps an embedded index built from the
+// repositories and rebuilt once older than RefreshInterval, 0 rebuilding it
+// on every search. Empty picks fulltext for the mysql driver and memory
+// otherwise.
+type SearchConfig struct {
+	Index           string
+	RefreshInterval time.Duration
+}
+
+// ProductCacheConfig controls the read-through cache of product pages. Up to
+// Size products are kept, with their reviews, for TTL or until a write
+// through the API changes them.
+type ProductCacheConfig struct {
+	Enabled bool
+	Size    int
+	TTL     time.Duration
+}
+
+// RateLimitConfig holds the token bucket policies. Routes is keyed by
+// "METHOD /path" using the Echo route path, e.g. "POST /api/v1/members/".
+type RateLimitConfig struct {
+	Enabled bool
+	Default RateLimitPolicy
+	Routes  map[string]RateLimitPolicy
 }
 
+// RateLimitPolicy allows Requests per Per on average with bursts of up to
+// Burst requests. Burst defaults to Requests.
+type RateLimitPolicy struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// RequestLoggerConfig controls what RequestLoggerMiddleware writes.
+// RedactFields are dot separated JSON paths ("*" matches any key) whose
+// values are replaced in logged bodies, single segment paths also name form
+// fields and CSV columns, and bodies that cannot be redacted are left out.
+// Bodies are cut at MaxBodySize bytes,
+// SkipRoutes ("METHOD /path" or "/path") are never logged and SampleRate is
+// the fraction of successful requests logged (0 or 1 logs all).
+type RequestLoggerConfig struct {
+	RedactFields []string
+	MaxBodySize  int
+	SkipRoutes   []string
+	SampleRate   float64
+}
+
+// envBindings lets the variables of the .env files override the config file
+var envBindings = map[string]string{
+	"mysql.Host":     "DB_HOST",
+	"mysql.Port":     "DB_PORT",
+	"mysql.User":     "DB_USER",
+	"mysql.Password": "DB_PASSWORD",
+	"mysql.DBName":   "DB_NAME",
+}
+
+// LoadConfig reads filename, either a config name such as "./config/config"
+// or a file path. Files with an unknown extension, like config.yaml.example,
+// are read as YAML.
 func LoadConfig(filename string) (*viper.Viper, error) {
 	v := viper.New()
 
-	v.SetConfigName(filename)
-	v.AddConfigPath(".")
 	v.AutomaticEnv()
+	for key, env := range envBindings {
+		if err := v.BindEnv(key, env); err != nil {
+			return nil, err
+		}
+	}
+
+	path := resolveConfigFile(filename)
+	if path == "" {
+		return nil, fmt.Errorf("config file %s not found", filename)
+	}
+	setConfigFile(v, path)
 	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, errors.New("config file not found, ")
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// LoadProfile reads filename like LoadConfig for an environment profile such
+// as "dev". The variables in .env.<profile> that are not already set are
+// exported first, and <filename>.<profile> is merged over the base file when
+// it exists, e.g. config/config.dev.yaml over config/config.yaml.
+func LoadProfile(filename string, profile string) (*viper.Viper, error) {
+	if profile != "" {
+		if err := loadEnvFile(".env." + profile); err != nil {
+			return nil, err
 		}
+	}
+
+	v, err := LoadConfig(filename)
+	if err != nil || profile == "" {
+		return v, err
+	}
+
+	base := resolveConfigFile(filename)
+	ext := filepath.Ext(base)
+	path := resolveConfigFile(strings.TrimSuffix(base, ext) + "." + profile + ext)
+	if path == "" {
+		return v, nil
+	}
+	setConfigFile(v, path)
+	if err := v.MergeInConfig(); err != nil {
 		return nil, err
 	}
 
 	return v, nil
 }
 
+// resolveConfigFile returns the file named filename, or filename with one
+// of the extensions viper reads, and "" when there is none.
+func resolveConfigFile(filename string) string {
+	if info, err := os.Stat(filename); err == nil && !info.IsDir() {
+		return filename
+	}
+	for _, ext := range viper.SupportedExts {
+		if _, err := os.Stat(filename + "." + ext); err == nil {
+			return filename + "." + ext
+		}
+	}
+	return ""
+}
+
+func setConfigFile(v *viper.Viper, path string) {
+	v.SetConfigFile(path)
+
+	configType := "yaml"
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); contains(viper.SupportedExts, ext) {
+		configType = ext
+	}
+	v.SetConfigType(configType)
+}
+
+// loadEnvFile exports KEY=VALUE lines, optionally prefixed with ENV as in
+// .env.dev, without overriding variables that are already set. A missing
+// file is not an error.
+func loadEnvFile(filename string) error {
+	content, err := ioutil.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "ENV "))
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s: invalid line %q", filename, line)
+		}
+		key = strings.TrimSpace(key)
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, strings.Trim(strings.TrimSpace(value), `"'`)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AdminAuthEnabled reports whether /admin routes require an admin API key,
+// and so are served at all.
+func (c *Config) AdminAuthEnabled() bool {
+	if c.Server.AdminAuth != nil {
+		return *c.Server.AdminAuth
+	}
+	return c.MySQL.Driver != DriverMemory
+}
+
 func ParseConfigDefault(v *viper.Viper) (*Config, error) {
 	var c Config
 
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.yaml"), "server:\n  Port: :8080\nmysql:\n  Host: localhost\n  DBName: base\n")
+	writeFile(t, filepath.Join(dir, "config.dev.yaml"), "server:\n  Port: :9090\n")
+	writeFile(t, filepath.Join(dir, ".env.dev"), "# comment\nENV DB_HOST=db.dev\nDB_USER=dev\nENV DB_NAME=from_env_file\n")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// Variables already set win over the .env file
+	t.Setenv("DB_NAME", "from_env")
+	for _, key := range []string{"DB_HOST", "DB_USER"} {
+		key := key
+		value, set := os.LookupEnv(key)
+		t.Cleanup(func() {
+			if set {
+				os.Setenv(key, value)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+		os.Unsetenv(key)
+	}
+
+	v, err := LoadProfile("./config", "dev")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	cfg, err := ParseConfigDefault(v)
+	if err != nil {
+		t.Fatalf("ParseConfigDefault() error = %v", err)
+	}
+
+	if cfg.Server.Port != ":9090" {
+		t.Errorf("Server.Port = %q, want the profile's :9090", cfg.Server.Port)
+	}
+	if cfg.MySQL.Host != "db.dev" || cfg.MySQL.User != "dev" {
+		t.Errorf("MySQL host and user = %q, %q, want the .env.dev values", cfg.MySQL.Host, cfg.MySQL.User)
+	}
+	if cfg.MySQL.DBName != "from_env" {
+		t.Errorf("MySQL.DBName = %q, want the environment's from_env", cfg.MySQL.DBName)
+	}
+
+	if _, err := LoadProfile("./missing", ""); err == nil {
+		t.Error("LoadProfile() of a missing file returned no error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		cfg := &Config{}
+		cfg.Server.Port = ":8080"
+		cfg.MySQL.Host = "localhost"
+		cfg.MySQL.DBName = "test_code"
+		cfg.Logger.Level = "debug"
+		cfg.Logger.Encoding = "json"
+		cfg.Logger.Outputs = []string{"stdout"}
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Default = RateLimitPolicy{Requests: 100, Per: time.Minute}
+		return cfg
+	}
+
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{"unknown driver", func(c *Config) { c.MySQL.Driver = "postgres" }, []string{"mysql.Driver"}},
+		{"mysql without database", func(c *Config) { c.MySQL.Host, c.MySQL.DBName = "", "" }, []string{"mysql.Host", "mysql.DBName"}},
+		{"memory without database", func(c *Config) { c.MySQL = MySQLConfig{Driver: DriverMemory} }, nil},
+		{"admin auth in memory", func(c *Config) {
+			enabled := true
+			c.MySQL.Driver, c.Server.AdminAuth = DriverMemory, &enabled
+		}, []string{"server.AdminAuth"}},
+		{"ssl without files", func(c *Config) { c.Server.SSL = true }, []string{"server.CertFile", "server.KeyFile"}},
+		{"bad logger", func(c *Config) {
+			c.Logger.Level, c.Logger.Encoding, c.Logger.Outputs = "loud", "xml", []string{"file"}
+		}, []string{"logger.Level", "logger.Encoding", "logger.File"}},
+		{"bad rate limit", func(c *Config) {
+			c.RateLimit.Routes = map[string]RateLimitPolicy{"GET /": {Requests: 1}}
+		}, []string{`rateLimit.Routes["GET /"]`}},
+		{"bad trusted proxy", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.1"} }, []string{"server.TrustedProxies"}},
+		{"bad sample rate", func(c *Config) { c.RequestLogger.SampleRate = 2 }, []string{"requestLogger.SampleRate"}},
+		{"negative data export ttl", func(c *Config) { c.DataExport.TTL = -time.Hour }, []string{"dataExport"}},
+		{"unknown search index", func(c *Config) { c.Search.Index = "elastic" }, []string{"search.Index"}},
+		{"fulltext search without mysql", func(c *Config) {
+			c.MySQL.Driver = DriverMemory
+			c.Search.Index = SearchIndexFullText
+		}, []string{"search.Index"}},
+		{"product cache without size", func(c *Config) {
+			c.ProductCache = ProductCacheConfig{Enabled: true, TTL: time.Minute}
+		}, []string{"productCache"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want %v", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, want it to mention %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,16 +2,24 @@
 CREATE TABLE members (
   ID_MEMBER INT AUTO_INCREMENT PRIMARY KEY,
   USERNAME VARCHAR(255) NOT NULL,
-  GENDER VARCHAR(255) NOT NULL,
-  SKINTYPE VARCHAR(255) NOT NULL,
-  SKINCOLOR VARCHAR(255) NOT NULL
+  GENDER ENUM('Male', 'Female') NULL,
+  SKINTYPE ENUM('Oily', 'Dry', 'Normal', 'Combination') NULL,
+  SKINCOLOR ENUM('Fair', 'Medium', 'Dark') NULL,
+  VERSION INT NOT NULL DEFAULT 1,
+  DELETED_AT DATETIME NULL,
+  ANONYMIZED_AT DATETIME NULL,
+  INDEX idx_members_deleted_at (DELETED_AT)
 );
 
 -- Create the products table
 CREATE TABLE products (
   ID_PRODUCT INT AUTO_INCREMENT PRIMARY KEY,
   PRODUCT_NAME VARCHAR(255) NOT NULL,
-  PRICE DECIMAL(10, 2) NOT NULL
+  PRICE DECIMAL(10, 2) NOT NULL,
+  VERSION INT NOT NULL DEFAULT 1,
+  DELETED_AT DATETIME NULL,
+  INDEX idx_products_deleted_at (DELETED_AT),
+  FULLTEXT INDEX ft_products_name (PRODUCT_NAME)
 );
 
 -- Create the review_products table
@@ -20,6 +28,11 @@ CREATE TABLE review_products (
   ID_MEMBER INT NOT NULL,
   ID_PRODUCT INT NOT NULL,
   DESC_REVIEW TEXT,
+  VERSION INT NOT NULL DEFAULT 1,
+  DELETED_AT DATETIME NULL,
+  INDEX idx_review_products_deleted_at (DELETED_AT),
+  INDEX idx_review_products_product (ID_PRODUCT, DELETED_AT),
+  FULLTEXT INDEX ft_review_products_desc (DESC_REVIEW),
   FOREIGN KEY (ID_MEMBER) REFERENCES members (ID_MEMBER) ON DELETE CASCADE,
   FOREIGN KEY (ID_PRODUCT) REFERENCES products (ID_PRODUCT) ON DELETE CASCADE
 );
@@ -29,10 +42,46 @@ CREATE TABLE like_reviews (
   ID_LIKE INT AUTO_INCREMENT PRIMARY KEY,
   ID_REVIEW INT NOT NULL,
   ID_MEMBER INT NOT NULL,
+  UNIQUE INDEX idx_like_reviews_review (ID_REVIEW, ID_MEMBER),
   FOREIGN KEY (ID_REVIEW) REFERENCES review_products (ID_REVIEW) ON DELETE CASCADE,
   FOREIGN KEY (ID_MEMBER) REFERENCES members (ID_MEMBER) ON DELETE CASCADE
 );
 
+-- Create the admins table
+CREATE TABLE admins (
+  ID_ADMIN INT AUTO_INCREMENT PRIMARY KEY,
+  USERNAME VARCHAR(255) NOT NULL,
+  KEY_HASH CHAR(64) NOT NULL,
+  CREATED_AT DATETIME NOT NULL,
+  UNIQUE INDEX idx_admins_username (USERNAME),
+  UNIQUE INDEX idx_admins_key_hash (KEY_HASH)
+);
+
+-- Create the member_erasures table, the audit trail of account deletions
+CREATE TABLE member_erasures (
+  ID_ERASURE INT AUTO_INCREMENT PRIMARY KEY,
+  ID_MEMBER INT NOT NULL,
+  MODE ENUM('delete', 'anonymize') NOT NULL,
+  REQUESTED_BY INT NULL,
+  REQUEST_ID VARCHAR(255) NOT NULL DEFAULT '',
+  CREATED_AT DATETIME NOT NULL,
+  INDEX idx_member_erasures_member (ID_MEMBER)
+);
+
+-- Create the member_exports table, the background data exports
+CREATE TABLE member_exports (
+  ID_EXPORT CHAR(32) PRIMARY KEY,
+  ID_MEMBER INT NOT NULL,
+  STATUS ENUM('pending', 'ready', 'failed') NOT NULL,
+  CREATED_AT DATETIME NOT NULL,
+  COMPLETED_AT DATETIME NULL,
+  EXPIRES_AT DATETIME NOT NULL,
+  ARCHIVE LONGBLOB NULL,
+  INDEX idx_member_exports_member (ID_MEMBER, CREATED_AT),
+  INDEX idx_member_exports_expires_at (EXPIRES_AT),
+  FOREIGN KEY (ID_MEMBER) REFERENCES members (ID_MEMBER) ON DELETE CASCADE
+);
+
 -- Insert dummy data into the members table
 INSERT INTO members (USERNAME, GENDER, SKINTYPE, SKINCOLOR)
 VALUES
@@ -0,0 +1,278 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Migrations holds the scripts of config/db/migrations, named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// Migration is a pair of up and down scripts.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Migrator applies migrations, recording the current version in the same
+// schema_migrations table as golang-migrate so either tool can be used.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator reads the migrations at the root of fsys.
+func NewMigrator(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, file := range files {
+		name := path.Base(file)
+		direction := ""
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: name must end in .up.sql or .down.sql", name)
+		}
+
+		prefix, title, ok := strings.Cut(strings.TrimSuffix(name, "."+direction+".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: name must start with <version>_", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", name, prefix)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: title}
+			byVersion[uint(version)] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	m := &Migrator{db: db}
+	for _, migration := range byVersion {
+		m.migrations = append(m.migrations, *migration)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool { return m.migrations[i].Version < m.migrations[j].Version })
+
+	return m, nil
+}
+
+// NewDefaultMigrator returns a Migrator for the embedded Migrations.
+func NewDefaultMigrator(db *gorm.DB) (*Migrator, error) {
+	fsys, err := fs.Sub(Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrator(db, fsys)
+}
+
+// Version returns the current version, 0 before the first migration. A
+// dirty version failed half way and must be fixed by hand, then forced.
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, false, err
+	}
+
+	var rows []struct {
+		Version uint
+		Dirty   bool
+	}
+	if err := m.db.WithContext(ctx).Raw("SELECT version, dirty FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return 0, false, err
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+	return rows[0].Version, rows[0].Dirty, nil
+}
+
+// Up applies up to steps pending migrations, all of them when steps is 0,
+// and returns those applied.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	current, err := m.cleanVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= current {
+			continue
+		}
+		if steps > 0 && len(applied) == steps {
+			break
+		}
+		if err := m.run(ctx, migration.Version, migration.Version, migration.Up); err != nil {
+			return applied, fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down reverts up to steps applied migrations, all of them when steps is 0,
+// and returns those reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	current, err := m.cleanVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current {
+			continue
+		}
+		if steps > 0 && len(reverted) == steps {
+			break
+		}
+
+		var previous uint
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+		if err := m.run(ctx, migration.Version, previous, migration.Down); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// Status lists every migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	current, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= current,
+		})
+	}
+	return statuses, nil
+}
+
+// Force records version as applied and clean without running anything, for
+// databases created from db.sql or repaired by hand after a failure.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+	return m.setVersion(ctx, version, false)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)").Error
+}
+
+func (m *Migrator) cleanVersion(ctx context.Context) (uint, error) {
+	current, dirty, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d, fix it and run migrate force", current)
+	}
+	return current, nil
+}
+
+// run marks the database dirty at version, runs script and records target
+// as the clean version. MySQL commits DDL implicitly, so a failure leaves the
+// version dirty rather than rolling back.
+func (m *Migrator) run(ctx context.Context, version uint, target uint, script string) error {
+	if strings.TrimSpace(script) == "" {
+		return errors.New("script is missing or empty")
+	}
+	if err := m.setVersion(ctx, version, true); err != nil {
+		return err
+	}
+	for _, statement := range splitStatements(script) {
+		if err := m.db.WithContext(ctx).Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return m.setVersion(ctx, target, false)
+}
+
+func (m *Migrator) setVersion(ctx context.Context, version uint, dirty bool) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM schema_migrations").Error; err != nil {
+			return err
+		}
+		if version == 0 {
+			return nil
+		}
+		return tx.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)", version, dirty).Error
+	})
+}
+
+// splitStatements splits a script on semicolons ending a line, dropping
+// comment lines.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
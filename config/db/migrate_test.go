@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testMigrations = fstest.MapFS{
+	"000001_create_members.up.sql":   {Data: []byte("-- Members\nCREATE TABLE members (\n  ID_MEMBER INTEGER PRIMARY KEY\n);\n")},
+	"000001_create_members.down.sql": {Data: []byte("DROP TABLE members;\n")},
+	"000002_add_username.up.sql":     {Data: []byte("ALTER TABLE members ADD COLUMN USERNAME TEXT;\nCREATE INDEX idx_members_username ON members (USERNAME);\n")},
+	"000002_add_username.down.sql":   {Data: []byte("DROP INDEX idx_members_username;\nALTER TABLE members DROP COLUMN USERNAME;\n")},
+	"000003_broken.up.sql":           {Data: []byte("CREATE TABLE;\n")},
+	"000003_broken.down.sql":         {Data: []byte("SELECT 1;\n")},
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, *gorm.DB) {
+	t.Helper()
+
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := database.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := NewMigrator(database, testMigrations)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	return migrator, database
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	migrator, database := newTestMigrator(t)
+
+	applied, err := migrator.Up(ctx, 2)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("Up(2) = %d migrations, %v, want 2", len(applied), err)
+	}
+	if err := database.Exec("INSERT INTO members (ID_MEMBER, USERNAME) VALUES (1, 'User1')").Error; err != nil {
+		t.Fatalf("schema after Up: %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Applied != (status.Version <= 2) {
+			t.Errorf("Status() %d_%s applied = %v", status.Version, status.Name, status.Applied)
+		}
+	}
+
+	// A failing migration leaves the version dirty until it is forced
+	if _, err := migrator.Up(ctx, 0); err == nil {
+		t.Fatal("Up() of a broken migration returned no error")
+	}
+	if version, dirty, _ := migrator.Version(ctx); version != 3 || !dirty {
+		t.Fatalf("Version() = %d, dirty %v, want 3 dirty", version, dirty)
+	}
+	if _, err := migrator.Down(ctx, 1); err == nil {
+		t.Fatal("Down() of a dirty database returned no error")
+	}
+	if err := migrator.Force(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("Down(1) = %v, %v, want migration 2", reverted, err)
+	}
+	if version, dirty, _ := migrator.Version(ctx); version != 1 || dirty {
+		t.Fatalf("Version() = %d, dirty %v, want 1 clean", version, dirty)
+	}
+
+	if _, err := migrator.Down(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if version, _, _ := migrator.Version(ctx); version != 0 {
+		t.Fatalf("Version() after Down(0) = %d, want 0", version)
+	}
+	if err := database.Exec("SELECT * FROM members").Error; err == nil {
+		t.Error("members still exists after reverting every migration")
+	}
+}
+
+func TestDefaultMigrations(t *testing.T) {
+	migrator, err := NewDefaultMigrator(nil)
+	if err != nil {
+		t.Fatalf("NewDefaultMigrator() error = %v", err)
+	}
+
+	for i, migration := range migrator.migrations {
+		if migration.Version != uint(i+1) {
+			t.Errorf("migration %d_%s, want version %d", migration.Version, migration.Name, i+1)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %d_%s is missing a script", migration.Version, migration.Name)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+-- The values cleared by the up migration are lost, they come back empty.
+ALTER TABLE members
+  MODIFY GENDER VARCHAR(255) NULL,
+  MODIFY SKINTYPE VARCHAR(255) NULL,
+  MODIFY SKINCOLOR VARCHAR(255) NULL;
+UPDATE members SET GENDER = '' WHERE GENDER IS NULL;
+UPDATE members SET SKINTYPE = '' WHERE SKINTYPE IS NULL;
+UPDATE members SET SKINCOLOR = '' WHERE SKINCOLOR IS NULL;
+ALTER TABLE members
+  MODIFY GENDER VARCHAR(255) NOT NULL,
+  MODIFY SKINTYPE VARCHAR(255) NOT NULL,
+  MODIFY SKINCOLOR VARCHAR(255) NOT NULL;
@@ -0,0 +1,24 @@
+-- Normalize the spelling of enumerated member attributes so that
+-- "oily", "Oily" and "OILY" end up in the same cohort.
+UPDATE members SET GENDER = 'Male' WHERE LOWER(TRIM(GENDER)) IN ('male', 'm', 'man');
+UPDATE members SET GENDER = 'Female' WHERE LOWER(TRIM(GENDER)) IN ('female', 'f', 'woman');
+
+UPDATE members SET SKINTYPE = 'Oily' WHERE LOWER(TRIM(SKINTYPE)) IN ('oily', 'oily skin');
+UPDATE members SET SKINTYPE = 'Dry' WHERE LOWER(TRIM(SKINTYPE)) IN ('dry', 'dry skin');
+UPDATE members SET SKINTYPE = 'Normal' WHERE LOWER(TRIM(SKINTYPE)) IN ('normal', 'normal skin');
+UPDATE members SET SKINTYPE = 'Combination' WHERE LOWER(TRIM(SKINTYPE)) IN ('combination', 'combination skin', 'combo', 'mixed');
+
+UPDATE members SET SKINCOLOR = 'Fair' WHERE LOWER(TRIM(SKINCOLOR)) IN ('fair', 'light');
+UPDATE members SET SKINCOLOR = 'Medium' WHERE LOWER(TRIM(SKINCOLOR)) IN ('medium', 'mid');
+UPDATE members SET SKINCOLOR = 'Dark' WHERE LOWER(TRIM(SKINCOLOR)) = 'dark';
+
+-- Values that are still unknown cannot be mapped, they are cleared so the
+-- columns can be restricted and members set them again on their next update.
+UPDATE members SET GENDER = NULL WHERE GENDER NOT IN ('Male', 'Female');
+UPDATE members SET SKINTYPE = NULL WHERE SKINTYPE NOT IN ('Oily', 'Dry', 'Normal', 'Combination');
+UPDATE members SET SKINCOLOR = NULL WHERE SKINCOLOR NOT IN ('Fair', 'Medium', 'Dark');
+
+ALTER TABLE members
+  MODIFY GENDER ENUM('Male', 'Female') NULL,
+  MODIFY SKINTYPE ENUM('Oily', 'Dry', 'Normal', 'Combination') NULL,
+  MODIFY SKINCOLOR ENUM('Fair', 'Medium', 'Dark') NULL;
@@ -0,0 +1,3 @@
+-- Version columns back the ETag / If-Match optimistic concurrency checks.
+ALTER TABLE members ADD COLUMN VERSION INT NOT NULL DEFAULT 1;
+ALTER TABLE products ADD COLUMN VERSION INT NOT NULL DEFAULT 1;
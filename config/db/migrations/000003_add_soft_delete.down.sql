@@ -0,0 +1,3 @@
+ALTER TABLE review_products DROP INDEX idx_review_products_deleted_at, DROP COLUMN DELETED_AT, DROP COLUMN VERSION;
+ALTER TABLE products DROP INDEX idx_products_deleted_at, DROP COLUMN DELETED_AT;
+ALTER TABLE members DROP INDEX idx_members_deleted_at, DROP COLUMN DELETED_AT;
@@ -0,0 +1,6 @@
+-- Soft deleted rows keep their reviews and likes until they are purged.
+ALTER TABLE members ADD COLUMN DELETED_AT DATETIME NULL, ADD INDEX idx_members_deleted_at (DELETED_AT);
+ALTER TABLE products ADD COLUMN DELETED_AT DATETIME NULL, ADD INDEX idx_products_deleted_at (DELETED_AT);
+ALTER TABLE review_products ADD COLUMN DELETED_AT DATETIME NULL, ADD INDEX idx_review_products_deleted_at (DELETED_AT);
+-- Reviews are deleted and restored with If-Match, like members and products.
+ALTER TABLE review_products ADD COLUMN VERSION INT NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+-- Admin API keys are stored as SHA-256 hashes, the key itself is shown once.
+CREATE TABLE admins (
+  ID_ADMIN INT AUTO_INCREMENT PRIMARY KEY,
+  USERNAME VARCHAR(255) NOT NULL,
+  KEY_HASH CHAR(64) NOT NULL,
+  CREATED_AT DATETIME NOT NULL,
+  UNIQUE INDEX idx_admins_username (USERNAME),
+  UNIQUE INDEX idx_admins_key_hash (KEY_HASH)
+);
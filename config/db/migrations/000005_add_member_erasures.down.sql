@@ -0,0 +1,5 @@
+DROP TABLE member_erasures;
+-- Without ANONYMIZED_AT anonymized members look like live ones, they are
+-- removed together with their reviews and likes.
+DELETE FROM members WHERE ANONYMIZED_AT IS NOT NULL;
+ALTER TABLE members DROP COLUMN ANONYMIZED_AT;
@@ -0,0 +1,18 @@
+-- Anonymized members keep their row, and so their reviews and likes, with
+-- the personal columns cleared.
+ALTER TABLE members
+  MODIFY GENDER ENUM('Male', 'Female') NULL,
+  MODIFY SKINTYPE ENUM('Oily', 'Dry', 'Normal', 'Combination') NULL,
+  MODIFY SKINCOLOR ENUM('Fair', 'Medium', 'Dark') NULL,
+  ADD COLUMN ANONYMIZED_AT DATETIME NULL;
+
+-- The audit trail outlives the members it records, it has no foreign key.
+CREATE TABLE member_erasures (
+  ID_ERASURE INT AUTO_INCREMENT PRIMARY KEY,
+  ID_MEMBER INT NOT NULL,
+  MODE ENUM('delete', 'anonymize') NOT NULL,
+  REQUESTED_BY INT NULL,
+  REQUEST_ID VARCHAR(255) NOT NULL DEFAULT '',
+  CREATED_AT DATETIME NOT NULL,
+  INDEX idx_member_erasures_member (ID_MEMBER)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE review_products DROP INDEX ft_review_products_desc;
+ALTER TABLE products DROP INDEX ft_products_name;
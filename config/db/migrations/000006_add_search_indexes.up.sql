@@ -0,0 +1,3 @@
+-- GET /search ranks product names and review text with these indexes.
+ALTER TABLE products ADD FULLTEXT INDEX ft_products_name (PRODUCT_NAME);
+ALTER TABLE review_products ADD FULLTEXT INDEX ft_review_products_desc (DESC_REVIEW);
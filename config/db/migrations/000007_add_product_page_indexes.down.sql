@@ -0,0 +1,4 @@
+-- MySQL may have dropped the implicit foreign key indexes in favour of the
+-- new ones, so plain ones are added back before those are dropped.
+ALTER TABLE like_reviews ADD INDEX idx_like_reviews_id_review (ID_REVIEW), DROP INDEX idx_like_reviews_review;
+ALTER TABLE review_products ADD INDEX idx_review_products_id_product (ID_PRODUCT), DROP INDEX idx_review_products_product;
@@ -0,0 +1,8 @@
+-- A product page reads the live reviews of a product in ID order, the primary
+-- key riding along in the index, and counts the likes of each review by its
+-- members from the like index alone. The like index is unique, a member likes
+-- a review at most once, so repeats left by concurrent likes are dropped first.
+ALTER TABLE review_products ADD INDEX idx_review_products_product (ID_PRODUCT, DELETED_AT);
+DELETE l FROM like_reviews l
+  JOIN like_reviews k ON k.ID_REVIEW = l.ID_REVIEW AND k.ID_MEMBER = l.ID_MEMBER AND k.ID_LIKE < l.ID_LIKE;
+ALTER TABLE like_reviews ADD UNIQUE INDEX idx_like_reviews_review (ID_REVIEW, ID_MEMBER);
@@ -0,0 +1,15 @@
+-- Background data exports, kept in the database so their download links
+-- survive restarts and work on every instance. An export holds personal
+-- data, it goes when its member is purged.
+CREATE TABLE member_exports (
+  ID_EXPORT CHAR(32) PRIMARY KEY,
+  ID_MEMBER INT NOT NULL,
+  STATUS ENUM('pending', 'ready', 'failed') NOT NULL,
+  CREATED_AT DATETIME NOT NULL,
+  COMPLETED_AT DATETIME NULL,
+  EXPIRES_AT DATETIME NOT NULL,
+  ARCHIVE LONGBLOB NULL,
+  INDEX idx_member_exports_member (ID_MEMBER, CREATED_AT),
+  INDEX idx_member_exports_expires_at (EXPIRES_AT),
+  FOREIGN KEY (ID_MEMBER) REFERENCES members (ID_MEMBER) ON DELETE CASCADE
+);
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+var (
+	logLevels    = []string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"}
+	logEncodings = []string{"json", "console"}
+	logOutputs   = []string{"stdout", "stderr", "file"}
+)
+
+// Validate reports every setting that would stop the server from starting
+// or make it misbehave, in a single error.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.MySQL.Driver {
+	case "", DriverMySQL:
+		if c.MySQL.Host == "" {
+			add("mysql.Host is required")
+		}
+		if c.MySQL.DBName == "" {
+			add("mysql.DBName is required")
+		}
+	case DriverMemory:
+		if c.Server.AdminAuth != nil && *c.Server.AdminAuth {
+			add("server.AdminAuth needs the mysql driver to store admin keys")
+		}
+	default:
+		add("mysql.Driver %q must be %q or %q", c.MySQL.Driver, DriverMySQL, DriverMemory)
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			add("server.TrustedProxies %q is not a CIDR", proxy)
+		}
+	}
+
+	if c.Server.Port == "" {
+		add("server.Port is required")
+	}
+	if c.Server.SSL {
+		for _, file := range [][2]string{{"server.CertFile", c.Server.CertFile}, {"server.KeyFile", c.Server.KeyFile}} {
+			name, path := file[0], file[1]
+			if path == "" {
+				add("%s is required when server.SSL is enabled", name)
+			} else if _, err := os.Stat(path); err != nil {
+				add("%s: %v", name, err)
+			}
+		}
+	}
+
+	if c.Logger.Level != "" && !contains(logLevels, c.Logger.Level) {
+		add("logger.Level %q must be one of %s", c.Logger.Level, strings.Join(logLevels, ", "))
+	}
+	if c.Logger.Encoding != "" && !contains(logEncodings, c.Logger.Encoding) {
+		add("logger.Encoding %q must be one of %s", c.Logger.Encoding, strings.Join(logEncodings, ", "))
+	}
+	for _, output := range c.Logger.Outputs {
+		if !contains(logOutputs, output) {
+			add("logger.Outputs %q must be one of %s", output, strings.Join(logOutputs, ", "))
+		}
+		if output == "file" && c.Logger.File == "" {
+			add("logger.File is required when logging to a file")
+		}
+	}
+
+	if c.RateLimit.Enabled {
+		validatePolicy := func(name string, policy RateLimitPolicy) {
+			if policy.Requests <= 0 || policy.Per <= 0 {
+				add("%s needs positive Requests and Per", name)
+			}
+		}
+		validatePolicy("rateLimit.Default", c.RateLimit.Default)
+		for route, policy := range c.RateLimit.Routes {
+			validatePolicy(fmt.Sprintf("rateLimit.Routes[%q]", route), policy)
+		}
+	}
+
+	if c.RequestLogger.SampleRate < 0 || c.RequestLogger.SampleRate > 1 {
+		add("requestLogger.SampleRate %v must be between 0 and 1", c.RequestLogger.SampleRate)
+	}
+
+	if c.DataExport.SyncLimit < 0 || c.DataExport.Workers < 0 || c.DataExport.Timeout < 0 || c.DataExport.TTL < 0 {
+		add("dataExport settings cannot be negative")
+	}
+
+	switch c.Search.Index {
+	case "", SearchIndexMemory:
+	case SearchIndexFullText:
+		if c.MySQL.Driver == DriverMemory {
+			add("search.Index %q needs the mysql driver", SearchIndexFullText)
+		}
+	default:
+		add("search.Index %q must be %q or %q", c.Search.Index, SearchIndexFullText, SearchIndexMemory)
+	}
+	if c.Search.RefreshInterval < 0 {
+		add("search.RefreshInterval cannot be negative")
+	}
+
+	if c.ProductCache.Enabled && (c.ProductCache.Size <= 0 || c.ProductCache.TTL <= 0) {
+		add("productCache needs positive Size and TTL")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
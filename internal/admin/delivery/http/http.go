@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"social_media/internal/admin/models"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdminHandler struct {
+	logger zap.Logger
+}
+
+func MapAdminRoutes(adminGroup *echo.Group, logger zap.Logger) {
+	h := &AdminHandler{
+		logger: logger,
+	}
+
+	adminGroup.GET("/log-level", h.GetLogLevel)
+	adminGroup.PUT("/log-level", h.SetLogLevel)
+}
+
+// GetLogLevel godoc
+// @Tags Admin
+// @Summary Get log level
+// @Description Get the current minimum log level
+// @ID getLogLevel
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.LogLevel}
+// @Router /admin/log-level [get]
+func (h *AdminHandler) GetLogLevel(c echo.Context) error {
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", &models.LogLevel{Level: h.logger.Level()}))
+}
+
+// SetLogLevel godoc
+// @Tags Admin
+// @Summary Set log level
+// @Description Change the minimum log level at runtime, until the next restart
+// @ID setLogLevel
+// @Accept json
+// @Produce json
+// @Param level body models.LogLevel true "New log level"
+// @Success 200 {object} utils.Response{data=models.LogLevel}
+// @Failure 400 {object} utils.Response
+// @Router /admin/log-level [put]
+func (h *AdminHandler) SetLogLevel(c echo.Context) error {
+	var request models.LogLevel
+	if err := utils.ReadRequest(c, &request); err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	if err := h.logger.SetLevel(request.Level); err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	h.logger.FromContext(utils.GetRequestCtx(c)).Infof("Log level changed to %s", request.Level)
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", &models.LogLevel{Level: h.logger.Level()}))
+}
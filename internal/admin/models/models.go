@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type LogLevel struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn error dpanic panic fatal"`
+}
+
+// Admin holds an admin API key. Only the SHA-256 hash of the key is stored.
+type Admin struct {
+	ID        int       `json:"id" gorm:"column:ID_ADMIN"`
+	Username  string    `json:"username" gorm:"column:USERNAME"`
+	KeyHash   string    `json:"-" gorm:"column:KEY_HASH"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:CREATED_AT"`
+}
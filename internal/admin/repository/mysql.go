@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"social_media/internal/admin/models"
+	"social_media/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type AdminRepository interface {
+	AddAdmin(ctx context.Context, admin *models.Admin) error
+	GetAdminByKeyHash(ctx context.Context, keyHash string) (*models.Admin, error)
+}
+
+type MySQLRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) *MySQLRepository {
+	return &MySQLRepository{db: db}
+}
+
+func (r *MySQLRepository) AddAdmin(ctx context.Context, admin *models.Admin) error {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Admin{}).Where("USERNAME = ?", admin.Username).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utils.ErrAdminAlreadyExists
+	}
+
+	return r.db.WithContext(ctx).Create(admin).Error
+}
+
+// GetAdminByKeyHash returns utils.ErrAdminKeyInvalid when no admin holds
+// the key.
+func (r *MySQLRepository) GetAdminByKeyHash(ctx context.Context, keyHash string) (*models.Admin, error) {
+	var admin models.Admin
+	err := r.db.WithContext(ctx).Where("KEY_HASH = ?", keyHash).First(&admin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrAdminKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"social_media/internal/admin/models"
+	"social_media/internal/admin/repository"
+	"social_media/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// keyBytes is the entropy of an admin API key, hex encoded into 64 characters.
+const keyBytes = 32
+
+type AdminUsecase struct {
+	AdminRepository repository.AdminRepository
+}
+
+func NewAdminUsecase(adminRepository repository.AdminRepository) *AdminUsecase {
+	return &AdminUsecase{AdminRepository: adminRepository}
+}
+
+// CreateAdmin stores a new admin and returns its API key. The key is not
+// stored and cannot be recovered afterwards.
+func (u *AdminUsecase) CreateAdmin(ctx context.Context, username string) (*models.Admin, string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.CreateAdmin")
+	defer span.Finish()
+
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, "", utils.NewValidationError(utils.ValidationFailedCode, "username is required")
+	}
+
+	raw := make([]byte, keyBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	key := hex.EncodeToString(raw)
+
+	admin := &models.Admin{Username: username, KeyHash: hashKey(key), CreatedAt: time.Now()}
+	if err := u.AdminRepository.AddAdmin(ctx, admin); err != nil {
+		return nil, "", err
+	}
+
+	return admin, key, nil
+}
+
+// Authenticate returns the admin holding key, or utils.ErrAdminKeyInvalid.
+func (u *AdminUsecase) Authenticate(ctx context.Context, key string) (*models.Admin, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.Authenticate")
+	defer span.Finish()
+
+	if key == "" {
+		return nil, utils.ErrAdminKeyInvalid
+	}
+	return u.AdminRepository.GetAdminByKeyHash(ctx, hashKey(key))
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"social_media/internal/admin/repository"
+	"social_media/internal/testutil"
+	"social_media/pkg/utils"
+	"testing"
+)
+
+func TestAdminUsecase(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewDatabase(t)
+	u := NewAdminUsecase(repository.NewAdminRepository(db))
+
+	admin, key, err := u.CreateAdmin(ctx, " root ")
+	if err != nil {
+		t.Fatalf("CreateAdmin() error = %v", err)
+	}
+	if admin.Username != "root" || len(key) != 2*keyBytes {
+		t.Fatalf("CreateAdmin() = %q with a %d character key", admin.Username, len(key))
+	}
+
+	var stored string
+	if err := db.Raw("SELECT KEY_HASH FROM admins WHERE USERNAME = ?", "root").Scan(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored == key || stored != hashKey(key) {
+		t.Errorf("stored KEY_HASH = %q, want the hash of the key", stored)
+	}
+
+	if _, _, err := u.CreateAdmin(ctx, "root"); !errors.Is(err, utils.ErrAdminAlreadyExists) {
+		t.Errorf("CreateAdmin() of a taken username error = %v, want ErrAdminAlreadyExists", err)
+	}
+	if _, _, err := u.CreateAdmin(ctx, " "); !errors.Is(err, utils.BadRequest) {
+		t.Errorf("CreateAdmin() without username error = %v, want a validation error", err)
+	}
+
+	authenticated, err := u.Authenticate(ctx, key)
+	if err != nil || authenticated.ID != admin.ID {
+		t.Fatalf("Authenticate() = %+v, %v, want admin %d", authenticated, err, admin.ID)
+	}
+	for _, wrong := range []string{"", key[1:], stored} {
+		if _, err := u.Authenticate(ctx, wrong); !errors.Is(err, utils.ErrAdminKeyInvalid) {
+			t.Errorf("Authenticate(%q) error = %v, want ErrAdminKeyInvalid", wrong, err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"social_media/internal/bulk/models"
+	"social_media/internal/bulk/usecase"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+// contentTypes maps the import and export formats to their media types.
+var contentTypes = map[string]string{
+	models.FormatCSV:    "text/csv; charset=utf-8",
+	models.FormatNDJSON: "application/x-ndjson",
+}
+
+// formatsByMediaType also accepts the other names NDJSON goes by.
+var formatsByMediaType = map[string]string{
+	"text/csv":             models.FormatCSV,
+	"application/csv":      models.FormatCSV,
+	"application/x-ndjson": models.FormatNDJSON,
+	"application/ndjson":   models.FormatNDJSON,
+	"application/jsonl":    models.FormatNDJSON,
+}
+
+type BulkHandler struct {
+	BulkUsecase usecase.BulkUsecaseInterface
+	logger      zap.Logger
+}
+
+func MapBulkAdminRoutes(adminGroup *echo.Group, logger zap.Logger, bulkUsecase usecase.BulkUsecaseInterface) {
+	h := &BulkHandler{
+		logger:      logger,
+		BulkUsecase: bulkUsecase,
+	}
+
+	adminGroup.POST("/members/import", h.ImportMembers)
+	adminGroup.GET("/members/export", h.ExportMembers)
+	adminGroup.POST("/products/import", h.ImportProducts)
+	adminGroup.GET("/products/export", h.ExportProducts)
+}
+
+// ImportMembers godoc
+// @Tags Admin
+// @Summary Import members
+// @Description Create or update members from a CSV or NDJSON file, matching rows by username or id. Invalid rows are skipped and listed in the report. When the import stops on an error, the report of the rows stored so far is returned with it.
+// @ID importMembers
+// @Accept text/csv,application/x-ndjson
+// @Produce json
+// @Param format query string false "csv or ndjson, defaults to the Content-Type" Enums(csv, ndjson)
+// @Param key query string false "Column matching rows to stored members" Enums(username, id) default(username)
+// @Param dryRun query bool false "Validate and report without writing"
+// @Param file body string true "Member rows, CSV with a header line or one JSON object per line"
+// @Success 200 {object} utils.Response{data=models.ImportReport}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response{data=models.ImportReport}
+// @Router /admin/members/import [post]
+func (h *BulkHandler) ImportMembers(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.ImportMembers")
+	defer span.Finish()
+
+	opts, err := readImportOptions(c)
+	if err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	report, err := h.BulkUsecase.ImportMembers(ctx, c.Request().Body, opts)
+	if err != nil {
+		// The rows before the failure are stored, report them
+		if report != nil {
+			return c.JSON(utils.ErrorResponseWithData(c, err, report))
+		}
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", report))
+}
+
+// ImportProducts godoc
+// @Tags Admin
+// @Summary Import products
+// @Description Create or update products from a CSV or NDJSON file, matching rows by id or productName. Invalid rows are skipped and listed in the report. When the import stops on an error, the report of the rows stored so far is returned with it.
+// @ID importProducts
+// @Accept text/csv,application/x-ndjson
+// @Produce json
+// @Param format query string false "csv or ndjson, defaults to the Content-Type" Enums(csv, ndjson)
+// @Param key query string false "Column matching rows to stored products" Enums(id, productName) default(id)
+// @Param dryRun query bool false "Validate and report without writing"
+// @Param file body string true "Product rows, CSV with a header line or one JSON object per line"
+// @Success 200 {object} utils.Response{data=models.ImportReport}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response{data=models.ImportReport}
+// @Router /admin/products/import [post]
+func (h *BulkHandler) ImportProducts(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.ImportProducts")
+	defer span.Finish()
+
+	opts, err := readImportOptions(c)
+	if err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	report, err := h.BulkUsecase.ImportProducts(ctx, c.Request().Body, opts)
+	if err != nil {
+		// The rows before the failure are stored, report them
+		if report != nil {
+			return c.JSON(utils.ErrorResponseWithData(c, err, report))
+		}
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", report))
+}
+
+// ExportMembers godoc
+// @Tags Admin
+// @Summary Export members
+// @Description Download every member as CSV or NDJSON, in the columns accepted by the import
+// @ID exportMembers
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "File format" Enums(csv, ndjson) default(csv)
+// @Success 200 {string} string "Member rows"
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/members/export [get]
+func (h *BulkHandler) ExportMembers(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.ExportMembers")
+	defer span.Finish()
+
+	format, err := readExportFormat(c)
+	if err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	w := newExportWriter(c, "members", format)
+	_, err = h.BulkUsecase.ExportMembers(ctx, w, format)
+	return h.finishExport(c, w, err)
+}
+
+// ExportProducts godoc
+// @Tags Admin
+// @Summary Export products
+// @Description Download every product as CSV or NDJSON, in the columns accepted by the import
+// @ID exportProducts
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "File format" Enums(csv, ndjson) default(csv)
+// @Success 200 {string} string "Product rows"
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/products/export [get]
+func (h *BulkHandler) ExportProducts(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.ExportProducts")
+	defer span.Finish()
+
+	format, err := readExportFormat(c)
+	if err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	w := newExportWriter(c, "products", format)
+	_, err = h.BulkUsecase.ExportProducts(ctx, w, format)
+	return h.finishExport(c, w, err)
+}
+
+// finishExport reports an export error as JSON when nothing was sent yet.
+// Once rows are on the wire the status cannot change, the error is logged
+// and the client sees a cut file.
+func (h *BulkHandler) finishExport(c echo.Context, w *exportWriter, err error) error {
+	if err == nil {
+		if !w.started {
+			w.start()
+		}
+		return nil
+	}
+	if !w.started {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+	h.logger.FromContext(utils.GetRequestCtx(c)).Errorf("export %s interrupted: %v", w.entity, err)
+	return nil
+}
+
+// readImportOptions reads the query parameters, taking the format from the
+// Content-Type when the query does not name it. The body is left unread.
+func readImportOptions(c echo.Context) (models.ImportOptions, error) {
+	var request models.ImportRequest
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &request); err != nil {
+		return models.ImportOptions{}, err
+	}
+
+	format := strings.ToLower(request.Format)
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+		format = formatsByMediaType[mediaType]
+	}
+	if _, ok := contentTypes[format]; !ok {
+		return models.ImportOptions{}, utils.ErrUnsupportedFormat
+	}
+
+	return models.ImportOptions{Format: format, Key: request.Key, DryRun: request.DryRun}, nil
+}
+
+func readExportFormat(c echo.Context) (string, error) {
+	var request models.ExportRequest
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &request); err != nil {
+		return "", err
+	}
+
+	format := strings.ToLower(request.Format)
+	if format == "" {
+		format = models.FormatCSV
+	}
+	if _, ok := contentTypes[format]; !ok {
+		return "", utils.ErrUnsupportedFormat
+	}
+	return format, nil
+}
+
+// exportWriter sends the headers of the download on the first write, so an
+// error before any row is written can still be answered with JSON. Every
+// write is flushed, the usecase writes a page of rows at a time.
+type exportWriter struct {
+	c       echo.Context
+	entity  string
+	format  string
+	started bool
+}
+
+func newExportWriter(c echo.Context, entity string, format string) *exportWriter {
+	return &exportWriter{c: c, entity: entity, format: format}
+}
+
+func (w *exportWriter) start() {
+	header := w.c.Response().Header()
+	header.Set(echo.HeaderContentType, contentTypes[w.format])
+	header.Set(echo.HeaderContentDisposition, `attachment; filename="`+w.entity+"."+w.format+`"`)
+	w.c.Response().WriteHeader(http.StatusOK)
+	w.started = true
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.start()
+	}
+	n, err := w.c.Response().Write(p)
+	if err != nil {
+		return n, err
+	}
+	w.c.Response().Flush()
+	return n, nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import "social_media/pkg/utils"
+
+// Formats accepted by imports and produced by exports
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Import keys: rows are matched to stored rows by KeyID, or by the natural
+// key of the entity, KeyUsername for members and KeyProductName for products.
+const (
+	KeyID          = "id"
+	KeyUsername    = "username"
+	KeyProductName = "productName"
+)
+
+// MemberRecord is a member row of an import or export file. ID is only
+// needed to update by id and Version is exported for reference, imports
+// ignore it. Username may not be FormerMemberUsername, reserved by the
+// member models.
+type MemberRecord struct {
+	ID        int    `json:"id,omitempty" validate:"min=0"`
+	Username  string `json:"username" validate:"required,max=255,neci=Former member"`
+	Gender    string `json:"gender" validate:"required,oneofci=Male Female"`
+	SkinType  string `json:"skinType" validate:"required,oneofci=Oily Dry Normal Combination"`
+	SkinColor string `json:"skinColor" validate:"required,oneofci=Fair Medium Dark"`
+	Version   int    `json:"version,omitempty"`
+}
+
+// MemberColumns are the CSV columns of MemberRecord, in export order.
+var MemberColumns = []string{"id", "username", "gender", "skinType", "skinColor", "version"}
+
+// ProductRecord is a product row of an import or export file.
+type ProductRecord struct {
+	ID      int     `json:"id,omitempty" validate:"min=0"`
+	Name    string  `json:"productName" validate:"required,max=255"`
+	Price   float64 `json:"price" validate:"required,gt=0"`
+	Version int     `json:"version,omitempty"`
+}
+
+// ProductColumns are the CSV columns of ProductRecord, in export order.
+var ProductColumns = []string{"id", "productName", "price", "version"}
+
+// ImportOptions controls an import. Nothing is written in a DryRun, the
+// report shows what would happen.
+type ImportOptions struct {
+	Format string
+	Key    string
+	DryRun bool
+}
+
+// RowError explains why a row was not imported. Row counts data rows from
+// 1, the CSV header and blank NDJSON lines are not counted.
+type RowError struct {
+	Row     int                    `json:"row"`
+	Key     string                 `json:"key,omitempty"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Fields  utils.ValidationErrors `json:"fields,omitempty"`
+}
+
+// ImportReport counts what an import did, or would do in a dry run. Only
+// the first errors are listed, Failed counts them all.
+type ImportReport struct {
+	Entity          string     `json:"entity"`
+	Format          string     `json:"format"`
+	Key             string     `json:"key"`
+	DryRun          bool       `json:"dryRun"`
+	Rows            int        `json:"rows"`
+	Created         int        `json:"created"`
+	Updated         int        `json:"updated"`
+	Unchanged       int        `json:"unchanged"`
+	Failed          int        `json:"failed"`
+	Errors          []RowError `json:"errors"`
+	ErrorsTruncated bool       `json:"errorsTruncated,omitempty"`
+}
+
+// ImportRequest holds the query parameters of an import. Format defaults to
+// the one named by the Content-Type of the body.
+type ImportRequest struct {
+	Format string `query:"format"`
+	Key    string `query:"key"`
+	DryRun bool   `query:"dryRun"`
+}
+
+// ExportRequest holds the query parameters of an export.
+type ExportRequest struct {
+	Format string `query:"format"`
+}
@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"social_media/internal/bulk/models"
+	"social_media/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+// maxLineSize bounds an NDJSON line, a longer one aborts the import.
+const maxLineSize = 1 << 20
+
+// recordReader yields the rows of an import file as JSON objects. A row
+// that cannot be read is returned as rowErr and reading can go on; err ends
+// the import, io.EOF after the last row.
+type recordReader interface {
+	Next() (row []byte, rowErr error, err error)
+}
+
+func newRecordReader(r io.Reader, format string, columns []string) (recordReader, error) {
+	switch format {
+	case models.FormatCSV:
+		return newCSVReader(r, columns)
+	case models.FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64<<10), maxLineSize)
+		return &ndjsonReader{scanner: scanner}, nil
+	default:
+		return nil, utils.ErrUnsupportedFormat
+	}
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *ndjsonReader) Next() ([]byte, error, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '{' || !json.Valid(line) {
+			return nil, utils.NewValidationError(utils.InvalidRowCode, "line is not a JSON object"), nil
+		}
+		return append([]byte(nil), line...), nil, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, nil, utils.NewValidationError(utils.InvalidRowCode, fmt.Sprintf("line longer than %d bytes", maxLineSize))
+		}
+		return nil, nil, err
+	}
+	return nil, nil, io.EOF
+}
+
+// csvReader turns CSV rows into JSON objects keyed by the header. Numeric
+// columns are written as JSON numbers so both formats decode the same way.
+type csvReader struct {
+	reader  *csv.Reader
+	header  []string
+	numeric map[string]bool
+}
+
+// numericColumns are decoded into numbers, empty cells are left out.
+var numericColumns = map[string]bool{"id": true, "price": true, "version": true}
+
+func newCSVReader(r io.Reader, columns []string) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, utils.NewValidationError(utils.InvalidHeaderCode, "file is empty, the first line must name the columns")
+	}
+	if err != nil {
+		return nil, utils.NewValidationError(utils.InvalidHeaderCode, err.Error())
+	}
+
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+	seen := make(map[string]bool, len(header))
+	names := make([]string, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if !known[name] {
+			return nil, utils.NewValidationError(utils.InvalidHeaderCode,
+				fmt.Sprintf("unknown column %q, columns are %s", name, strings.Join(columns, ", ")))
+		}
+		if seen[name] {
+			return nil, utils.NewValidationError(utils.InvalidHeaderCode, fmt.Sprintf("column %q appears twice", name))
+		}
+		seen[name] = true
+		names[i] = name
+	}
+
+	return &csvReader{reader: reader, header: names, numeric: numericColumns}, nil
+}
+
+func (r *csvReader) Next() ([]byte, error, error) {
+	record, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, utils.NewValidationError(utils.InvalidRowCode, parseErr.Err.Error()), nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	object := make(map[string]interface{}, len(record))
+	var fieldErrs utils.ValidationErrors
+	for i, value := range record {
+		column := r.header[i]
+		value = strings.TrimSpace(value)
+		if !r.numeric[column] {
+			object[column] = value
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			fieldErrs = append(fieldErrs, utils.FieldError{Field: column, Message: "must be a number"})
+			continue
+		}
+		object[column] = json.Number(value)
+	}
+	if len(fieldErrs) > 0 {
+		return nil, fieldErrs, nil
+	}
+
+	row, err := json.Marshal(object)
+	return row, nil, err
+}
+
+// decodeRecord decodes a row into record, rejecting unknown fields.
+func decodeRecord(row []byte, record interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(row))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(record)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr):
+		return utils.ValidationErrors{{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()}}
+	default:
+		return utils.NewValidationError(utils.InvalidRowCode, err.Error())
+	}
+}
+
+// recordWriter writes export rows.
+type recordWriter interface {
+	Write(record interface{}) error
+	Flush() error
+}
+
+func newRecordWriter(w io.Writer, format string, columns []string) (recordWriter, error) {
+	switch format {
+	case models.FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: writer, columns: columns}, nil
+	case models.FormatNDJSON:
+		buffered := bufio.NewWriter(w)
+		return &ndjsonWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	default:
+		return nil, utils.ErrUnsupportedFormat
+	}
+}
+
+type ndjsonWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(record interface{}) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.buffered.Flush()
+}
+
+// csvWriter writes the JSON fields of a record in column order, so both
+// formats hold the same values under the same names.
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func (w *csvWriter) Write(record interface{}) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return err
+	}
+
+	row := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		if value, ok := object[column]; ok {
+			row[i] = fmt.Sprint(value)
+		}
+	}
+	return w.writer.Write(row)
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
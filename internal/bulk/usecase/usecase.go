@@ -0,0 +1,444 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"social_media/internal/bulk/models"
+	memberModels "social_media/internal/member/models"
+	memberRepo "social_media/internal/member/repository"
+	productModels "social_media/internal/product/models"
+	productRepo "social_media/internal/product/repository"
+	"social_media/pkg/utils"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	// maxReportedErrors caps the row errors listed in an ImportReport
+	maxReportedErrors = 1000
+	// exportPageSize is the number of rows read per query during an export
+	exportPageSize = 500
+)
+
+type BulkUsecase struct {
+	MemberRepository  memberRepo.MemberRepository
+	ProductRepository productRepo.ProductRepository
+	// ProductCache, when set, is told about every member an import updates
+	ProductCache productRepo.MemberPageCache
+}
+
+type BulkUsecaseInterface interface {
+	ImportMembers(ctx context.Context, r io.Reader, opts models.ImportOptions) (*models.ImportReport, error)
+	ImportProducts(ctx context.Context, r io.Reader, opts models.ImportOptions) (*models.ImportReport, error)
+	ExportMembers(ctx context.Context, w io.Writer, format string) (int, error)
+	ExportProducts(ctx context.Context, w io.Writer, format string) (int, error)
+}
+
+func NewBulkUsecase(memberRepository memberRepo.MemberRepository, productRepository productRepo.ProductRepository) *BulkUsecase {
+	return &BulkUsecase{
+		MemberRepository:  memberRepository,
+		ProductRepository: productRepository,
+	}
+}
+
+// WithProductCache drops the cached product pages showing the members an
+// import updates. Product rows go through ProductRepository, which already
+// invalidates its own cache.
+func (u *BulkUsecase) WithProductCache(cache productRepo.MemberPageCache) *BulkUsecase {
+	u.ProductCache = cache
+	return u
+}
+
+// outcome is what an import did with a row.
+type outcome int
+
+const (
+	created outcome = iota
+	updated
+	unchanged
+)
+
+// rowImporter decodes and stores the rows of one entity. decode returns the
+// record and its import key, empty when the row has none and is created.
+type rowImporter interface {
+	decode(ctx context.Context, row []byte) (record interface{}, key string, err error)
+	upsert(ctx context.Context, record interface{}, dryRun bool) (outcome, error)
+}
+
+// ImportMembers creates or updates a member for every row, matching rows by
+// username (the default) or id. Invalid rows are reported and skipped, the
+// others are stored as they are read unless opts.DryRun is set.
+func (u *BulkUsecase) ImportMembers(ctx context.Context, r io.Reader, opts models.ImportOptions) (*models.ImportReport, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.ImportMembers")
+	defer span.Finish()
+
+	if opts.Key == "" {
+		opts.Key = models.KeyUsername
+	}
+	if opts.Key != models.KeyUsername && opts.Key != models.KeyID {
+		return nil, utils.NewValidationError(utils.InvalidParameterCode, "key must be username or id")
+	}
+
+	return u.importRows(ctx, "members", r, opts, models.MemberColumns, &memberImporter{repo: u.MemberRepository, pages: u.ProductCache, key: opts.Key})
+}
+
+// ImportProducts creates or updates a product for every row, matching rows
+// by id (the default) or productName, like ImportMembers.
+func (u *BulkUsecase) ImportProducts(ctx context.Context, r io.Reader, opts models.ImportOptions) (*models.ImportReport, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.ImportProducts")
+	defer span.Finish()
+
+	if opts.Key == "" {
+		opts.Key = models.KeyID
+	}
+	if opts.Key != models.KeyProductName && opts.Key != models.KeyID {
+		return nil, utils.NewValidationError(utils.InvalidParameterCode, "key must be id or productName")
+	}
+
+	return u.importRows(ctx, "products", r, opts, models.ProductColumns, &productImporter{repo: u.ProductRepository, key: opts.Key})
+}
+
+// importRows streams the rows of r through importer. A row failing with a
+// domain error is reported and skipped; any other error, e.g. a lost
+// database connection, stops the import and is returned with the report so
+// far.
+func (u *BulkUsecase) importRows(ctx context.Context, entity string, r io.Reader, opts models.ImportOptions, columns []string, importer rowImporter) (*models.ImportReport, error) {
+	reader, err := newRecordReader(r, opts.Format, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{
+		Entity: entity,
+		Format: opts.Format,
+		Key:    opts.Key,
+		DryRun: opts.DryRun,
+		Errors: make([]models.RowError, 0),
+	}
+
+	// seen maps the keys already imported to their row, a key appearing
+	// twice would silently overwrite the first row
+	seen := make(map[string]int)
+
+	for {
+		row, rowErr, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		report.Rows++
+		if rowErr != nil {
+			addRowError(report, report.Rows, "", rowErr)
+			continue
+		}
+
+		record, key, err := importer.decode(ctx, row)
+		if err != nil {
+			addRowError(report, report.Rows, key, err)
+			continue
+		}
+		if key != "" {
+			if first, ok := seen[key]; ok {
+				addRowError(report, report.Rows, key, utils.NewValidationError(utils.DuplicateKeyCode, fmt.Sprintf("%s %q was already imported by row %d", opts.Key, key, first)))
+				continue
+			}
+			seen[key] = report.Rows
+		}
+
+		result, err := importer.upsert(ctx, record, opts.DryRun)
+		if err != nil {
+			if !isRowError(err) {
+				return report, err
+			}
+			addRowError(report, report.Rows, key, err)
+			continue
+		}
+
+		switch result {
+		case created:
+			report.Created++
+		case updated:
+			report.Updated++
+		case unchanged:
+			report.Unchanged++
+		}
+	}
+}
+
+// isRowError reports whether err is about the row itself rather than the
+// storage behind it.
+func isRowError(err error) bool {
+	var (
+		domainErr *utils.DomainError
+		fieldErrs utils.ValidationErrors
+	)
+	return errors.As(err, &domainErr) || errors.As(err, &fieldErrs)
+}
+
+func addRowError(report *models.ImportReport, row int, key string, err error) {
+	report.Failed++
+	if len(report.Errors) == maxReportedErrors {
+		report.ErrorsTruncated = true
+		return
+	}
+
+	restErr := utils.ParseError(err)
+	rowErr := models.RowError{Row: row, Key: key, Code: restErr.ErrorCode(), Message: fmt.Sprint(restErr.Cause())}
+	var fieldErrs utils.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		rowErr.Fields = fieldErrs
+	}
+	report.Errors = append(report.Errors, rowErr)
+}
+
+type memberImporter struct {
+	repo  memberRepo.MemberRepository
+	pages productRepo.MemberPageCache
+	key   string
+}
+
+func (i *memberImporter) decode(ctx context.Context, row []byte) (interface{}, string, error) {
+	var record models.MemberRecord
+	if err := decodeRecord(row, &record); err != nil {
+		return nil, "", err
+	}
+
+	key := record.Username
+	if i.key == models.KeyID {
+		key = ""
+		if record.ID != 0 {
+			key = strconv.Itoa(record.ID)
+		}
+	}
+
+	if err := utils.ValidateStruct(ctx, &record); err != nil {
+		return nil, key, err
+	}
+
+	// oneofci accepted the attributes, store their canonical spelling
+	record.Username = strings.TrimSpace(record.Username)
+	record.Gender, _ = memberModels.Normalize(record.Gender, memberModels.Genders)
+	record.SkinType, _ = memberModels.Normalize(record.SkinType, memberModels.SkinTypes)
+	record.SkinColor, _ = memberModels.Normalize(record.SkinColor, memberModels.SkinColors)
+
+	return &record, key, nil
+}
+
+func (i *memberImporter) upsert(ctx context.Context, record interface{}, dryRun bool) (outcome, error) {
+	row := record.(*models.MemberRecord)
+	member := &memberModels.Member{
+		Username:  row.Username,
+		Gender:    row.Gender,
+		SkinType:  row.SkinType,
+		SkinColor: row.SkinColor,
+	}
+
+	var (
+		existing *memberModels.Member
+		err      error
+	)
+	if i.key == models.KeyUsername {
+		existing, err = i.repo.GetMemberByUsername(ctx, row.Username)
+	} else if row.ID != 0 {
+		existing, err = i.repo.GetMemberByID(ctx, row.ID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if existing == nil {
+		if dryRun {
+			return created, i.checkUsername(ctx, member.Username, 0)
+		}
+		return created, i.repo.AddNewMember(ctx, member)
+	}
+
+	if existing.Username == member.Username && existing.Gender == member.Gender &&
+		existing.SkinType == member.SkinType && existing.SkinColor == member.SkinColor {
+		return unchanged, nil
+	}
+	if dryRun {
+		return updated, i.checkUsername(ctx, member.Username, existing.ID)
+	}
+
+	member.Version = existing.Version
+	if _, err := i.repo.UpdateMemberByID(ctx, member, existing.ID); err != nil {
+		return updated, err
+	}
+	if i.pages != nil {
+		i.pages.InvalidateMember(ctx, existing.ID)
+	}
+	return updated, nil
+}
+
+// checkUsername reports the conflict a write would hit when another live
+// member uses username.
+func (i *memberImporter) checkUsername(ctx context.Context, username string, exceptID int) error {
+	other, err := i.repo.GetMemberByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if other != nil && other.ID != exceptID {
+		return utils.ErrUsernameAlreadyExists
+	}
+	return nil
+}
+
+type productImporter struct {
+	repo productRepo.ProductRepository
+	key  string
+}
+
+func (i *productImporter) decode(ctx context.Context, row []byte) (interface{}, string, error) {
+	var record models.ProductRecord
+	if err := decodeRecord(row, &record); err != nil {
+		return nil, "", err
+	}
+
+	record.Name = strings.TrimSpace(record.Name)
+	key := record.Name
+	if i.key == models.KeyID {
+		key = ""
+		if record.ID != 0 {
+			key = strconv.Itoa(record.ID)
+		}
+	}
+
+	if err := utils.ValidateStruct(ctx, &record); err != nil {
+		return nil, key, err
+	}
+	return &record, key, nil
+}
+
+func (i *productImporter) upsert(ctx context.Context, record interface{}, dryRun bool) (outcome, error) {
+	row := record.(*models.ProductRecord)
+	product := &productModels.Product{Name: row.Name, Price: row.Price}
+
+	var (
+		existing *productModels.Product
+		err      error
+	)
+	if i.key == models.KeyProductName {
+		existing, err = i.repo.GetProductByName(ctx, row.Name)
+		if errors.Is(err, utils.ErrProductNotFound) {
+			existing, err = nil, nil
+		}
+	} else if row.ID != 0 {
+		existing, err = i.repo.GetProductByID(ctx, row.ID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if existing == nil {
+		if dryRun {
+			return created, nil
+		}
+		return created, i.repo.CreateProduct(ctx, product)
+	}
+
+	if existing.Name == product.Name && existing.Price == product.Price {
+		return unchanged, nil
+	}
+	if dryRun {
+		return updated, nil
+	}
+
+	product.Version = existing.Version
+	_, err = i.repo.UpdateProductByID(ctx, product, existing.ID)
+	return updated, err
+}
+
+// ExportMembers writes every live member in ID order and returns how many
+// were written. Rows are read and written a page at a time, so any number
+// of members is exported in constant memory.
+func (u *BulkUsecase) ExportMembers(ctx context.Context, w io.Writer, format string) (int, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.ExportMembers")
+	defer span.Finish()
+
+	writer, err := newRecordWriter(w, format, models.MemberColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	exported, afterID := 0, 0
+	for {
+		members, err := u.MemberRepository.GetMembersAfter(ctx, afterID, exportPageSize)
+		if err != nil {
+			return exported, err
+		}
+
+		for _, member := range members {
+			err := writer.Write(&models.MemberRecord{
+				ID:        member.ID,
+				Username:  member.Username,
+				Gender:    member.Gender,
+				SkinType:  member.SkinType,
+				SkinColor: member.SkinColor,
+				Version:   member.Version,
+			})
+			if err != nil {
+				return exported, err
+			}
+			exported++
+			afterID = member.ID
+		}
+
+		if err := writer.Flush(); err != nil {
+			return exported, err
+		}
+		if len(members) < exportPageSize {
+			return exported, nil
+		}
+	}
+}
+
+// ExportProducts writes every live product in ID order, like ExportMembers.
+func (u *BulkUsecase) ExportProducts(ctx context.Context, w io.Writer, format string) (int, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.ExportProducts")
+	defer span.Finish()
+
+	writer, err := newRecordWriter(w, format, models.ProductColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	exported, afterID := 0, 0
+	for {
+		products, err := u.ProductRepository.GetProductsAfter(ctx, afterID, exportPageSize)
+		if err != nil {
+			return exported, err
+		}
+
+		for _, product := range products {
+			err := writer.Write(&models.ProductRecord{
+				ID:      product.ID,
+				Name:    product.Name,
+				Price:   product.Price,
+				Version: product.Version,
+			})
+			if err != nil {
+				return exported, err
+			}
+			exported++
+			afterID = product.ID
+		}
+
+		if err := writer.Flush(); err != nil {
+			return exported, err
+		}
+		if len(products) < exportPageSize {
+			return exported, nil
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"social_media/internal/bulk/models"
+	memberModels "social_media/internal/member/models"
+	memberRepo "social_media/internal/member/repository"
+	productModels "social_media/internal/product/models"
+	productRepo "social_media/internal/product/repository"
+	"social_media/pkg/utils"
+	"strings"
+	"testing"
+)
+
+func newTestUsecase(members ...*memberModels.Member) (*BulkUsecase, *memberRepo.MemoryRepository, *productRepo.MemoryProductRepository) {
+	memberRepository := memberRepo.NewMemoryMemberRepository(members...)
+	productRepository := productRepo.NewMemoryProductRepository(memberRepository)
+	productRepository.AddProduct(&productModels.Product{ID: 1, Name: "Serum", Price: 25, Version: 1})
+	productRepository.AddProduct(&productModels.Product{ID: 2, Name: "Toner", Price: 12.5, Version: 1})
+	return NewBulkUsecase(memberRepository, productRepository), memberRepository, productRepository
+}
+
+func testMembers() []*memberModels.Member {
+	return []*memberModels.Member{
+		{ID: 1, Username: "alice", Gender: "Female", SkinType: "Dry", SkinColor: "Fair", Version: 1},
+		{ID: 2, Username: "bob", Gender: "Male", SkinType: "Oily", SkinColor: "Dark", Version: 1},
+	}
+}
+
+// counts strips a report down to what the tests compare.
+func counts(r *models.ImportReport) [5]int {
+	return [5]int{r.Rows, r.Created, r.Updated, r.Unchanged, r.Failed}
+}
+
+func TestImportMembers(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   models.ImportOptions
+		input  string
+		counts [5]int // rows, created, updated, unchanged, failed
+		codes  []string
+	}{
+		{
+			name: "csv by username",
+			opts: models.ImportOptions{Format: models.FormatCSV},
+			input: "username,gender,skinType,skinColor\n" +
+				"alice,female,DRY,fair\n" +
+				"bob,Male,Normal,Dark\n" +
+				"carol,Female,Combination,Medium\n",
+			counts: [5]int{3, 1, 1, 1, 0},
+		},
+		{
+			name: "ndjson by id",
+			opts: models.ImportOptions{Format: models.FormatNDJSON, Key: models.KeyID},
+			input: `{"id":1,"username":"alicia","gender":"Female","skinType":"Dry","skinColor":"Fair"}` + "\n\n" +
+				`{"username":"carol","gender":"Female","skinType":"Normal","skinColor":"Medium"}` + "\n" +
+				`{"id":42,"username":"dave","gender":"Male","skinType":"Normal","skinColor":"Medium"}` + "\n",
+			counts: [5]int{3, 1, 1, 0, 1},
+			codes:  []string{utils.MemberNotFoundCode},
+		},
+		{
+			name: "invalid rows",
+			opts: models.ImportOptions{Format: models.FormatCSV},
+			input: "id,username,gender,skinType,skinColor\n" +
+				"x,carol,Female,Dry,Fair\n" +
+				",dave,robot,Dry,Fair\n" +
+				",\"unterminated,Female,Dry,Fair\n",
+			counts: [5]int{3, 0, 0, 0, 3},
+			codes:  []string{utils.ValidationFailedCode, utils.ValidationFailedCode, utils.InvalidRowCode},
+		},
+		{
+			name: "duplicate keys and conflicts",
+			opts: models.ImportOptions{Format: models.FormatNDJSON, Key: models.KeyID},
+			input: `{"id":2,"username":"bobby","gender":"Male","skinType":"Oily","skinColor":"Dark"}` + "\n" +
+				`{"id":2,"username":"robert","gender":"Male","skinType":"Oily","skinColor":"Dark"}` + "\n" +
+				`{"username":"alice","gender":"Female","skinType":"Dry","skinColor":"Fair"}` + "\n" +
+				`[1, 2]` + "\n" +
+				`{"username":"erin","gender":"Female","skinType":"Dry","skinColor":"Fair","age":30}` + "\n",
+			counts: [5]int{5, 0, 1, 0, 4},
+			codes:  []string{utils.DuplicateKeyCode, utils.UsernameTakenCode, utils.InvalidRowCode, utils.InvalidRowCode},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, dryRun := range []bool{true, false} {
+				u, members, _ := newTestUsecase(testMembers()...)
+				opts := tt.opts
+				opts.DryRun = dryRun
+
+				report, err := u.ImportMembers(context.Background(), strings.NewReader(tt.input), opts)
+				if err != nil {
+					t.Fatalf("ImportMembers(dryRun %v) error = %v", dryRun, err)
+				}
+				if counts(report) != tt.counts {
+					t.Errorf("ImportMembers(dryRun %v) counts = %v, want %v, errors %+v", dryRun, counts(report), tt.counts, report.Errors)
+				}
+				var codes []string
+				for _, rowErr := range report.Errors {
+					codes = append(codes, rowErr.Code)
+				}
+				if fmt.Sprint(codes) != fmt.Sprint(tt.codes) {
+					t.Errorf("ImportMembers(dryRun %v) error codes = %v, want %v", dryRun, codes, tt.codes)
+				}
+
+				all, _ := members.GetAllMembers(context.Background())
+				written := len(all) - 2
+				if dryRun && written != 0 || !dryRun && written != tt.counts[1] {
+					t.Errorf("ImportMembers(dryRun %v) added %d members, report says %d", dryRun, written, tt.counts[1])
+				}
+			}
+		})
+	}
+}
+
+func TestImportMembersNormalizesAndUpdates(t *testing.T) {
+	ctx := context.Background()
+	u, members, _ := newTestUsecase(testMembers()...)
+
+	input := "username,gender,skinType,skinColor\nbob,male,normal,DARK\n"
+	if _, err := u.ImportMembers(ctx, strings.NewReader(input), models.ImportOptions{Format: models.FormatCSV}); err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err := members.GetMemberByID(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.SkinType != "Normal" || bob.Gender != "Male" || bob.SkinColor != "Dark" || bob.Version != 2 {
+		t.Errorf("member = %+v, want normalized attributes at version 2", bob)
+	}
+}
+
+func TestImportRejectsBadInput(t *testing.T) {
+	ctx := context.Background()
+	u, _, _ := newTestUsecase(testMembers()...)
+
+	tests := []struct {
+		name  string
+		opts  models.ImportOptions
+		input string
+		code  string
+	}{
+		{"unknown format", models.ImportOptions{Format: "xml"}, "", utils.UnsupportedFormatCode},
+		{"unknown key", models.ImportOptions{Format: models.FormatCSV, Key: "gender"}, "", utils.InvalidParameterCode},
+		{"empty csv", models.ImportOptions{Format: models.FormatCSV}, "", utils.InvalidHeaderCode},
+		{"unknown column", models.ImportOptions{Format: models.FormatCSV}, "username,age\n", utils.InvalidHeaderCode},
+		{"repeated column", models.ImportOptions{Format: models.FormatCSV}, "username,gender,username\n", utils.InvalidHeaderCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := u.ImportMembers(ctx, strings.NewReader(tt.input), tt.opts)
+			if code := utils.ParseError(err).ErrorCode(); code != tt.code {
+				t.Errorf("ImportMembers() error = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestImportProducts(t *testing.T) {
+	ctx := context.Background()
+	u, _, products := newTestUsecase()
+
+	input := "productName,price\n" +
+		"Serum,25\n" +
+		"Toner,14\n" +
+		"Sunscreen,18.5\n" +
+		"Mask,-1\n"
+	report, err := u.ImportProducts(ctx, strings.NewReader(input), models.ImportOptions{Format: models.FormatCSV, Key: models.KeyProductName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [5]int{4, 1, 1, 1, 1}; counts(report) != want {
+		t.Errorf("counts = %v, want %v, errors %+v", counts(report), want, report.Errors)
+	}
+
+	toner, err := products.GetProductByName(ctx, "Toner")
+	if err != nil || toner.Price != 14 || toner.Version != 2 {
+		t.Errorf("updated product = %+v, %v, want price 14 at version 2", toner, err)
+	}
+	if _, err := products.GetProductByName(ctx, "Sunscreen"); err != nil {
+		t.Errorf("created product error = %v", err)
+	}
+
+	report, err = u.ImportProducts(ctx, strings.NewReader(`{"id":9,"productName":"Mask","price":3}`), models.ImportOptions{Format: models.FormatNDJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Failed != 1 || report.Errors[0].Code != utils.ProductNotFoundCode {
+		t.Errorf("import of an unknown id = %+v, want PRODUCT_NOT_FOUND", report)
+	}
+}
+
+func TestImportReportIsCapped(t *testing.T) {
+	u, _, _ := newTestUsecase()
+
+	var input bytes.Buffer
+	input.WriteString("productName,price\n")
+	for i := 0; i < maxReportedErrors+5; i++ {
+		input.WriteString("Broken,0\n")
+	}
+
+	report, err := u.ImportProducts(context.Background(), &input, models.ImportOptions{Format: models.FormatCSV})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Failed != maxReportedErrors+5 || len(report.Errors) != maxReportedErrors || !report.ErrorsTruncated {
+		t.Errorf("report failed %d, listed %d, truncated %v", report.Failed, len(report.Errors), report.ErrorsTruncated)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	// More than a page of members, so the export pages through them
+	var members []*memberModels.Member
+	for i := 1; i <= exportPageSize+20; i++ {
+		members = append(members, &memberModels.Member{ID: i, Username: fmt.Sprintf("member%d", i), Gender: "Female", SkinType: "Dry", SkinColor: "Fair", Version: 1})
+	}
+
+	for _, format := range []string{models.FormatCSV, models.FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			u, memberRepository, _ := newTestUsecase(members...)
+			if err := memberRepository.EraseMemberByID(ctx, &memberModels.Erasure{MemberID: 3, Mode: memberModels.ErasureDelete}, 1); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			exported, err := u.ExportMembers(ctx, &out, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exported != len(members)-1 {
+				t.Errorf("ExportMembers() = %d, want %d without the deleted member", exported, len(members)-1)
+			}
+
+			report, err := u.ImportMembers(ctx, &out, models.ImportOptions{Format: format, Key: models.KeyID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := [5]int{exported, 0, 0, exported, 0}; counts(report) != want {
+				t.Errorf("re-import counts = %v, want %v, errors %+v", counts(report), want, report.Errors)
+			}
+
+			var products bytes.Buffer
+			if _, err := u.ExportProducts(ctx, &products, format); err != nil {
+				t.Fatal(err)
+			}
+			report, err = u.ImportProducts(ctx, &products, models.ImportOptions{Format: format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := [5]int{2, 0, 0, 2, 0}; counts(report) != want {
+				t.Errorf("product re-import counts = %v, want %v", counts(report), want)
+			}
+		})
+	}
+
+	u, _, _ := newTestUsecase()
+	if _, err := u.ExportProducts(ctx, &bytes.Buffer{}, "xml"); !errors.Is(err, utils.ErrUnsupportedFormat) {
+		t.Errorf("ExportProducts(xml) error = %v, want ErrUnsupportedFormat", err)
+	}
+}
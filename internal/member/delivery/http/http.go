@@ -1,11 +1,12 @@
 package http
 
 import (
+	"io/ioutil"
 	"net/http"
 	"social_media/internal/member/models"
 	"social_media/internal/member/usecase"
 	"social_media/pkg/utils"
-	"strconv"
+	"strings"
 
 	"social_media/pkg/zap"
 
@@ -14,23 +15,35 @@ import (
 )
 
 type MemberHandler struct {
-	MemberUsecase *usecase.MemberUsecase
+	MemberUsecase usecase.MemberUsecaseInterface
 	logger        zap.Logger
 }
 
-func MapMemberRoute(MemberGroup *echo.Group, logger zap.Logger, memberUsecase *usecase.MemberUsecase) {
+func MapMemberRoute(MemberGroup *echo.Group, logger zap.Logger, memberUsecase usecase.MemberUsecaseInterface) {
 	h := MemberHandler{
 		logger:        logger,
 		MemberUsecase: memberUsecase,
 	}
 
 	MemberGroup.GET("/all", h.GetAllMembers)
+	MemberGroup.GET("/attributes", h.GetMemberAttributes)
 	MemberGroup.GET("/:id", h.GetMemberByID)
 	MemberGroup.POST("/", h.AddNewMember)
 	MemberGroup.PUT("/:id", h.UpdateMember)
+	MemberGroup.PATCH("/:id", h.PatchMember)
 	MemberGroup.DELETE("/:id", h.DeleteMember)
 }
 
+func MapMemberAdminRoute(adminGroup *echo.Group, logger zap.Logger, memberUsecase usecase.MemberUsecaseInterface) {
+	h := MemberHandler{
+		logger:        logger,
+		MemberUsecase: memberUsecase,
+	}
+
+	adminGroup.POST("/members/:id/restore", h.RestoreMember)
+	adminGroup.GET("/members/:id/erasures", h.GetMemberErasures)
+}
+
 // GetAllMembers godoc
 // @Tags Member
 // @Summary Get all members
@@ -48,35 +61,60 @@ func (h *MemberHandler) GetAllMembers(c echo.Context) error {
 	result, err := h.MemberUsecase.GetAllMember(ctx)
 
 	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.GetAllMembers", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
 	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", result))
 }
 
+// GetMemberAttributes godoc
+// @Tags Member
+// @Summary Get member attribute values
+// @Description Get the allowed values for gender, skin type and skin color
+// @ID getMemberAttributes
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.MemberAttributes}
+// @Router /members/attributes [get]
+func (h *MemberHandler) GetMemberAttributes(c echo.Context) error {
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", models.AllowedMemberAttributes()))
+}
+
 // GetMemberByID godoc
 // @Tags Member
 // @Summary Get member by ID
 // @Description Get a member by their ID
 // @ID getMemberByID
 // @Param id path int true "Member ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Produce json
 // @Success 200 {object} utils.Response{data=models.Member}
+// @Success 304
 // @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /members/{id} [get]
 func (h *MemberHandler) GetMemberByID(c echo.Context) error {
 	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.GetMemberByID")
 	defer span.Finish()
 
-	id := c.Param("id")
-	newId, _ := strconv.Atoi(id)
-	result, err := h.MemberUsecase.GetMemberByID(ctx, newId)
+	var request models.MemberIDRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.GetMemberByID", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	result, err := h.MemberUsecase.GetMemberByID(ctx, request.ID)
 
 	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.GetMemberByID", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
+	if utils.NotModified(c, utils.VersionETag(result.Version)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", result))
 }
 
@@ -87,23 +125,26 @@ func (h *MemberHandler) GetMemberByID(c echo.Context) error {
 // @ID addNewMember
 // @Accept json
 // @Produce json
-// @Param member body models.Member true "Member object"
+// @Param member body models.MemberRequest true "Member object"
 // @Success 200 {object} utils.Response
 // @Failure 400 {object} utils.Response
+// @Failure 409 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /members/ [post]
 func (h *MemberHandler) AddNewMember(c echo.Context) error {
 	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.AddNewMember")
 	defer span.Finish()
 
-	var member models.Member
-	if err := c.Bind(&member); err != nil {
+	var request models.MemberRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.AddNewMember", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
-	err := h.MemberUsecase.AddNewMember(ctx, &member)
+	err := h.MemberUsecase.AddNewMember(ctx, request.ToMember())
 
 	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.AddNewMember", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
@@ -113,60 +154,214 @@ func (h *MemberHandler) AddNewMember(c echo.Context) error {
 // UpdateMember godoc
 // @Tags Member
 // @Summary Update member
-// @Description Update an existing member
+// @Description Replace every field of an existing member
 // @ID updateMember
 // @Param id path int true "Member ID"
 // @Accept json
 // @Produce json
-// @Param member body models.Member true "Member object"
-// @Success 200 {object} utils.Response
+// @Param member body models.MemberRequest true "Member object"
+// @Param If-Match header string true "ETag of the member being replaced"
+// @Success 200 {object} utils.Response{data=models.Member}
 // @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 412 {object} utils.Response
+// @Failure 428 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /members/{id} [put]
 func (h *MemberHandler) UpdateMember(c echo.Context) error {
 	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.UpdateMember")
 	defer span.Finish()
 
-	id := c.Param("id")
-	newId, _ := strconv.Atoi(id)
+	var request models.UpdateMemberRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.UpdateMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
 
-	var member models.Member
-	if err := c.Bind(&member); err != nil {
+	version, err := utils.IfMatchVersion(c)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.UpdateMember", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
-	err := h.MemberUsecase.UpdateMember(ctx, newId, &member)
+	member := request.ToMember()
+	member.Version = version
+
+	result, err := h.MemberUsecase.UpdateMember(ctx, request.ID, member)
 
 	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.UpdateMember", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
-	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", nil))
+	c.Response().Header().Set(utils.HeaderETag, utils.VersionETag(result.Version))
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", result))
+}
+
+// PatchMember godoc
+// @Tags Member
+// @Summary Partially update member
+// @Description Update an existing member with a JSON Merge Patch (RFC 7396). Omitted fields are kept, every field is required so a null is rejected with 400
+// @ID patchMember
+// @Param id path int true "Member ID"
+// @Accept json,application/merge-patch+json
+// @Produce json
+// @Param member body models.MemberRequest true "Fields to change"
+// @Param If-Match header string true "ETag of the member being changed"
+// @Success 200 {object} utils.Response{data=models.Member}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 415 {object} utils.Response
+// @Failure 412 {object} utils.Response
+// @Failure 428 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /members/{id} [patch]
+func (h *MemberHandler) PatchMember(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.PatchMember")
+	defer span.Finish()
+
+	var request models.MemberIDRequest
+	if err := utils.ReadPathParams(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.PatchMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	version, err := utils.IfMatchVersion(c)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.PatchMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	if !strings.HasPrefix(contentType, utils.MIMEApplicationMergePatchJSON) && !strings.HasPrefix(contentType, echo.MIMEApplicationJSON) {
+		return c.JSON(utils.ErrorResponse(c, echo.ErrUnsupportedMediaType))
+	}
+
+	patch, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.PatchMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	result, err := h.MemberUsecase.PatchMember(ctx, request.ID, version, patch)
+
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.PatchMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	c.Response().Header().Set(utils.HeaderETag, utils.VersionETag(result.Version))
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", result))
 }
 
 // DeleteMember godoc
 // @Tags Member
 // @Summary Delete member
-// @Description Delete a member by ID
+// @Description Delete a member by ID. mode=delete (default) removes their reviews and likes once the member is purged, mode=anonymize clears their personal data and keeps their reviews and likes under "Former member". The choice is recorded in the erasure audit trail.
 // @ID deleteMember
 // @Param id path int true "Member ID"
+// @Param mode query string false "Erasure mode" Enums(delete, anonymize)
+// @Param If-Match header string true "ETag of the member being deleted"
 // @Produce json
 // @Success 200 {object} utils.Response
 // @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 412 {object} utils.Response
+// @Failure 428 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /members/{id} [delete]
 func (h *MemberHandler) DeleteMember(c echo.Context) error {
 	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.DeleteMember")
 	defer span.Finish()
 
-	id := c.Param("id")
-	newId, _ := strconv.Atoi(id)
+	var request models.DeleteMemberRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	version, err := utils.IfMatchVersion(c)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
 
-	err := h.MemberUsecase.DeleteMember(ctx, newId)
+	err = h.MemberUsecase.DeleteMember(ctx, request.ID, version, request.Mode)
 
 	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteMember", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
 	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", nil))
 }
+
+// RestoreMember godoc
+// @Tags Admin
+// @Summary Restore member
+// @Description Restore a soft deleted member by ID
+// @ID restoreMember
+// @Param id path int true "Member ID"
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.Member}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/members/{id}/restore [post]
+func (h *MemberHandler) RestoreMember(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.RestoreMember")
+	defer span.Finish()
+
+	var request models.MemberIDRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.RestoreMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	result, err := h.MemberUsecase.RestoreMember(ctx, request.ID)
+
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.RestoreMember", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	c.Response().Header().Set(utils.HeaderETag, utils.VersionETag(result.Version))
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", result))
+}
+
+// GetMemberErasures godoc
+// @Tags Admin
+// @Summary Get member erasures
+// @Description List the audit trail of a member's account deletions, oldest first. It is kept after the member is purged.
+// @ID getMemberErasures
+// @Param id path int true "Member ID"
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]models.Erasure}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/members/{id}/erasures [get]
+func (h *MemberHandler) GetMemberErasures(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.GetMemberErasures")
+	defer span.Finish()
+
+	var request models.MemberIDRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.GetMemberErasures", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	result, err := h.MemberUsecase.GetErasures(ctx, request.ID)
+
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.GetMemberErasures", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", result))
+}
@@ -0,0 +1,311 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social_media/config"
+	"social_media/internal/member/mock"
+	"social_media/internal/member/models"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+type testRequest struct {
+	method      string
+	target      string
+	body        string
+	contentType string
+	headers     map[string]string
+}
+
+type wantResponse struct {
+	status    int
+	errorCode string
+	etag      string
+}
+
+func newTestServer(t *testing.T, setup func(uc *mock.MockMemberUsecaseInterface)) *echo.Echo {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	uc := mock.NewMockMemberUsecaseInterface(ctrl)
+	if setup != nil {
+		setup(uc)
+	}
+
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	cfg.Logger.Encoding = "json"
+	logger := zap.NewAppLogger(cfg)
+	logger.InitLogger()
+
+	e := echo.New()
+	e.Validator = utils.NewValidator()
+	MapMemberRoute(e.Group("/members"), logger, uc)
+	MapMemberAdminRoute(e.Group("/admin"), logger, uc)
+	return e
+}
+
+func serve(e *echo.Echo, tr testRequest) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(tr.method, tr.target, strings.NewReader(tr.body))
+	if tr.body != "" {
+		contentType := tr.contentType
+		if contentType == "" {
+			contentType = echo.MIMEApplicationJSON
+		}
+		req.Header.Set(echo.HeaderContentType, contentType)
+	}
+	for key, value := range tr.headers {
+		req.Header.Set(key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func assertResponse(t *testing.T, rec *httptest.ResponseRecorder, want wantResponse) {
+	t.Helper()
+
+	if rec.Code != want.status {
+		t.Fatalf("status = %d, want %d, body %s", rec.Code, want.status, rec.Body.String())
+	}
+	if want.etag != "" && rec.Header().Get(utils.HeaderETag) != want.etag {
+		t.Errorf("ETag = %q, want %q", rec.Header().Get(utils.HeaderETag), want.etag)
+	}
+	if rec.Code == http.StatusNotModified {
+		return
+	}
+
+	var response utils.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("body is not a utils.Response: %v", err)
+	}
+	if response.ResponseCode != want.status {
+		t.Errorf("response.code = %d, want %d", response.ResponseCode, want.status)
+	}
+	if response.ErrorCode != want.errorCode {
+		t.Errorf("response.errorCode = %q, want %q", response.ErrorCode, want.errorCode)
+	}
+}
+
+func testMember() *models.Member {
+	return &models.Member{ID: 1, Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair", Version: 2}
+}
+
+func TestMemberHandler(t *testing.T) {
+	validBody := `{"username":"User1","gender":"male","skinType":"Oily","skinColor":"Fair"}`
+	stored := &models.Member{Username: "User1", Gender: "male", SkinType: "Oily", SkinColor: "Fair"}
+
+	tests := []struct {
+		name    string
+		request testRequest
+		setup   func(uc *mock.MockMemberUsecaseInterface)
+		want    wantResponse
+	}{
+		{
+			name:    "get all members",
+			request: testRequest{method: http.MethodGet, target: "/members/all"},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().GetAllMember(gomock.Any()).Return([]*models.Member{testMember()}, nil)
+			},
+			want: wantResponse{status: http.StatusOK},
+		},
+		{
+			name:    "get member attributes",
+			request: testRequest{method: http.MethodGet, target: "/members/attributes"},
+			want:    wantResponse{status: http.StatusOK},
+		},
+		{
+			name:    "get member by id",
+			request: testRequest{method: http.MethodGet, target: "/members/1"},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().GetMemberByID(gomock.Any(), 1).Return(testMember(), nil)
+			},
+			want: wantResponse{status: http.StatusOK, etag: `"2"`},
+		},
+		{
+			name:    "get member by id not modified",
+			request: testRequest{method: http.MethodGet, target: "/members/1", headers: map[string]string{utils.HeaderIfNoneMatch: `"2"`}},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().GetMemberByID(gomock.Any(), 1).Return(testMember(), nil)
+			},
+			want: wantResponse{status: http.StatusNotModified, etag: `"2"`},
+		},
+		{
+			name:    "get member by non numeric id",
+			request: testRequest{method: http.MethodGet, target: "/members/abc"},
+			want:    wantResponse{status: http.StatusBadRequest, errorCode: utils.InvalidParameterCode},
+		},
+		{
+			name:    "get member by id not found",
+			request: testRequest{method: http.MethodGet, target: "/members/9"},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().GetMemberByID(gomock.Any(), 9).Return(nil, utils.ErrMemberNotFound)
+			},
+			want: wantResponse{status: http.StatusNotFound, errorCode: utils.MemberNotFoundCode},
+		},
+		{
+			name:    "add member",
+			request: testRequest{method: http.MethodPost, target: "/members/", body: validBody},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().AddNewMember(gomock.Any(), stored).Return(nil)
+			},
+			want: wantResponse{status: http.StatusOK},
+		},
+		{
+			name:    "add member with invalid attributes",
+			request: testRequest{method: http.MethodPost, target: "/members/", body: `{"username":"User1","gender":"other"}`},
+			want:    wantResponse{status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+		},
+		{
+			name:    "add member with the reserved username",
+			request: testRequest{method: http.MethodPost, target: "/members/", body: `{"username":" Former Member","gender":"Male","skinType":"Oily","skinColor":"Fair"}`},
+			want:    wantResponse{status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+		},
+		{
+			name:    "add member with taken username",
+			request: testRequest{method: http.MethodPost, target: "/members/", body: validBody},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().AddNewMember(gomock.Any(), stored).Return(utils.ErrUsernameAlreadyExists)
+			},
+			want: wantResponse{status: http.StatusConflict, errorCode: utils.UsernameTakenCode},
+		},
+		{
+			name:    "update member",
+			request: testRequest{method: http.MethodPut, target: "/members/1", body: validBody, headers: map[string]string{utils.HeaderIfMatch: `"1"`}},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				expected := *stored
+				expected.Version = 1
+				uc.EXPECT().UpdateMember(gomock.Any(), 1, &expected).Return(testMember(), nil)
+			},
+			want: wantResponse{status: http.StatusOK, etag: `"2"`},
+		},
+		{
+			name:    "update member without If-Match",
+			request: testRequest{method: http.MethodPut, target: "/members/1", body: validBody},
+			want:    wantResponse{status: http.StatusPreconditionRequired, errorCode: utils.PreconditionRequiredCode},
+		},
+		{
+			name:    "update member with stale version",
+			request: testRequest{method: http.MethodPut, target: "/members/1", body: validBody, headers: map[string]string{utils.HeaderIfMatch: `"1"`}},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().UpdateMember(gomock.Any(), 1, gomock.Any()).Return(nil, utils.ErrVersionMismatch)
+			},
+			want: wantResponse{status: http.StatusPreconditionFailed, errorCode: utils.VersionMismatchCode},
+		},
+		{
+			name:    "update any version of a member",
+			request: testRequest{method: http.MethodPut, target: "/members/1", body: validBody, headers: map[string]string{utils.HeaderIfMatch: `*`}},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				expected := *stored
+				expected.Version = utils.AnyVersion
+				uc.EXPECT().UpdateMember(gomock.Any(), 1, &expected).Return(testMember(), nil)
+			},
+			want: wantResponse{status: http.StatusOK, etag: `"2"`},
+		},
+		{
+			name:    "update member with a weak If-Match",
+			request: testRequest{method: http.MethodPut, target: "/members/1", body: validBody, headers: map[string]string{utils.HeaderIfMatch: `W/"1"`}},
+			want:    wantResponse{status: http.StatusPreconditionFailed, errorCode: utils.VersionMismatchCode},
+		},
+		{
+			name: "patch member",
+			request: testRequest{
+				method:      http.MethodPatch,
+				target:      "/members/1",
+				body:        `{"skinType":"Dry"}`,
+				contentType: utils.MIMEApplicationMergePatchJSON,
+				headers:     map[string]string{utils.HeaderIfMatch: `"1"`},
+			},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().PatchMember(gomock.Any(), 1, 1, []byte(`{"skinType":"Dry"}`)).Return(testMember(), nil)
+			},
+			want: wantResponse{status: http.StatusOK, etag: `"2"`},
+		},
+		{
+			name: "patch member with unsupported media type",
+			request: testRequest{
+				method:      http.MethodPatch,
+				target:      "/members/1",
+				body:        `skinType=Dry`,
+				contentType: echo.MIMEApplicationForm,
+				headers:     map[string]string{utils.HeaderIfMatch: `"1"`},
+			},
+			want: wantResponse{status: http.StatusUnsupportedMediaType},
+		},
+		{
+			name:    "delete member",
+			request: testRequest{method: http.MethodDelete, target: "/members/1", headers: map[string]string{utils.HeaderIfMatch: `"2"`}},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().DeleteMember(gomock.Any(), 1, 2, "").Return(nil)
+			},
+			want: wantResponse{status: http.StatusOK},
+		},
+		{
+			name:    "anonymize member",
+			request: testRequest{method: http.MethodDelete, target: "/members/1?mode=anonymize", headers: map[string]string{utils.HeaderIfMatch: `"2"`}},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().DeleteMember(gomock.Any(), 1, 2, models.ErasureAnonymize).Return(nil)
+			},
+			want: wantResponse{status: http.StatusOK},
+		},
+		{
+			name:    "delete member with unknown mode",
+			request: testRequest{method: http.MethodDelete, target: "/members/1?mode=wipe", headers: map[string]string{utils.HeaderIfMatch: `"2"`}},
+			want:    wantResponse{status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+		},
+		{
+			name:    "delete member with malformed If-Match",
+			request: testRequest{method: http.MethodDelete, target: "/members/1", headers: map[string]string{utils.HeaderIfMatch: `two`}},
+			want:    wantResponse{status: http.StatusPreconditionFailed, errorCode: utils.VersionMismatchCode},
+		},
+		{
+			name:    "restore member",
+			request: testRequest{method: http.MethodPost, target: "/admin/members/1/restore"},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().RestoreMember(gomock.Any(), 1).Return(testMember(), nil)
+			},
+			want: wantResponse{status: http.StatusOK, etag: `"2"`},
+		},
+		{
+			name:    "restore member with taken username",
+			request: testRequest{method: http.MethodPost, target: "/admin/members/1/restore"},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().RestoreMember(gomock.Any(), 1).Return(nil, utils.ErrUsernameAlreadyExists)
+			},
+			want: wantResponse{status: http.StatusConflict, errorCode: utils.UsernameTakenCode},
+		},
+		{
+			name:    "restore anonymized member",
+			request: testRequest{method: http.MethodPost, target: "/admin/members/1/restore"},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().RestoreMember(gomock.Any(), 1).Return(nil, utils.ErrMemberAnonymized)
+			},
+			want: wantResponse{status: http.StatusConflict, errorCode: utils.MemberAnonymizedCode},
+		},
+		{
+			name:    "get member erasures",
+			request: testRequest{method: http.MethodGet, target: "/admin/members/1/erasures"},
+			setup: func(uc *mock.MockMemberUsecaseInterface) {
+				uc.EXPECT().GetErasures(gomock.Any(), 1).Return([]*models.Erasure{{ID: 1, MemberID: 1, Mode: models.ErasureDelete}}, nil)
+			},
+			want: wantResponse{status: http.StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestServer(t, tt.setup)
+			assertResponse(t, serve(e, tt.request), tt.want)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: social_media/internal/member/repository (interfaces: MemberRepository)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	models "social_media/internal/member/models"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMemberRepository is a mock of MemberRepository interface.
+type MockMemberRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMemberRepositoryMockRecorder
+}
+
+// MockMemberRepositoryMockRecorder is the mock recorder for MockMemberRepository.
+type MockMemberRepositoryMockRecorder struct {
+	mock *MockMemberRepository
+}
+
+// NewMockMemberRepository creates a new mock instance.
+func NewMockMemberRepository(ctrl *gomock.Controller) *MockMemberRepository {
+	mock := &MockMemberRepository{ctrl: ctrl}
+	mock.recorder = &MockMemberRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMemberRepository) EXPECT() *MockMemberRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddNewMember mocks base method.
+func (m *MockMemberRepository) AddNewMember(arg0 context.Context, arg1 *models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddNewMember", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddNewMember indicates an expected call of AddNewMember.
+func (mr *MockMemberRepositoryMockRecorder) AddNewMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNewMember", reflect.TypeOf((*MockMemberRepository)(nil).AddNewMember), arg0, arg1)
+}
+
+// EraseMemberByID mocks base method.
+func (m *MockMemberRepository) EraseMemberByID(arg0 context.Context, arg1 *models.Erasure, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseMemberByID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EraseMemberByID indicates an expected call of EraseMemberByID.
+func (mr *MockMemberRepositoryMockRecorder) EraseMemberByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseMemberByID", reflect.TypeOf((*MockMemberRepository)(nil).EraseMemberByID), arg0, arg1, arg2)
+}
+
+// GetAllMembers mocks base method.
+func (m *MockMemberRepository) GetAllMembers(arg0 context.Context) ([]*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllMembers", arg0)
+	ret0, _ := ret[0].([]*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllMembers indicates an expected call of GetAllMembers.
+func (mr *MockMemberRepositoryMockRecorder) GetAllMembers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllMembers", reflect.TypeOf((*MockMemberRepository)(nil).GetAllMembers), arg0)
+}
+
+// GetAuthorByID mocks base method.
+func (m *MockMemberRepository) GetAuthorByID(arg0 context.Context, arg1 int) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthorByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthorByID indicates an expected call of GetAuthorByID.
+func (mr *MockMemberRepositoryMockRecorder) GetAuthorByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthorByID", reflect.TypeOf((*MockMemberRepository)(nil).GetAuthorByID), arg0, arg1)
+}
+
+// GetErasuresByMemberID mocks base method.
+func (m *MockMemberRepository) GetErasuresByMemberID(arg0 context.Context, arg1 int) ([]*models.Erasure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetErasuresByMemberID", arg0, arg1)
+	ret0, _ := ret[0].([]*models.Erasure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetErasuresByMemberID indicates an expected call of GetErasuresByMemberID.
+func (mr *MockMemberRepositoryMockRecorder) GetErasuresByMemberID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetErasuresByMemberID", reflect.TypeOf((*MockMemberRepository)(nil).GetErasuresByMemberID), arg0, arg1)
+}
+
+// GetMemberByID mocks base method.
+func (m *MockMemberRepository) GetMemberByID(arg0 context.Context, arg1 int) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberByID indicates an expected call of GetMemberByID.
+func (mr *MockMemberRepositoryMockRecorder) GetMemberByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberByID", reflect.TypeOf((*MockMemberRepository)(nil).GetMemberByID), arg0, arg1)
+}
+
+// GetMemberByUsername mocks base method.
+func (m *MockMemberRepository) GetMemberByUsername(arg0 context.Context, arg1 string) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberByUsername", arg0, arg1)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberByUsername indicates an expected call of GetMemberByUsername.
+func (mr *MockMemberRepositoryMockRecorder) GetMemberByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberByUsername", reflect.TypeOf((*MockMemberRepository)(nil).GetMemberByUsername), arg0, arg1)
+}
+
+// GetMembersAfter mocks base method.
+func (m *MockMemberRepository) GetMembersAfter(arg0 context.Context, arg1, arg2 int) ([]*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembersAfter", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembersAfter indicates an expected call of GetMembersAfter.
+func (mr *MockMemberRepositoryMockRecorder) GetMembersAfter(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembersAfter", reflect.TypeOf((*MockMemberRepository)(nil).GetMembersAfter), arg0, arg1, arg2)
+}
+
+// PurgeDeletedMembers mocks base method.
+func (m *MockMemberRepository) PurgeDeletedMembers(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedMembers", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedMembers indicates an expected call of PurgeDeletedMembers.
+func (mr *MockMemberRepositoryMockRecorder) PurgeDeletedMembers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedMembers", reflect.TypeOf((*MockMemberRepository)(nil).PurgeDeletedMembers), arg0, arg1)
+}
+
+// RestoreMemberByID mocks base method.
+func (m *MockMemberRepository) RestoreMemberByID(arg0 context.Context, arg1 int) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreMemberByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreMemberByID indicates an expected call of RestoreMemberByID.
+func (mr *MockMemberRepositoryMockRecorder) RestoreMemberByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreMemberByID", reflect.TypeOf((*MockMemberRepository)(nil).RestoreMemberByID), arg0, arg1)
+}
+
+// UpdateMemberByID mocks base method.
+func (m *MockMemberRepository) UpdateMemberByID(arg0 context.Context, arg1 *models.Member, arg2 int) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMemberByID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMemberByID indicates an expected call of UpdateMemberByID.
+func (mr *MockMemberRepositoryMockRecorder) UpdateMemberByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMemberByID", reflect.TypeOf((*MockMemberRepository)(nil).UpdateMemberByID), arg0, arg1, arg2)
+}
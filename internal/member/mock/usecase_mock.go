@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: social_media/internal/member/usecase (interfaces: MemberUsecaseInterface)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	models "social_media/internal/member/models"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMemberUsecaseInterface is a mock of MemberUsecaseInterface interface.
+type MockMemberUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMemberUsecaseInterfaceMockRecorder
+}
+
+// MockMemberUsecaseInterfaceMockRecorder is the mock recorder for MockMemberUsecaseInterface.
+type MockMemberUsecaseInterfaceMockRecorder struct {
+	mock *MockMemberUsecaseInterface
+}
+
+// NewMockMemberUsecaseInterface creates a new mock instance.
+func NewMockMemberUsecaseInterface(ctrl *gomock.Controller) *MockMemberUsecaseInterface {
+	mock := &MockMemberUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockMemberUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMemberUsecaseInterface) EXPECT() *MockMemberUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AddNewMember mocks base method.
+func (m *MockMemberUsecaseInterface) AddNewMember(arg0 context.Context, arg1 *models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddNewMember", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddNewMember indicates an expected call of AddNewMember.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) AddNewMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNewMember", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).AddNewMember), arg0, arg1)
+}
+
+// DeleteMember mocks base method.
+func (m *MockMemberUsecaseInterface) DeleteMember(arg0 context.Context, arg1, arg2 int, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMember", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMember indicates an expected call of DeleteMember.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) DeleteMember(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMember", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).DeleteMember), arg0, arg1, arg2, arg3)
+}
+
+// GetAllMember mocks base method.
+func (m *MockMemberUsecaseInterface) GetAllMember(arg0 context.Context) ([]*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllMember", arg0)
+	ret0, _ := ret[0].([]*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllMember indicates an expected call of GetAllMember.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) GetAllMember(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllMember", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).GetAllMember), arg0)
+}
+
+// GetErasures mocks base method.
+func (m *MockMemberUsecaseInterface) GetErasures(arg0 context.Context, arg1 int) ([]*models.Erasure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetErasures", arg0, arg1)
+	ret0, _ := ret[0].([]*models.Erasure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetErasures indicates an expected call of GetErasures.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) GetErasures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetErasures", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).GetErasures), arg0, arg1)
+}
+
+// GetMemberByID mocks base method.
+func (m *MockMemberUsecaseInterface) GetMemberByID(arg0 context.Context, arg1 int) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberByID indicates an expected call of GetMemberByID.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) GetMemberByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberByID", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).GetMemberByID), arg0, arg1)
+}
+
+// PatchMember mocks base method.
+func (m *MockMemberUsecaseInterface) PatchMember(arg0 context.Context, arg1, arg2 int, arg3 []byte) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchMember", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchMember indicates an expected call of PatchMember.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) PatchMember(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchMember", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).PatchMember), arg0, arg1, arg2, arg3)
+}
+
+// PurgeDeletedMembers mocks base method.
+func (m *MockMemberUsecaseInterface) PurgeDeletedMembers(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedMembers", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedMembers indicates an expected call of PurgeDeletedMembers.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) PurgeDeletedMembers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedMembers", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).PurgeDeletedMembers), arg0, arg1)
+}
+
+// RestoreMember mocks base method.
+func (m *MockMemberUsecaseInterface) RestoreMember(arg0 context.Context, arg1 int) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreMember", arg0, arg1)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreMember indicates an expected call of RestoreMember.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) RestoreMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreMember", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).RestoreMember), arg0, arg1)
+}
+
+// UpdateMember mocks base method.
+func (m *MockMemberUsecaseInterface) UpdateMember(arg0 context.Context, arg1 int, arg2 *models.Member) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMember", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMember indicates an expected call of UpdateMember.
+func (mr *MockMemberUsecaseInterfaceMockRecorder) UpdateMember(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMember", reflect.TypeOf((*MockMemberUsecaseInterface)(nil).UpdateMember), arg0, arg1, arg2)
+}
@@ -0,0 +1,50 @@
+package models
+
+import "strings"
+
+const (
+	GenderMale   = "Male"
+	GenderFemale = "Female"
+
+	SkinTypeOily        = "Oily"
+	SkinTypeDry         = "Dry"
+	SkinTypeNormal      = "Normal"
+	SkinTypeCombination = "Combination"
+
+	SkinColorFair   = "Fair"
+	SkinColorMedium = "Medium"
+	SkinColorDark   = "Dark"
+)
+
+var (
+	Genders    = []string{GenderMale, GenderFemale}
+	SkinTypes  = []string{SkinTypeOily, SkinTypeDry, SkinTypeNormal, SkinTypeCombination}
+	SkinColors = []string{SkinColorFair, SkinColorMedium, SkinColorDark}
+)
+
+// MemberAttributes lists the allowed values of the enumerated member columns.
+type MemberAttributes struct {
+	Gender    []string `json:"gender"`
+	SkinType  []string `json:"skinType"`
+	SkinColor []string `json:"skinColor"`
+}
+
+func AllowedMemberAttributes() *MemberAttributes {
+	return &MemberAttributes{
+		Gender:    Genders,
+		SkinType:  SkinTypes,
+		SkinColor: SkinColors,
+	}
+}
+
+// Normalize maps value onto its canonical spelling in allowed, ignoring case
+// and surrounding whitespace. It reports false when value is not allowed.
+func Normalize(value string, allowed []string) (string, bool) {
+	value = strings.TrimSpace(value)
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return candidate, true
+		}
+	}
+	return value, false
+}
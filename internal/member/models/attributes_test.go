@@ -0,0 +1,25 @@
+package models
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		value   string
+		allowed []string
+		want    string
+		wantOK  bool
+	}{
+		{"Male", Genders, GenderMale, true},
+		{" female ", Genders, GenderFemale, true},
+		{"COMBINATION", SkinTypes, SkinTypeCombination, true},
+		{"dark", SkinColors, SkinColorDark, true},
+		{"olive", SkinColors, "olive", false},
+		{"", Genders, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Normalize(tt.value, tt.allowed)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
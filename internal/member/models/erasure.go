@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Erasure modes of a member account deletion
+const (
+	// ErasureDelete soft deletes the member, their reviews and likes go when
+	// the member is purged
+	ErasureDelete = "delete"
+	// ErasureAnonymize clears the member's personal data and keeps their
+	// reviews and likes under FormerMemberUsername
+	ErasureAnonymize = "anonymize"
+)
+
+// FormerMemberUsername replaces the username of anonymized members. It is
+// reserved, no member may choose it.
+const FormerMemberUsername = "Former member"
+
+// IsReservedUsername reports whether username is FormerMemberUsername,
+// ignoring case and surrounding whitespace.
+func IsReservedUsername(username string) bool {
+	return strings.EqualFold(strings.TrimSpace(username), FormerMemberUsername)
+}
+
+// Erasure is the audit record of a member account deletion. It holds no
+// personal data, only which mode was chosen, by whom and when.
+type Erasure struct {
+	ID       int    `json:"id" gorm:"column:ID_ERASURE"`
+	MemberID int    `json:"memberId" gorm:"column:ID_MEMBER"`
+	Mode     string `json:"mode" gorm:"column:MODE"`
+	// RequestedBy is the member ID of the authenticated caller, if any
+	RequestedBy *int      `json:"requestedBy,omitempty" gorm:"column:REQUESTED_BY"`
+	RequestID   string    `json:"requestId,omitempty" gorm:"column:REQUEST_ID"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:CREATED_AT"`
+}
+
+func (Erasure) TableName() string {
+	return "member_erasures"
+}
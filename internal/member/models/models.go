@@ -1,9 +1,20 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Member struct {
-	ID        int    `json:"id" gorm:"column:ID_MEMBER"`
-	Username  string `json:"username" gorm:"column:USERNAME"`
-	Gender    string `json:"gender" gorm:"column:GENDER"`
-	SkinType  string `json:"skinType" gorm:"column:SKINTYPE"`
-	SkinColor string `json:"skinColor" gorm:"column:SKINCOLOR"`
+	ID        int            `json:"id" gorm:"column:ID_MEMBER"`
+	Username  string         `json:"username" gorm:"column:USERNAME"`
+	Gender    string         `json:"gender" gorm:"column:GENDER"`
+	SkinType  string         `json:"skinType" gorm:"column:SKINTYPE"`
+	SkinColor string         `json:"skinColor" gorm:"column:SKINCOLOR"`
+	Version   int            `json:"version" gorm:"column:VERSION"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:DELETED_AT"`
+	// AnonymizedAt is set on members erased with ErasureAnonymize, who stay
+	// deleted while their reviews and likes are kept
+	AnonymizedAt *time.Time `json:"-" gorm:"column:ANONYMIZED_AT"`
 }
@@ -0,0 +1,42 @@
+package models
+
+type MemberIDRequest struct {
+	ID int `param:"id" json:"-" validate:"required,min=1"`
+}
+
+type DeleteMemberRequest struct {
+	ID   int    `param:"id" json:"-" validate:"required,min=1"`
+	Mode string `query:"mode" json:"-" validate:"omitempty,oneof=delete anonymize"`
+}
+
+// MemberRequest holds the fields a client sets on a member. Username may not
+// be the reserved FormerMemberUsername.
+type MemberRequest struct {
+	Username  string `json:"username" validate:"required,max=255,neci=Former member"`
+	Gender    string `json:"gender" validate:"required,oneofci=Male Female"`
+	SkinType  string `json:"skinType" validate:"required,oneofci=Oily Dry Normal Combination"`
+	SkinColor string `json:"skinColor" validate:"required,oneofci=Fair Medium Dark"`
+}
+
+type UpdateMemberRequest struct {
+	ID int `param:"id" json:"-" validate:"required,min=1"`
+	MemberRequest
+}
+
+func NewMemberRequest(member *Member) *MemberRequest {
+	return &MemberRequest{
+		Username:  member.Username,
+		Gender:    member.Gender,
+		SkinType:  member.SkinType,
+		SkinColor: member.SkinColor,
+	}
+}
+
+func (r *MemberRequest) ToMember() *Member {
+	return &Member{
+		Username:  r.Username,
+		Gender:    r.Gender,
+		SkinType:  r.SkinType,
+		SkinColor: r.SkinColor,
+	}
+}
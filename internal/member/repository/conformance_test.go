@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"social_media/internal/member/models"
+	"social_media/pkg/utils"
+	"testing"
+	"time"
+)
+
+// testMemberRepository is the behaviour every MemberRepository must share.
+// newRepository returns an empty repository.
+func testMemberRepository(t *testing.T, newRepository func(t *testing.T) MemberRepository) {
+	ctx := context.Background()
+
+	add := func(t *testing.T, repo MemberRepository, username string) *models.Member {
+		t.Helper()
+
+		member := &models.Member{Username: username, Gender: models.GenderFemale, SkinType: models.SkinTypeDry, SkinColor: models.SkinColorDark}
+		if err := repo.AddNewMember(ctx, member); err != nil {
+			t.Fatalf("AddNewMember(%q) error = %v", username, err)
+		}
+		return member
+	}
+
+	wantErr := func(t *testing.T, op string, err, want error) {
+		t.Helper()
+
+		if !errors.Is(err, want) {
+			t.Fatalf("%s error = %v, want %v", op, err, want)
+		}
+	}
+
+	remove := func(t *testing.T, repo MemberRepository, member *models.Member) {
+		t.Helper()
+
+		erasure := &models.Erasure{MemberID: member.ID, Mode: models.ErasureDelete}
+		wantErr(t, "EraseMemberByID()", repo.EraseMemberByID(ctx, erasure, member.Version), nil)
+	}
+
+	t.Run("AddNewMember assigns an ID and the first version", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+
+		if member.ID == 0 || member.Version != 1 {
+			t.Fatalf("AddNewMember() stored ID %d version %d, want an ID and version 1", member.ID, member.Version)
+		}
+
+		got, err := repo.GetMemberByID(ctx, member.ID)
+		wantErr(t, "GetMemberByID()", err, nil)
+		if got.Username != "User1" || got.SkinType != models.SkinTypeDry || got.Version != 1 {
+			t.Errorf("GetMemberByID() = %+v", got)
+		}
+	})
+
+	t.Run("AddNewMember rejects a taken username", func(t *testing.T) {
+		repo := newRepository(t)
+		add(t, repo, "User1")
+
+		err := repo.AddNewMember(ctx, &models.Member{Username: "User1", Gender: models.GenderMale, SkinType: models.SkinTypeOily, SkinColor: models.SkinColorFair})
+		wantErr(t, "AddNewMember()", err, utils.ErrUsernameAlreadyExists)
+	})
+
+	t.Run("AddNewMember compares usernames case-insensitively", func(t *testing.T) {
+		repo := newRepository(t)
+		add(t, repo, "alice")
+
+		err := repo.AddNewMember(ctx, &models.Member{Username: "Alice", Gender: models.GenderMale, SkinType: models.SkinTypeOily, SkinColor: models.SkinColorFair})
+		wantErr(t, "AddNewMember()", err, utils.ErrUsernameAlreadyExists)
+	})
+
+	t.Run("AddNewMember rejects the reserved username", func(t *testing.T) {
+		err := newRepository(t).AddNewMember(ctx, &models.Member{Username: "former MEMBER", Gender: models.GenderMale, SkinType: models.SkinTypeOily, SkinColor: models.SkinColorFair})
+		wantErr(t, "AddNewMember()", err, utils.ErrUsernameAlreadyExists)
+	})
+
+	t.Run("GetMemberByID reports unknown members", func(t *testing.T) {
+		_, err := newRepository(t).GetMemberByID(ctx, 42)
+		wantErr(t, "GetMemberByID()", err, utils.ErrMemberNotFound)
+	})
+
+	t.Run("GetAllMembers lists live members", func(t *testing.T) {
+		repo := newRepository(t)
+		add(t, repo, "User1")
+		deleted := add(t, repo, "User2")
+		add(t, repo, "User3")
+		remove(t, repo, deleted)
+
+		members, err := repo.GetAllMembers(ctx)
+		wantErr(t, "GetAllMembers()", err, nil)
+
+		usernames := make(map[string]bool)
+		for _, member := range members {
+			usernames[member.Username] = true
+		}
+		if len(members) != 2 || !usernames["User1"] || !usernames["User3"] {
+			t.Errorf("GetAllMembers() = %v, want User1 and User3", usernames)
+		}
+	})
+
+	t.Run("GetMembersAfter pages through live members in ID order", func(t *testing.T) {
+		repo := newRepository(t)
+		first := add(t, repo, "User1")
+		deleted := add(t, repo, "User2")
+		third := add(t, repo, "User3")
+		fourth := add(t, repo, "User4")
+		remove(t, repo, deleted)
+
+		page, err := repo.GetMembersAfter(ctx, 0, 2)
+		wantErr(t, "GetMembersAfter()", err, nil)
+		if len(page) != 2 || page[0].ID != first.ID || page[1].ID != third.ID {
+			t.Fatalf("GetMembersAfter(0, 2) = %+v, want User1 and User3", page)
+		}
+
+		page, err = repo.GetMembersAfter(ctx, third.ID, 2)
+		wantErr(t, "GetMembersAfter()", err, nil)
+		if len(page) != 1 || page[0].ID != fourth.ID {
+			t.Fatalf("GetMembersAfter(%d, 2) = %+v, want User4", third.ID, page)
+		}
+
+		page, err = repo.GetMembersAfter(ctx, fourth.ID, 2)
+		wantErr(t, "GetMembersAfter()", err, nil)
+		if len(page) != 0 {
+			t.Fatalf("GetMembersAfter() past the last member = %+v", page)
+		}
+	})
+
+	t.Run("GetMemberByUsername finds live members only", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+
+		got, err := repo.GetMemberByUsername(ctx, "User1")
+		wantErr(t, "GetMemberByUsername()", err, nil)
+		if got == nil || got.ID != member.ID {
+			t.Fatalf("GetMemberByUsername() = %+v, want member %d", got, member.ID)
+		}
+
+		remove(t, repo, member)
+		got, err = repo.GetMemberByUsername(ctx, "User1")
+		wantErr(t, "GetMemberByUsername()", err, nil)
+		if got != nil {
+			t.Errorf("GetMemberByUsername() of a deleted member = %+v, want nil", got)
+		}
+	})
+
+	t.Run("GetMemberByUsername compares usernames case-insensitively", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "alice")
+
+		got, err := repo.GetMemberByUsername(ctx, "ALICE")
+		wantErr(t, "GetMemberByUsername()", err, nil)
+		if got == nil || got.ID != member.ID {
+			t.Errorf("GetMemberByUsername() = %+v, want member %d", got, member.ID)
+		}
+	})
+
+	t.Run("UpdateMemberByID replaces every field and bumps the version", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+
+		got, err := repo.UpdateMemberByID(ctx, &models.Member{
+			Username:  "Renamed",
+			Gender:    models.GenderMale,
+			SkinType:  models.SkinTypeOily,
+			SkinColor: models.SkinColorFair,
+			Version:   member.Version,
+		}, member.ID)
+		wantErr(t, "UpdateMemberByID()", err, nil)
+
+		want := models.Member{ID: member.ID, Username: "Renamed", Gender: models.GenderMale, SkinType: models.SkinTypeOily, SkinColor: models.SkinColorFair, Version: 2}
+		if got.ID != want.ID || got.Username != want.Username || got.Gender != want.Gender ||
+			got.SkinType != want.SkinType || got.SkinColor != want.SkinColor || got.Version != want.Version {
+			t.Errorf("UpdateMemberByID() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("UpdateMemberByID rejects a stale version", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+
+		member.Version++
+		_, err := repo.UpdateMemberByID(ctx, member, member.ID)
+		wantErr(t, "UpdateMemberByID()", err, utils.ErrVersionMismatch)
+	})
+
+	t.Run("UpdateMemberByID rejects a username taken by another member", func(t *testing.T) {
+		repo := newRepository(t)
+		add(t, repo, "User1")
+		member := add(t, repo, "User2")
+
+		member.Username = "User1"
+		_, err := repo.UpdateMemberByID(ctx, member, member.ID)
+		wantErr(t, "UpdateMemberByID()", err, utils.ErrUsernameAlreadyExists)
+	})
+
+	t.Run("UpdateMemberByID rejects the reserved username", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+
+		member.Username = models.FormerMemberUsername
+		_, err := repo.UpdateMemberByID(ctx, member, member.ID)
+		wantErr(t, "UpdateMemberByID()", err, utils.ErrUsernameAlreadyExists)
+	})
+
+	t.Run("UpdateMemberByID checks the version before the username", func(t *testing.T) {
+		repo := newRepository(t)
+		add(t, repo, "User1")
+		member := add(t, repo, "User2")
+
+		member.Username = "User1"
+		member.Version++
+		_, err := repo.UpdateMemberByID(ctx, member, member.ID)
+		wantErr(t, "UpdateMemberByID()", err, utils.ErrVersionMismatch)
+	})
+
+	t.Run("UpdateMemberByID reports unknown members", func(t *testing.T) {
+		_, err := newRepository(t).UpdateMemberByID(ctx, &models.Member{Username: "User1", Version: 1}, 42)
+		wantErr(t, "UpdateMemberByID()", err, utils.ErrMemberNotFound)
+	})
+
+	t.Run("EraseMemberByID hides the member", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+		remove(t, repo, member)
+
+		_, err := repo.GetMemberByID(ctx, member.ID)
+		wantErr(t, "GetMemberByID()", err, utils.ErrMemberNotFound)
+	})
+
+	t.Run("EraseMemberByID frees the username", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+		remove(t, repo, member)
+
+		add(t, repo, "User1")
+	})
+
+	t.Run("RestoreMemberByID brings the member back with a new version", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+		remove(t, repo, member)
+
+		got, err := repo.RestoreMemberByID(ctx, member.ID)
+		wantErr(t, "RestoreMemberByID()", err, nil)
+		if got.Username != "User1" || got.Version != member.Version+1 {
+			t.Errorf("RestoreMemberByID() = %+v, want User1 at version %d", got, member.Version+1)
+		}
+
+		again, err := repo.RestoreMemberByID(ctx, member.ID)
+		wantErr(t, "RestoreMemberByID()", err, nil)
+		if again.Version != got.Version {
+			t.Errorf("restoring a live member changed its version to %d", again.Version)
+		}
+	})
+
+	t.Run("RestoreMemberByID rejects a username taken while deleted", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+		remove(t, repo, member)
+		add(t, repo, "User1")
+
+		_, err := repo.RestoreMemberByID(ctx, member.ID)
+		wantErr(t, "RestoreMemberByID()", err, utils.ErrUsernameAlreadyExists)
+	})
+
+	t.Run("RestoreMemberByID reports unknown members", func(t *testing.T) {
+		_, err := newRepository(t).RestoreMemberByID(ctx, 42)
+		wantErr(t, "RestoreMemberByID()", err, utils.ErrMemberNotFound)
+	})
+
+	t.Run("PurgeDeletedMembers removes members deleted before the cutoff", func(t *testing.T) {
+		repo := newRepository(t)
+		kept := add(t, repo, "User1")
+		purged := add(t, repo, "User2")
+		remove(t, repo, purged)
+
+		count, err := repo.PurgeDeletedMembers(ctx, time.Now().Add(-time.Hour))
+		wantErr(t, "PurgeDeletedMembers()", err, nil)
+		if count != 0 {
+			t.Errorf("PurgeDeletedMembers() before the deletion purged %d members", count)
+		}
+
+		count, err = repo.PurgeDeletedMembers(ctx, time.Now().Add(time.Hour))
+		wantErr(t, "PurgeDeletedMembers()", err, nil)
+		if count != 1 {
+			t.Errorf("PurgeDeletedMembers() purged %d members, want 1", count)
+		}
+
+		_, err = repo.RestoreMemberByID(ctx, purged.ID)
+		wantErr(t, "RestoreMemberByID()", err, utils.ErrMemberNotFound)
+		_, err = repo.GetMemberByID(ctx, kept.ID)
+		wantErr(t, "GetMemberByID()", err, nil)
+	})
+
+	t.Run("EraseMemberByID deletes the member and records the erasure", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+		requestedBy := 7
+
+		erasure := &models.Erasure{MemberID: member.ID, Mode: models.ErasureDelete, RequestedBy: &requestedBy, RequestID: "req-1"}
+		wantErr(t, "EraseMemberByID()", repo.EraseMemberByID(ctx, erasure, member.Version+1), utils.ErrVersionMismatch)
+		wantErr(t, "EraseMemberByID()", repo.EraseMemberByID(ctx, erasure, member.Version), nil)
+		wantErr(t, "EraseMemberByID()", repo.EraseMemberByID(ctx, erasure, member.Version), utils.ErrMemberNotFound)
+
+		_, err := repo.GetAuthorByID(ctx, member.ID)
+		wantErr(t, "GetAuthorByID()", err, utils.ErrMemberNotFound)
+
+		erasures, err := repo.GetErasuresByMemberID(ctx, member.ID)
+		wantErr(t, "GetErasuresByMemberID()", err, nil)
+		if len(erasures) != 1 {
+			t.Fatalf("GetErasuresByMemberID() = %d erasures, want 1", len(erasures))
+		}
+		got := erasures[0]
+		if got.ID == 0 || got.Mode != models.ErasureDelete || got.RequestedBy == nil || *got.RequestedBy != 7 || got.RequestID != "req-1" || got.CreatedAt.IsZero() {
+			t.Errorf("GetErasuresByMemberID() = %+v", got)
+		}
+	})
+
+	t.Run("EraseMemberByID anonymizes the member", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+
+		erasure := &models.Erasure{MemberID: member.ID, Mode: models.ErasureAnonymize}
+		wantErr(t, "EraseMemberByID()", repo.EraseMemberByID(ctx, erasure, member.Version), nil)
+
+		_, err := repo.GetMemberByID(ctx, member.ID)
+		wantErr(t, "GetMemberByID()", err, utils.ErrMemberNotFound)
+		add(t, repo, "User1")
+
+		author, err := repo.GetAuthorByID(ctx, member.ID)
+		wantErr(t, "GetAuthorByID()", err, nil)
+		if author.Username != models.FormerMemberUsername || author.Gender != "" || author.SkinType != "" || author.SkinColor != "" || author.AnonymizedAt == nil {
+			t.Errorf("GetAuthorByID() = %+v, want an anonymized member", author)
+		}
+
+		_, err = repo.RestoreMemberByID(ctx, member.ID)
+		wantErr(t, "RestoreMemberByID()", err, utils.ErrMemberAnonymized)
+
+		count, err := repo.PurgeDeletedMembers(ctx, time.Now().Add(time.Hour))
+		wantErr(t, "PurgeDeletedMembers()", err, nil)
+		if count != 0 {
+			t.Errorf("PurgeDeletedMembers() purged %d anonymized members", count)
+		}
+
+		erasures, err := repo.GetErasuresByMemberID(ctx, member.ID)
+		wantErr(t, "GetErasuresByMemberID()", err, nil)
+		if len(erasures) != 1 || erasures[0].Mode != models.ErasureAnonymize || erasures[0].RequestedBy != nil {
+			t.Errorf("GetErasuresByMemberID() = %+v", erasures)
+		}
+	})
+
+	t.Run("GetErasuresByMemberID outlives a purged member", func(t *testing.T) {
+		repo := newRepository(t)
+		member := add(t, repo, "User1")
+		erasure := &models.Erasure{MemberID: member.ID, Mode: models.ErasureDelete}
+		wantErr(t, "EraseMemberByID()", repo.EraseMemberByID(ctx, erasure, member.Version), nil)
+
+		_, err := repo.PurgeDeletedMembers(ctx, time.Now().Add(time.Hour))
+		wantErr(t, "PurgeDeletedMembers()", err, nil)
+
+		erasures, err := repo.GetErasuresByMemberID(ctx, member.ID)
+		wantErr(t, "GetErasuresByMemberID()", err, nil)
+		if len(erasures) != 1 {
+			t.Errorf("GetErasuresByMemberID() = %d erasures, want 1", len(erasures))
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"social_media/internal/member/models"
+	"social_media/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryRepository is a MemberRepository kept in process memory, it is safe
+// for concurrent use and returns the same errors as MySQLRepository.
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	members  map[int]*models.Member
+	lastID   int
+	erasures []*models.Erasure
+}
+
+// NewMemoryMemberRepository returns a repository holding the given members.
+// Seed members keep their ID when set and start at version 1 when unset.
+func NewMemoryMemberRepository(seed ...*models.Member) *MemoryRepository {
+	r := &MemoryRepository{members: make(map[int]*models.Member)}
+
+	for _, member := range seed {
+		stored := *member
+		if stored.ID == 0 {
+			stored.ID = r.lastID + 1
+		}
+		if stored.Version == 0 {
+			stored.Version = 1
+		}
+		if stored.ID > r.lastID {
+			r.lastID = stored.ID
+		}
+		r.members[stored.ID] = &stored
+	}
+
+	return r
+}
+
+func (r *MemoryRepository) GetMemberByID(ctx context.Context, id int) (*models.Member, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	member, ok := r.members[id]
+	if !ok || member.DeletedAt.Valid {
+		return nil, utils.ErrMemberNotFound
+	}
+
+	result := *member
+	return &result, nil
+}
+
+func (r *MemoryRepository) GetAllMembers(ctx context.Context) ([]*models.Member, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := make([]*models.Member, 0, len(r.members))
+	for id := 1; id <= r.lastID; id++ {
+		member, ok := r.members[id]
+		if !ok || member.DeletedAt.Valid {
+			continue
+		}
+		result := *member
+		members = append(members, &result)
+	}
+	return members, nil
+}
+
+func (r *MemoryRepository) GetMembersAfter(ctx context.Context, afterID int, limit int) ([]*models.Member, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := make([]*models.Member, 0, limit)
+	for id := afterID + 1; id <= r.lastID && len(members) < limit; id++ {
+		member, ok := r.members[id]
+		if !ok || member.DeletedAt.Valid {
+			continue
+		}
+		result := *member
+		members = append(members, &result)
+	}
+	return members, nil
+}
+
+func (r *MemoryRepository) GetMemberByUsername(ctx context.Context, username string) (*models.Member, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, member := range r.members {
+		if !member.DeletedAt.Valid && strings.EqualFold(member.Username, username) {
+			result := *member
+			return &result, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) AddNewMember(ctx context.Context, member *models.Member) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.usernameTaken(member.Username, 0) {
+		return utils.ErrUsernameAlreadyExists
+	}
+
+	r.lastID++
+	member.ID = r.lastID
+	member.Version = 1
+	member.DeletedAt = gorm.DeletedAt{}
+
+	stored := *member
+	r.members[stored.ID] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) UpdateMemberByID(ctx context.Context, member *models.Member, id int) (*models.Member, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.members[id]
+	if !ok || existing.DeletedAt.Valid {
+		return nil, utils.ErrMemberNotFound
+	}
+	if existing.Version != member.Version {
+		return nil, utils.ErrVersionMismatch
+	}
+	if r.usernameTaken(member.Username, id) {
+		return nil, utils.ErrUsernameAlreadyExists
+	}
+
+	existing.Username = member.Username
+	existing.Gender = member.Gender
+	existing.SkinType = member.SkinType
+	existing.SkinColor = member.SkinColor
+	existing.Version++
+
+	result := *existing
+	return &result, nil
+}
+
+func (r *MemoryRepository) EraseMemberByID(ctx context.Context, erasure *models.Erasure, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.members[erasure.MemberID]
+	if !ok || existing.DeletedAt.Valid {
+		return utils.ErrMemberNotFound
+	}
+	if erasure.Mode != models.ErasureDelete && erasure.Mode != models.ErasureAnonymize {
+		return fmt.Errorf("unknown erasure mode %q", erasure.Mode)
+	}
+	if existing.Version != version {
+		return utils.ErrVersionMismatch
+	}
+
+	now := time.Now()
+	if erasure.Mode == models.ErasureAnonymize {
+		existing.Username = models.FormerMemberUsername
+		existing.Gender = ""
+		existing.SkinType = ""
+		existing.SkinColor = ""
+		existing.Version++
+		existing.AnonymizedAt = &now
+	}
+	existing.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+
+	erasure.ID = len(r.erasures) + 1
+	erasure.CreatedAt = now
+	stored := *erasure
+	r.erasures = append(r.erasures, &stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetErasuresByMemberID(ctx context.Context, memberID int) ([]*models.Erasure, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	erasures := make([]*models.Erasure, 0)
+	for _, erasure := range r.erasures {
+		if erasure.MemberID == memberID {
+			result := *erasure
+			erasures = append(erasures, &result)
+		}
+	}
+	return erasures, nil
+}
+
+func (r *MemoryRepository) GetAuthorByID(ctx context.Context, id int) (*models.Member, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	member, ok := r.members[id]
+	if !ok || (member.DeletedAt.Valid && member.AnonymizedAt == nil) {
+		return nil, utils.ErrMemberNotFound
+	}
+
+	result := *member
+	return &result, nil
+}
+
+func (r *MemoryRepository) RestoreMemberByID(ctx context.Context, id int) (*models.Member, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.members[id]
+	if !ok {
+		return nil, utils.ErrMemberNotFound
+	}
+	if existing.AnonymizedAt != nil {
+		return nil, utils.ErrMemberAnonymized
+	}
+	if existing.DeletedAt.Valid {
+		// The username may have been taken while the member was deleted
+		if r.usernameTaken(existing.Username, id) {
+			return nil, utils.ErrUsernameAlreadyExists
+		}
+		existing.DeletedAt = gorm.DeletedAt{}
+		existing.Version++
+	}
+
+	result := *existing
+	return &result, nil
+}
+
+// PurgeDeletedMembers permanently removes members soft deleted before the
+// given time, except anonymized ones.
+func (r *MemoryRepository) PurgeDeletedMembers(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, member := range r.members {
+		if member.DeletedAt.Valid && member.DeletedAt.Time.Before(before) && member.AnonymizedAt == nil {
+			delete(r.members, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// usernameTaken reports whether username is reserved or a live member other
+// than exceptID uses it. Callers must hold r.mu.
+func (r *MemoryRepository) usernameTaken(username string, exceptID int) bool {
+	if models.IsReservedUsername(username) {
+		return true
+	}
+	for id, member := range r.members {
+		if id != exceptID && !member.DeletedAt.Valid && strings.EqualFold(member.Username, username) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"social_media/internal/member/models"
+	"sync"
+	"testing"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testMemberRepository(t, func(t *testing.T) MemberRepository {
+		return NewMemoryMemberRepository()
+	})
+}
+
+func TestMemoryRepository_ConcurrentAdds(t *testing.T) {
+	repo := NewMemoryMemberRepository(&models.Member{ID: 7, Username: "Seeded"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo.AddNewMember(context.Background(), &models.Member{Username: "Same"})
+		}()
+	}
+	wg.Wait()
+
+	members, err := repo.GetAllMembers(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllMembers() error = %v", err)
+	}
+	if len(members) != 2 || members[0].ID != 7 || members[1].ID != 8 {
+		t.Errorf("GetAllMembers() = %+v, want the seed and one added member", members)
+	}
+}
@@ -3,18 +3,28 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"social_media/internal/member/models"
 	"social_media/pkg/utils"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+//go:generate mockgen -destination=../mock/repository_mock.go -package=mock social_media/internal/member/repository MemberRepository
+
 type MemberRepository interface {
 	AddNewMember(ctx context.Context, member *models.Member) error
-	UpdateMemberByID(ctx context.Context, member *models.Member, id int) error
+	UpdateMemberByID(ctx context.Context, member *models.Member, id int) (*models.Member, error)
 	GetMemberByID(ctx context.Context, id int) (*models.Member, error)
 	GetAllMembers(ctx context.Context) ([]*models.Member, error)
-	DeleteMemberByID(ctx context.Context, id int) error
+	GetMembersAfter(ctx context.Context, afterID int, limit int) ([]*models.Member, error)
+	GetMemberByUsername(ctx context.Context, username string) (*models.Member, error)
+	EraseMemberByID(ctx context.Context, erasure *models.Erasure, version int) error
+	GetErasuresByMemberID(ctx context.Context, memberID int) ([]*models.Erasure, error)
+	GetAuthorByID(ctx context.Context, id int) (*models.Member, error)
+	RestoreMemberByID(ctx context.Context, id int) (*models.Member, error)
+	PurgeDeletedMembers(ctx context.Context, before time.Time) (int64, error)
 }
 
 type MySQLRepository struct {
@@ -27,9 +37,9 @@ func NewMemberRepository(db *gorm.DB) *MySQLRepository {
 
 func (r *MySQLRepository) GetMemberByID(ctx context.Context, id int) (*models.Member, error) {
 	var member models.Member
-	err := r.db.First(&member, id).Error
+	err := r.db.WithContext(ctx).First(&member, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New(utils.MemberNotFound)
+		return nil, utils.ErrMemberNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -39,7 +49,23 @@ func (r *MySQLRepository) GetMemberByID(ctx context.Context, id int) (*models.Me
 
 func (r *MySQLRepository) GetAllMembers(ctx context.Context) ([]*models.Member, error) {
 	var members []*models.Member
-	err := r.db.Find(&members).Error
+	err := r.db.WithContext(ctx).Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetMembersAfter returns up to limit live members with an ID above afterID,
+// in ID order, to page through every member.
+func (r *MySQLRepository) GetMembersAfter(ctx context.Context, afterID int, limit int) ([]*models.Member, error) {
+	members := make([]*models.Member, 0, limit)
+	err := r.db.WithContext(ctx).
+		Where("ID_MEMBER > ?", afterID).
+		Order("ID_MEMBER").
+		Limit(limit).
+		Find(&members).
+		Error
 	if err != nil {
 		return nil, err
 	}
@@ -47,22 +73,29 @@ func (r *MySQLRepository) GetAllMembers(ctx context.Context) ([]*models.Member,
 }
 
 func (r *MySQLRepository) AddNewMember(ctx context.Context, member *models.Member) error {
+	if models.IsReservedUsername(member.Username) {
+		return utils.ErrUsernameAlreadyExists
+	}
+
 	// Check if username already exists
 	existingMember, err := r.GetMemberByUsername(ctx, member.Username)
 	if err != nil {
 		return err
 	}
 	if existingMember != nil {
-		return errors.New(utils.UsernameAlreadyExists)
+		return utils.ErrUsernameAlreadyExists
 	}
 
 	// Create the new member
-	return r.db.Create(member).Error
+	member.Version = 1
+	return r.db.WithContext(ctx).Create(member).Error
 }
 
+// GetMemberByUsername returns the live member using username, or nil when
+// there is none.
 func (r *MySQLRepository) GetMemberByUsername(ctx context.Context, username string) (*models.Member, error) {
 	var member models.Member
-	err := r.db.Where("username = ?", username).First(&member).Error
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&member).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil // Username does not exist
 	}
@@ -72,28 +105,179 @@ func (r *MySQLRepository) GetMemberByUsername(ctx context.Context, username stri
 	return &member, nil
 }
 
-func (r *MySQLRepository) UpdateMemberByID(ctx context.Context, member *models.Member, id int) error {
+func (r *MySQLRepository) UpdateMemberByID(ctx context.Context, member *models.Member, id int) (*models.Member, error) {
 	existingMember, err := r.GetMemberByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if existingMember == nil {
-		return errors.New(utils.MemberNotFound)
+		return nil, utils.ErrMemberNotFound
+	}
+
+	// A stale client is told so before anything about the new values
+	if existingMember.Version != member.Version {
+		return nil, utils.ErrVersionMismatch
+	}
+
+	// Check if the username is reserved or taken by another member
+	if models.IsReservedUsername(member.Username) {
+		return nil, utils.ErrUsernameAlreadyExists
+	}
+	sameUsername, err := r.GetMemberByUsername(ctx, member.Username)
+	if err != nil {
+		return nil, err
+	}
+	if sameUsername != nil && sameUsername.ID != id {
+		return nil, utils.ErrUsernameAlreadyExists
 	}
 
-	// Perform the update
-	return r.db.Model(&models.Member{}).Where("id = ?", id).Updates(member).Error
+	// Replace every column, including zero values, only if nobody else
+	// updated the row since it was read
+	expectedVersion := member.Version
+	member.Version = expectedVersion + 1
+	result := r.db.WithContext(ctx).
+		Model(&models.Member{}).
+		Where("ID_MEMBER = ? AND VERSION = ?", id, expectedVersion).
+		Select("USERNAME", "GENDER", "SKINTYPE", "SKINCOLOR", "VERSION").
+		Updates(member)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, utils.ErrVersionMismatch
+	}
+
+	return r.GetMemberByID(ctx, id)
 }
 
-func (r *MySQLRepository) DeleteMemberByID(ctx context.Context, id int) error {
-	existingMember, err := r.GetMemberByID(ctx, id)
+// EraseMemberByID deletes the member erasure.MemberID as erasure.Mode asks
+// and records erasure in the audit trail, in a single transaction. Anonymized
+// members stay deleted with their personal columns cleared, their reviews
+// and likes are kept and PurgeDeletedMembers leaves them alone.
+func (r *MySQLRepository) EraseMemberByID(ctx context.Context, erasure *models.Erasure, version int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var member models.Member
+		err := tx.First(&member, erasure.MemberID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrMemberNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		query := tx.Model(&models.Member{}).Where("ID_MEMBER = ? AND VERSION = ?", erasure.MemberID, version)
+
+		var result *gorm.DB
+		switch erasure.Mode {
+		case models.ErasureDelete:
+			result = query.Delete(&models.Member{})
+		case models.ErasureAnonymize:
+			result = query.Updates(map[string]interface{}{
+				"USERNAME":      models.FormerMemberUsername,
+				"GENDER":        nil,
+				"SKINTYPE":      nil,
+				"SKINCOLOR":     nil,
+				"VERSION":       gorm.Expr("VERSION + 1"),
+				"ANONYMIZED_AT": now,
+				"DELETED_AT":    now,
+			})
+		default:
+			return fmt.Errorf("unknown erasure mode %q", erasure.Mode)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return utils.ErrVersionMismatch
+		}
+
+		erasure.CreatedAt = now
+		return tx.Create(erasure).Error
+	})
+}
+
+// GetErasuresByMemberID returns the audit trail of a member's erasures,
+// oldest first. It outlives the member, even once purged.
+func (r *MySQLRepository) GetErasuresByMemberID(ctx context.Context, memberID int) ([]*models.Erasure, error) {
+	erasures := make([]*models.Erasure, 0)
+	err := r.db.WithContext(ctx).
+		Where("ID_MEMBER = ?", memberID).
+		Order("ID_ERASURE").
+		Find(&erasures).
+		Error
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if existingMember == nil {
-		return errors.New(utils.MemberNotFound)
+	return erasures, nil
+}
+
+// GetAuthorByID returns a member reviews and likes are still credited to:
+// a live member or an anonymized one.
+func (r *MySQLRepository) GetAuthorByID(ctx context.Context, id int) (*models.Member, error) {
+	var member models.Member
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("DELETED_AT IS NULL OR ANONYMIZED_AT IS NOT NULL").
+		First(&member, id).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *MySQLRepository) RestoreMemberByID(ctx context.Context, id int) (*models.Member, error) {
+	var member models.Member
+	err := r.db.WithContext(ctx).Unscoped().First(&member, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !member.DeletedAt.Valid {
+		return &member, nil
+	}
+	if member.AnonymizedAt != nil {
+		return nil, utils.ErrMemberAnonymized
+	}
+
+	// The username may have been taken while the member was deleted
+	sameUsername, err := r.GetMemberByUsername(ctx, member.Username)
+	if err != nil {
+		return nil, err
 	}
+	if sameUsername != nil {
+		return nil, utils.ErrUsernameAlreadyExists
+	}
+
+	err = r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Member{}).
+		Where("ID_MEMBER = ?", id).
+		Updates(map[string]interface{}{
+			"DELETED_AT": nil,
+			"VERSION":    gorm.Expr("VERSION + 1"),
+		}).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetMemberByID(ctx, id)
+}
 
-	// Perform the delete operation
-	return r.db.Delete(&models.Member{}, id).Error
+// PurgeDeletedMembers permanently removes members soft deleted before the
+// given time, together with their reviews and likes. Anonymized members are
+// kept.
+func (r *MySQLRepository) PurgeDeletedMembers(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Where("DELETED_AT IS NOT NULL AND DELETED_AT < ? AND ANONYMIZED_AT IS NULL", before).
+		Delete(&models.Member{})
+	return result.RowsAffected, result.Error
 }
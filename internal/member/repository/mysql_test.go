@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"social_media/internal/member/models"
+	"social_media/internal/testutil"
+	"social_media/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var memberColumns = []string{"ID_MEMBER", "USERNAME", "GENDER", "SKINTYPE", "SKINCOLOR", "VERSION", "DELETED_AT", "ANONYMIZED_AT"}
+
+// Queries made through First take the row limit as their last argument
+const (
+	selectByID       = "SELECT * FROM `members` WHERE `members`.`ID_MEMBER` = ? AND `members`.`DELETED_AT` IS NULL"
+	selectByUsername = "SELECT * FROM `members` WHERE username = ? AND `members`.`DELETED_AT` IS NULL"
+)
+
+func newMockRepository(t *testing.T) (*MySQLRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return NewMemberRepository(db), mock
+}
+
+func memberRow(id int, username string, version int) *sqlmock.Rows {
+	return sqlmock.NewRows(memberColumns).AddRow(id, username, "Male", "Oily", "Fair", version, nil, nil)
+}
+
+func quote(query string) string {
+	return regexp.QuoteMeta(query)
+}
+
+func TestMySQLRepository_GetMemberByID(t *testing.T) {
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(memberRow(1, "User1", 1))
+			},
+		},
+		{
+			name: "not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(sqlmock.NewRows(memberColumns))
+			},
+			wantErr: utils.ErrMemberNotFound,
+		},
+		{
+			name: "database error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnError(dbErr)
+			},
+			wantErr: dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			tt.setup(mock)
+
+			got, err := repo.GetMemberByID(context.Background(), 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetMemberByID() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (got.ID != 1 || got.Username != "User1") {
+				t.Errorf("GetMemberByID() = %+v", got)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMySQLRepository_GetAllMembers(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	mock.ExpectQuery(quote("SELECT * FROM `members` WHERE `members`.`DELETED_AT` IS NULL")).
+		WillReturnRows(memberRow(1, "User1", 1).AddRow(2, "User2", "Female", "Dry", "Dark", 1, nil, nil))
+
+	got, err := repo.GetAllMembers(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllMembers() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("GetAllMembers() returned %d members, want 2", len(got))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMySQLRepository_AddNewMember(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "created",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByUsername)).WithArgs("User1", 1).WillReturnRows(sqlmock.NewRows(memberColumns))
+				mock.ExpectBegin()
+				mock.ExpectExec(quote("INSERT INTO `members`")).
+					WithArgs("User1", "Male", "Oily", "Fair", 1, nil, nil).
+					WillReturnResult(sqlmock.NewResult(11, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "username taken",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByUsername)).WithArgs("User1", 1).WillReturnRows(memberRow(3, "User1", 1))
+			},
+			wantErr: utils.ErrUsernameAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			tt.setup(mock)
+
+			member := &models.Member{Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair"}
+			err := repo.AddNewMember(context.Background(), member)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddNewMember() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (member.ID != 11 || member.Version != 1) {
+				t.Errorf("AddNewMember() stored %+v, want ID 11 and version 1", member)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMySQLRepository_UpdateMemberByID(t *testing.T) {
+	update := "UPDATE `members` SET `USERNAME`=?,`GENDER`=?,`SKINTYPE`=?,`SKINCOLOR`=?,`VERSION`=? WHERE (ID_MEMBER = ? AND VERSION = ?) AND `members`.`DELETED_AT` IS NULL"
+
+	tests := []struct {
+		name    string
+		version int
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name:    "replaced",
+			version: 1,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(memberRow(1, "User1", 1))
+				mock.ExpectQuery(quote(selectByUsername)).WithArgs("Renamed", 1).WillReturnRows(sqlmock.NewRows(memberColumns))
+				mock.ExpectBegin()
+				mock.ExpectExec(quote(update)).
+					WithArgs("Renamed", "Male", "Oily", "Fair", 2, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(memberRow(1, "Renamed", 2))
+			},
+		},
+		{
+			name:    "member not found",
+			version: 1,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(sqlmock.NewRows(memberColumns))
+			},
+			wantErr: utils.ErrMemberNotFound,
+		},
+		{
+			name:    "username taken by another member",
+			version: 1,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(memberRow(1, "User1", 1))
+				mock.ExpectQuery(quote(selectByUsername)).WithArgs("Renamed", 1).WillReturnRows(memberRow(2, "Renamed", 1))
+			},
+			wantErr: utils.ErrUsernameAlreadyExists,
+		},
+		{
+			name:    "stale version",
+			version: 1,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(memberRow(1, "User1", 4))
+			},
+			wantErr: utils.ErrVersionMismatch,
+		},
+		{
+			name:    "concurrent update between read and write",
+			version: 1,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(memberRow(1, "User1", 1))
+				mock.ExpectQuery(quote(selectByUsername)).WithArgs("Renamed", 1).WillReturnRows(sqlmock.NewRows(memberColumns))
+				mock.ExpectBegin()
+				mock.ExpectExec(quote(update)).
+					WithArgs("Renamed", "Male", "Oily", "Fair", 2, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: utils.ErrVersionMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			tt.setup(mock)
+
+			member := &models.Member{Username: "Renamed", Gender: "Male", SkinType: "Oily", SkinColor: "Fair", Version: tt.version}
+			got, err := repo.UpdateMemberByID(context.Background(), member, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateMemberByID() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (got.Username != "Renamed" || got.Version != 2) {
+				t.Errorf("UpdateMemberByID() = %+v", got)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMySQLRepository_RestoreMemberByID(t *testing.T) {
+	selectUnscoped := "SELECT * FROM `members` WHERE `members`.`ID_MEMBER` = ?"
+	deletedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "restored",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectUnscoped)).WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(memberColumns).AddRow(1, "User1", "Male", "Oily", "Fair", 2, deletedAt, nil))
+				mock.ExpectQuery(quote(selectByUsername)).WithArgs("User1", 1).WillReturnRows(sqlmock.NewRows(memberColumns))
+				mock.ExpectBegin()
+				mock.ExpectExec(quote("UPDATE `members` SET `DELETED_AT`=?,`VERSION`=VERSION + 1 WHERE ID_MEMBER = ?")).
+					WithArgs(nil, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(quote(selectByID)).WithArgs(1, 1).WillReturnRows(memberRow(1, "User1", 3))
+			},
+		},
+		{
+			name: "not deleted",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectUnscoped)).WithArgs(1, 1).WillReturnRows(memberRow(1, "User1", 2))
+			},
+		},
+		{
+			name: "username taken while deleted",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectUnscoped)).WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows(memberColumns).AddRow(1, "User1", "Male", "Oily", "Fair", 2, deletedAt, nil))
+				mock.ExpectQuery(quote(selectByUsername)).WithArgs("User1", 1).WillReturnRows(memberRow(5, "User1", 1))
+			},
+			wantErr: utils.ErrUsernameAlreadyExists,
+		},
+		{
+			name: "never existed",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(quote(selectUnscoped)).WithArgs(1, 1).WillReturnRows(sqlmock.NewRows(memberColumns))
+			},
+			wantErr: utils.ErrMemberNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			tt.setup(mock)
+
+			_, err := repo.RestoreMemberByID(context.Background(), 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RestoreMemberByID() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMySQLRepository_PurgeDeletedMembers(t *testing.T) {
+	before := time.Now()
+
+	repo, mock := newMockRepository(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(quote("DELETE FROM `members` WHERE DELETED_AT IS NOT NULL AND DELETED_AT < ?")).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgeDeletedMembers(context.Background(), before)
+	if err != nil || purged != 3 {
+		t.Fatalf("PurgeDeletedMembers() = %d, %v, want 3, nil", purged, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMySQLRepository_Conformance(t *testing.T) {
+	testMemberRepository(t, func(t *testing.T) MemberRepository {
+		return NewMemberRepository(testutil.NewDatabase(t))
+	})
+}
+
+func TestMySQLRepository_HonoursContext(t *testing.T) {
+	t.Run("cancelled", func(t *testing.T) {
+		repo, _ := newMockRepository(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := repo.GetMemberByID(ctx, 1); !errors.Is(err, context.Canceled) {
+			t.Errorf("GetMemberByID() error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		repo, mock := newMockRepository(t)
+		mock.ExpectQuery(quote(selectByID)).WillDelayFor(time.Second).WillReturnRows(memberRow(1, "User1", 1))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if _, err := repo.GetMemberByID(ctx, 1); err == nil || time.Since(start) > 500*time.Millisecond {
+			t.Errorf("GetMemberByID() error = %v after %v, want the query cut at the deadline", err, time.Since(start))
+		}
+	})
+}
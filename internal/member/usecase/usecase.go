@@ -4,26 +4,46 @@ import (
 	"context"
 	"social_media/internal/member/models"
 	"social_media/internal/member/repository"
+	productRepo "social_media/internal/product/repository"
+	"social_media/pkg/utils"
+	"strings"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 )
 
 type MemberUsecase struct {
-	MemberRepository *repository.MySQLRepository
+	MemberRepository repository.MemberRepository
+	// ProductCache, when set, is told about every member write
+	ProductCache productRepo.MemberPageCache
 }
 
+//go:generate mockgen -destination=../mock/usecase_mock.go -package=mock social_media/internal/member/usecase MemberUsecaseInterface
+
 type MemberUsecaseInterface interface {
 	GetMemberByID(ctx context.Context, id int) (*models.Member, error)
 	GetAllMember(ctx context.Context) ([]*models.Member, error)
-	UpdateMember(ctx context.Context, id int, member *models.Member) error
-	DeleteMember(ctx context.Context, id int) error
+	UpdateMember(ctx context.Context, id int, member *models.Member) (*models.Member, error)
+	PatchMember(ctx context.Context, id int, version int, patch []byte) (*models.Member, error)
+	DeleteMember(ctx context.Context, id int, version int, mode string) error
+	GetErasures(ctx context.Context, memberID int) ([]*models.Erasure, error)
+	RestoreMember(ctx context.Context, id int) (*models.Member, error)
+	PurgeDeletedMembers(ctx context.Context, before time.Time) (int64, error)
 	AddNewMember(ctx context.Context, member *models.Member) error
 }
 
-func NewMemberUsecase(MemberRepository *repository.MySQLRepository) *MemberUsecase {
+func NewMemberUsecase(MemberRepository repository.MemberRepository) *MemberUsecase {
 	return &MemberUsecase{MemberRepository: MemberRepository}
 }
 
+// WithProductCache drops the cached product pages showing a member whenever
+// the member is updated, erased or restored, so pages never show an old
+// username or an erased member.
+func (h *MemberUsecase) WithProductCache(cache productRepo.MemberPageCache) *MemberUsecase {
+	h.ProductCache = cache
+	return h
+}
+
 func (h *MemberUsecase) GetMemberByID(ctx context.Context, id int) (*models.Member, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.GetMemberByID")
 	defer span.Finish()
@@ -48,35 +68,181 @@ func (h *MemberUsecase) GetAllMember(ctx context.Context) ([]*models.Member, err
 	return result, nil
 }
 
-func (h *MemberUsecase) UpdateMember(ctx context.Context, id int, member *models.Member) error {
+// UpdateMember replaces the stored member. member.Version must hold the
+// version the caller last read, or utils.AnyVersion, otherwise
+// utils.ErrVersionMismatch is returned.
+func (h *MemberUsecase) UpdateMember(ctx context.Context, id int, member *models.Member) (*models.Member, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.UpdateMember")
 	defer span.Finish()
 
-	err := h.MemberRepository.UpdateMemberByID(ctx, member, id)
+	if err := normalizeMember(member); err != nil {
+		return nil, err
+	}
+
+	version, err := h.currentVersion(ctx, id, member.Version)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	member.Version = version
+
+	result, err := h.MemberRepository.UpdateMemberByID(ctx, member, id)
+	if err != nil {
+		return nil, err
+	}
+	h.invalidateProductPages(ctx, id)
+	return result, nil
+}
+
+// PatchMember applies a JSON Merge Patch to the stored member and saves the
+// result with the same validation as a full update.
+func (h *MemberUsecase) PatchMember(ctx context.Context, id int, version int, patch []byte) (*models.Member, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.PatchMember")
+	defer span.Finish()
+
+	existing, err := h.MemberRepository.GetMemberByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version == utils.AnyVersion {
+		version = existing.Version
+	}
+	if existing.Version != version {
+		return nil, utils.ErrVersionMismatch
+	}
+
+	request := models.NewMemberRequest(existing)
+	if err := utils.MergePatch(request, patch); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateStruct(ctx, request); err != nil {
+		return nil, err
+	}
+
+	member := request.ToMember()
+	member.Version = version
+
+	return h.UpdateMember(ctx, id, member)
 }
 
-func (h *MemberUsecase) DeleteMember(ctx context.Context, id int) error {
+// DeleteMember erases a member in the given mode, models.ErasureDelete when
+// empty, and records who asked for it in the audit trail.
+func (h *MemberUsecase) DeleteMember(ctx context.Context, id int, version int, mode string) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.DeleteMember")
 	defer span.Finish()
 
-	err := h.MemberRepository.DeleteMemberByID(ctx, id)
+	if mode == "" {
+		mode = models.ErasureDelete
+	}
+	version, err := h.currentVersion(ctx, id, version)
+	if err != nil {
+		return err
+	}
+	erasure := &models.Erasure{
+		MemberID:  id,
+		Mode:      mode,
+		RequestID: utils.RequestIDFromCtx(ctx),
+	}
+	if principal, ok := utils.PrincipalFromCtx(ctx); ok {
+		erasure.RequestedBy = &principal
+	}
+
+	err = h.MemberRepository.EraseMemberByID(ctx, erasure, version)
 	if err != nil {
 		return err
 	}
+	h.invalidateProductPages(ctx, id)
 	return nil
 }
 
+func (h *MemberUsecase) invalidateProductPages(ctx context.Context, memberID int) {
+	if h.ProductCache != nil {
+		h.ProductCache.InvalidateMember(ctx, memberID)
+	}
+}
+
+// currentVersion resolves utils.AnyVersion to the version of the stored
+// member, other versions are returned as they are.
+func (h *MemberUsecase) currentVersion(ctx context.Context, id int, version int) (int, error) {
+	if version != utils.AnyVersion {
+		return version, nil
+	}
+	existing, err := h.MemberRepository.GetMemberByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return existing.Version, nil
+}
+
+func (h *MemberUsecase) GetErasures(ctx context.Context, memberID int) ([]*models.Erasure, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.GetErasures")
+	defer span.Finish()
+
+	return h.MemberRepository.GetErasuresByMemberID(ctx, memberID)
+}
+
+func (h *MemberUsecase) RestoreMember(ctx context.Context, id int) (*models.Member, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.RestoreMember")
+	defer span.Finish()
+
+	result, err := h.MemberRepository.RestoreMemberByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	h.invalidateProductPages(ctx, id)
+	return result, nil
+}
+
+func (h *MemberUsecase) PurgeDeletedMembers(ctx context.Context, before time.Time) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.PurgeDeletedMembers")
+	defer span.Finish()
+
+	return h.MemberRepository.PurgeDeletedMembers(ctx, before)
+}
+
 func (h *MemberUsecase) AddNewMember(ctx context.Context, member *models.Member) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.AddNewMember")
 	defer span.Finish()
 
+	if err := normalizeMember(member); err != nil {
+		return err
+	}
+
 	err := h.MemberRepository.AddNewMember(ctx, member)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// normalizeMember rewrites the enumerated attributes to their canonical
+// spelling so "oily", "Oily" and "OILY" are stored as the same value.
+func normalizeMember(member *models.Member) error {
+	attributes := []struct {
+		field   string
+		value   *string
+		allowed []string
+	}{
+		{"gender", &member.Gender, models.Genders},
+		{"skinType", &member.SkinType, models.SkinTypes},
+		{"skinColor", &member.SkinColor, models.SkinColors},
+	}
+
+	var fieldErrs utils.ValidationErrors
+	for _, attribute := range attributes {
+		normalized, ok := models.Normalize(*attribute.value, attribute.allowed)
+		if !ok {
+			fieldErrs = append(fieldErrs, utils.FieldError{
+				Field:   attribute.field,
+				Message: "must be one of: " + strings.Join(attribute.allowed, ", "),
+				Allowed: attribute.allowed,
+			})
+			continue
+		}
+		*attribute.value = normalized
+	}
+
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"social_media/internal/member/mock"
+	"social_media/internal/member/models"
+	"social_media/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func newMember() *models.Member {
+	return &models.Member{
+		ID:        1,
+		Username:  "User1",
+		Gender:    models.GenderMale,
+		SkinType:  models.SkinTypeOily,
+		SkinColor: models.SkinColorFair,
+		Version:   1,
+	}
+}
+
+func TestMemberUsecase_GetMemberByID(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  *models.Member
+		repoErr error
+		wantErr error
+	}{
+		{name: "found", result: newMember()},
+		{name: "not found", repoErr: utils.ErrMemberNotFound, wantErr: utils.ErrMemberNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockMemberRepository(ctrl)
+			repo.EXPECT().GetMemberByID(gomock.Any(), 1).Return(tt.result, tt.repoErr)
+
+			got, err := NewMemberUsecase(repo).GetMemberByID(context.Background(), 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetMemberByID() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.result) {
+				t.Errorf("GetMemberByID() = %+v, want %+v", got, tt.result)
+			}
+		})
+	}
+}
+
+func TestMemberUsecase_GetAllMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	members := []*models.Member{newMember()}
+	repo := mock.NewMockMemberRepository(ctrl)
+	repo.EXPECT().GetAllMembers(gomock.Any()).Return(members, nil)
+
+	got, err := NewMemberUsecase(repo).GetAllMember(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllMember() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, members) {
+		t.Errorf("GetAllMember() = %+v, want %+v", got, members)
+	}
+}
+
+func TestMemberUsecase_AddNewMember(t *testing.T) {
+	tests := []struct {
+		name       string
+		member     *models.Member
+		wantStored *models.Member
+		repoErr    error
+		wantErr    error
+		wantFields []string
+	}{
+		{
+			name:       "normalizes enumerated values",
+			member:     &models.Member{Username: "User1", Gender: "male", SkinType: " OILY ", SkinColor: "fair"},
+			wantStored: &models.Member{Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair"},
+		},
+		{
+			name:       "rejects unknown values",
+			member:     &models.Member{Username: "User1", Gender: "other", SkinType: "Oily", SkinColor: "blue"},
+			wantFields: []string{"gender", "skinColor"},
+		},
+		{
+			name:       "username taken",
+			member:     &models.Member{Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair"},
+			wantStored: &models.Member{Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair"},
+			repoErr:    utils.ErrUsernameAlreadyExists,
+			wantErr:    utils.ErrUsernameAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockMemberRepository(ctrl)
+			if tt.wantStored != nil {
+				repo.EXPECT().AddNewMember(gomock.Any(), tt.wantStored).Return(tt.repoErr)
+			}
+
+			err := NewMemberUsecase(repo).AddNewMember(context.Background(), tt.member)
+			assertFieldErrors(t, err, tt.wantFields)
+			if tt.wantFields == nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddNewMember() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMemberUsecase_UpdateMember(t *testing.T) {
+	tests := []struct {
+		name    string
+		member  *models.Member
+		current *models.Member
+		repoErr error
+		wantErr error
+	}{
+		{
+			name:   "updated",
+			member: &models.Member{Username: "User1", Gender: "female", SkinType: "Dry", SkinColor: "Dark", Version: 1},
+		},
+		{
+			name:    "any version updates the current one",
+			member:  &models.Member{Username: "User1", Gender: "Female", SkinType: "Dry", SkinColor: "Dark", Version: utils.AnyVersion},
+			current: &models.Member{ID: 1, Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair", Version: 1},
+		},
+		{
+			name:    "stale version",
+			member:  &models.Member{Username: "User1", Gender: "Female", SkinType: "Dry", SkinColor: "Dark", Version: 1},
+			repoErr: utils.ErrVersionMismatch,
+			wantErr: utils.ErrVersionMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			stored := &models.Member{Username: "User1", Gender: "Female", SkinType: "Dry", SkinColor: "Dark", Version: 1}
+			updated := &models.Member{ID: 1, Username: "User1", Gender: "Female", SkinType: "Dry", SkinColor: "Dark", Version: 2}
+
+			repo := mock.NewMockMemberRepository(ctrl)
+			if tt.current != nil {
+				repo.EXPECT().GetMemberByID(gomock.Any(), 1).Return(tt.current, nil)
+			}
+			if tt.repoErr != nil {
+				updated = nil
+			}
+			repo.EXPECT().UpdateMemberByID(gomock.Any(), stored, 1).Return(updated, tt.repoErr)
+
+			got, err := NewMemberUsecase(repo).UpdateMember(context.Background(), 1, tt.member)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateMember() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, updated) {
+				t.Errorf("UpdateMember() = %+v, want %+v", got, updated)
+			}
+		})
+	}
+}
+
+func TestMemberUsecase_PatchMember(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    int
+		patch      string
+		wantStored *models.Member
+		wantErr    error
+		wantFields []string
+	}{
+		{
+			name:       "changes only the given fields",
+			version:    1,
+			patch:      `{"skinType": "dry"}`,
+			wantStored: &models.Member{Username: "User1", Gender: "Male", SkinType: "Dry", SkinColor: "Fair", Version: 1},
+		},
+		{
+			name:    "stale version",
+			version: 2,
+			patch:   `{"skinType": "Dry"}`,
+			wantErr: utils.ErrVersionMismatch,
+		},
+		{
+			name:       "null clears a required field",
+			version:    1,
+			patch:      `{"username": null}`,
+			wantFields: []string{"username"},
+		},
+		{
+			name:    "not an object",
+			version: 1,
+			patch:   `["skinType"]`,
+			wantErr: utils.BadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockMemberRepository(ctrl)
+			repo.EXPECT().GetMemberByID(gomock.Any(), 1).Return(newMember(), nil)
+			if tt.wantStored != nil {
+				repo.EXPECT().UpdateMemberByID(gomock.Any(), tt.wantStored, 1).Return(tt.wantStored, nil)
+			}
+
+			_, err := NewMemberUsecase(repo).PatchMember(context.Background(), 1, tt.version, []byte(tt.patch))
+			assertFieldErrors(t, err, tt.wantFields)
+			if tt.wantFields == nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PatchMember() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMemberUsecase_DeleteMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		wantMode string
+		repoErr  error
+	}{
+		{name: "deleted", wantMode: models.ErasureDelete},
+		{name: "anonymized", mode: models.ErasureAnonymize, wantMode: models.ErasureAnonymize},
+		{name: "not found", wantMode: models.ErasureDelete, repoErr: utils.ErrMemberNotFound},
+		{name: "stale version", wantMode: models.ErasureDelete, repoErr: utils.ErrVersionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.WithValue(context.Background(), utils.ReqIDCtxKey{}, "req-1")
+			ctx = context.WithValue(ctx, utils.PrincipalCtxKey{}, 7)
+
+			repo := mock.NewMockMemberRepository(ctrl)
+			repo.EXPECT().EraseMemberByID(gomock.Any(), gomock.Any(), 3).DoAndReturn(
+				func(_ context.Context, erasure *models.Erasure, _ int) error {
+					if erasure.MemberID != 1 || erasure.Mode != tt.wantMode || erasure.RequestID != "req-1" || erasure.RequestedBy == nil || *erasure.RequestedBy != 7 {
+						t.Errorf("EraseMemberByID() erasure = %+v", erasure)
+					}
+					return tt.repoErr
+				})
+
+			err := NewMemberUsecase(repo).DeleteMember(ctx, 1, 3, tt.mode)
+			if !errors.Is(err, tt.repoErr) {
+				t.Fatalf("DeleteMember() error = %v, want %v", err, tt.repoErr)
+			}
+		})
+	}
+}
+
+func TestMemberUsecase_RestoreMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockMemberRepository(ctrl)
+	repo.EXPECT().RestoreMemberByID(gomock.Any(), 1).Return(newMember(), nil)
+
+	got, err := NewMemberUsecase(repo).RestoreMember(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("RestoreMember() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, newMember()) {
+		t.Errorf("RestoreMember() = %+v, want %+v", got, newMember())
+	}
+}
+
+func TestMemberUsecase_PurgeDeletedMembers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	before := time.Now()
+	repo := mock.NewMockMemberRepository(ctrl)
+	repo.EXPECT().PurgeDeletedMembers(gomock.Any(), before).Return(int64(2), nil)
+
+	purged, err := NewMemberUsecase(repo).PurgeDeletedMembers(context.Background(), before)
+	if err != nil || purged != 2 {
+		t.Fatalf("PurgeDeletedMembers() = %d, %v, want 2, nil", purged, err)
+	}
+}
+
+func assertFieldErrors(t *testing.T, err error, fields []string) {
+	t.Helper()
+
+	if fields == nil {
+		return
+	}
+
+	var fieldErrs utils.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("error = %v, want validation errors on %v", err, fields)
+	}
+
+	got := make([]string, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		got[i] = fe.Field
+	}
+	if !reflect.DeepEqual(got, fields) {
+		t.Errorf("invalid fields = %v, want %v", got, fields)
+	}
+}
+
+// pageCache records the members whose product pages were dropped.
+type pageCache struct {
+	members []int
+}
+
+func (c *pageCache) InvalidateMember(ctx context.Context, memberID int) {
+	c.members = append(c.members, memberID)
+}
+
+func TestMemberUsecase_InvalidatesProductPages(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(repo *mock.MockMemberRepository, err error)
+		call  func(uc *MemberUsecase) error
+	}{
+		{
+			name: "update",
+			setup: func(repo *mock.MockMemberRepository, err error) {
+				repo.EXPECT().UpdateMemberByID(gomock.Any(), gomock.Any(), 1).Return(newMember(), err)
+			},
+			call: func(uc *MemberUsecase) error {
+				_, err := uc.UpdateMember(context.Background(), 1, newMember())
+				return err
+			},
+		},
+		{
+			name: "anonymize",
+			setup: func(repo *mock.MockMemberRepository, err error) {
+				repo.EXPECT().EraseMemberByID(gomock.Any(), gomock.Any(), 1).Return(err)
+			},
+			call: func(uc *MemberUsecase) error {
+				return uc.DeleteMember(context.Background(), 1, 1, models.ErasureAnonymize)
+			},
+		},
+		{
+			name: "restore",
+			setup: func(repo *mock.MockMemberRepository, err error) {
+				repo.EXPECT().RestoreMemberByID(gomock.Any(), 1).Return(newMember(), err)
+			},
+			call: func(uc *MemberUsecase) error {
+				_, err := uc.RestoreMember(context.Background(), 1)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, repoErr := range []error{nil, utils.ErrVersionMismatch} {
+				ctrl := gomock.NewController(t)
+				repo := mock.NewMockMemberRepository(ctrl)
+				tt.setup(repo, repoErr)
+
+				cache := &pageCache{}
+				if err := tt.call(NewMemberUsecase(repo).WithProductCache(cache)); !errors.Is(err, repoErr) {
+					t.Fatalf("error = %v, want %v", err, repoErr)
+				}
+
+				want := []int{1}
+				if repoErr != nil {
+					want = nil
+				}
+				if !reflect.DeepEqual(cache.members, want) {
+					t.Errorf("with error %v dropped the pages of members %v, want %v", repoErr, cache.members, want)
+				}
+				ctrl.Finish()
+			}
+		})
+	}
+}
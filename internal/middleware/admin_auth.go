@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"social_media/internal/admin/models"
+	"social_media/pkg/utils"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const bearerPrefix = "Bearer "
+
+// AdminAuthenticator resolves admin API keys, the admin usecase satisfies it.
+type AdminAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*models.Admin, error)
+}
+
+// AdminAuth rejects requests without an admin API key issued by the
+// create-admin command in "Authorization: Bearer <key>".
+func (mw *MiddlewareManager) AdminAuth(admins AdminAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if !strings.HasPrefix(header, bearerPrefix) {
+				return c.JSON(utils.ErrorResponse(c, utils.ErrAdminKeyInvalid))
+			}
+
+			admin, err := admins.Authenticate(utils.GetRequestCtx(c), strings.TrimPrefix(header, bearerPrefix))
+			if err != nil {
+				return c.JSON(utils.ErrorResponse(c, err))
+			}
+
+			mw.logger.FromContext(utils.GetRequestCtx(c)).Debugf("Admin request by %s", admin.Username)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"social_media/pkg/utils"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderIdempotencyKey         = "Idempotency-Key"
+	HeaderIdempotentReplayed     = "Idempotent-Replayed"
+	defaultIdempotencyTTL        = 24 * time.Hour
+	defaultMaxIdempotentBodySize = 1 << 20
+	maxIdempotencyKeyLength      = 255
+)
+
+// replayedHeaders are the response headers kept with the body, so a replay
+// tells the client the same version and location as the first response.
+var replayedHeaders = []string{utils.HeaderETag, echo.HeaderLocation}
+
+// IdempotencyMiddleware makes POST requests carrying an Idempotency-Key safe
+// to retry: the first response is stored for the configured TTL and replayed
+// for every repeat. Reusing a key with a different request is rejected.
+func (mw *MiddlewareManager) IdempotencyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		key := req.Header.Get(HeaderIdempotencyKey)
+		if req.Method != http.MethodPost || key == "" {
+			return next(c)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.JSON(utils.ErrorResponse(c, utils.ErrIdempotencyKeyInvalid))
+		}
+
+		maxBodySize := mw.cfg.Idempotency.MaxBodySize
+		if maxBodySize <= 0 {
+			maxBodySize = defaultMaxIdempotentBodySize
+		}
+
+		// The body is read once to fingerprint it, up to maxBodySize bytes
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = ioutil.ReadAll(io.LimitReader(req.Body, int64(maxBodySize)+1))
+			if err != nil {
+				return c.JSON(utils.ErrorResponse(c, err))
+			}
+			if len(body) > maxBodySize {
+				return c.JSON(utils.ErrorResponse(c, utils.ErrIdempotentBodyTooBig))
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		storeKey := idempotencyScope(c) + key
+
+		ttl := mw.cfg.Idempotency.TTL
+		if ttl <= 0 {
+			ttl = defaultIdempotencyTTL
+		}
+
+		record := &IdempotencyRecord{
+			Fingerprint: fingerprint(req.Method, req.URL.Path, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		if existing := mw.idempotencyStore.Reserve(storeKey, record); existing != nil {
+			switch {
+			case existing.Fingerprint != record.Fingerprint:
+				return c.JSON(utils.ErrorResponse(c, utils.ErrIdempotencyKeyReused))
+			case !existing.Completed:
+				return c.JSON(utils.ErrorResponse(c, utils.ErrIdempotencyKeyInProgress))
+			default:
+				header := c.Response().Header()
+				for name, value := range existing.Headers {
+					header.Set(name, value)
+				}
+				header.Set(HeaderIdempotentReplayed, "true")
+				return c.Blob(existing.StatusCode, existing.ContentType, existing.Body)
+			}
+		}
+
+		rbw := &responseBodyWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Response().Writer}
+		c.Response().Writer = rbw
+
+		err := next(c)
+
+		// Server errors are not stored so the client can retry them
+		status := c.Response().Status
+		if err != nil || status >= http.StatusInternalServerError {
+			mw.idempotencyStore.Release(storeKey)
+			return err
+		}
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := c.Response().Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+
+		mw.idempotencyStore.Complete(storeKey, &IdempotencyRecord{
+			Fingerprint: record.Fingerprint,
+			Completed:   true,
+			StatusCode:  status,
+			ContentType: c.Response().Header().Get(echo.HeaderContentType),
+			Headers:     headers,
+			Body:        rbw.body.Bytes(),
+			ExpiresAt:   record.ExpiresAt,
+		})
+
+		return nil
+	}
+}
+
+// idempotencyScope prefixes keys with the route and the caller's credentials,
+// hashed, so that clients picking the same key on different routes, or with
+// different admin keys, never see each other's responses. Identity headers
+// anyone can set, like X-Member-ID, are not part of it.
+func idempotencyScope(c echo.Context) string {
+	credentials := sha256.Sum256([]byte(c.Request().Header.Get(echo.HeaderAuthorization)))
+	return c.Request().Method + " " + c.Path() + "\x00" + hex.EncodeToString(credentials[:]) + "\x00"
+}
+
+func fingerprint(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
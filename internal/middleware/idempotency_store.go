@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord is what is kept for one Idempotency-Key. Completed is
+// false while the first request is still being handled, Headers holds the
+// replayed response headers.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Headers     map[string]string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore keeps the first response for each Idempotency-Key.
+// Reserve must be atomic so that only one of several concurrent requests
+// with the same key is executed.
+type IdempotencyStore interface {
+	// Reserve stores record under key if the key is free and returns nil,
+	// otherwise it returns the existing record.
+	Reserve(key string, record *IdempotencyRecord) *IdempotencyRecord
+	Complete(key string, record *IdempotencyRecord)
+	Release(key string)
+}
+
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]*IdempotencyRecord
+	lastSweep time.Time
+}
+
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(key string, record *IdempotencyRecord) *IdempotencyRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if existing, ok := s.records[key]; ok && now.Before(existing.ExpiresAt) {
+		copied := *existing
+		return &copied
+	}
+
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Complete(key string, record *IdempotencyRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = record
+}
+
+func (s *memoryIdempotencyStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+}
+
+// sweep drops expired records, at most once a minute.
+func (s *memoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
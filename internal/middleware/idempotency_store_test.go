@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	reserved := &IdempotencyRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(time.Hour)}
+
+	if existing := store.Reserve("key", reserved); existing != nil {
+		t.Fatalf("Reserve() on a free key = %+v, want nil", existing)
+	}
+	if existing := store.Reserve("key", &IdempotencyRecord{Fingerprint: "b"}); existing == nil || existing.Fingerprint != "a" || existing.Completed {
+		t.Errorf("Reserve() on a reserved key = %+v, want the pending record", existing)
+	}
+
+	store.Complete("key", &IdempotencyRecord{Fingerprint: "a", Completed: true, StatusCode: 201, ExpiresAt: reserved.ExpiresAt})
+	existing := store.Reserve("key", &IdempotencyRecord{Fingerprint: "a"})
+	if existing == nil || !existing.Completed || existing.StatusCode != 201 {
+		t.Errorf("Reserve() on a completed key = %+v, want the completed record", existing)
+	}
+
+	store.Release("key")
+	if existing := store.Reserve("key", reserved); existing != nil {
+		t.Errorf("Reserve() on a released key = %+v, want nil", existing)
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiresRecords(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+
+	store.Reserve("key", &IdempotencyRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(-time.Second)})
+	if existing := store.Reserve("key", &IdempotencyRecord{Fingerprint: "b", ExpiresAt: time.Now().Add(time.Hour)}); existing != nil {
+		t.Errorf("Reserve() on an expired key = %+v, want nil", existing)
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"social_media/config"
+	"social_media/pkg/utils"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newIdempotentServer serves POST /items and POST /other behind the
+// idempotency middleware, counting the requests that reach the handler.
+func newIdempotentServer(t *testing.T, cfg *config.Config) (*echo.Echo, *int) {
+	t.Helper()
+
+	calls := 0
+	e := serveIdempotent(cfg, func(c echo.Context) error {
+		calls++
+		c.Response().Header().Set(utils.HeaderETag, utils.VersionETag(calls))
+		c.Response().Header().Set(echo.HeaderLocation, "/items/"+strconv.Itoa(calls))
+		return c.JSON(http.StatusCreated, map[string]int{"id": calls})
+	})
+	return e, &calls
+}
+
+// serveIdempotent serves handler on GET, POST /items and POST /other behind
+// the idempotency middleware.
+func serveIdempotent(cfg *config.Config, handler echo.HandlerFunc) *echo.Echo {
+	mw := NewMiddlewareManager(cfg, nil, nil)
+
+	e := echo.New()
+	group := e.Group("", mw.IdempotencyMiddleware)
+	group.GET("/items", handler)
+	group.POST("/items", handler)
+	group.POST("/other", handler)
+	return e
+}
+
+func postIdempotent(e *echo.Echo, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(HeaderIdempotencyKey, "key-1")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddlewareReplaysHeaders(t *testing.T) {
+	e, calls := newIdempotentServer(t, &config.Config{})
+
+	first := postIdempotent(e, "/items", `{"name":"a"}`, nil)
+	replay := postIdempotent(e, "/items", `{"name":"a"}`, nil)
+
+	if *calls != 1 {
+		t.Fatalf("handler called %d times, want 1", *calls)
+	}
+	if replay.Code != http.StatusCreated || replay.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", replay.Code, replay.Body, first.Code, first.Body)
+	}
+	if got := replay.Header().Get(HeaderIdempotentReplayed); got != "true" {
+		t.Errorf("%s = %q, want true", HeaderIdempotentReplayed, got)
+	}
+	for _, name := range []string{utils.HeaderETag, echo.HeaderLocation, echo.HeaderContentType} {
+		if got, want := replay.Header().Get(name), first.Header().Get(name); got != want {
+			t.Errorf("replayed %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestIdempotencyMiddlewareScopesKeys(t *testing.T) {
+	e, calls := newIdempotentServer(t, &config.Config{})
+
+	postIdempotent(e, "/items", `{}`, nil)
+	if rec := postIdempotent(e, "/other", `{}`, nil); rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("same key on another route = %d %s, want a new response", rec.Code, rec.Body)
+	}
+	if rec := postIdempotent(e, "/items", `{}`, map[string]string{echo.HeaderAuthorization: "Bearer other"}); rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("same key with other credentials = %d %s, want a new response", rec.Code, rec.Body)
+	}
+	if *calls != 3 {
+		t.Errorf("handler called %d times, want 3", *calls)
+	}
+
+	// X-Member-ID can be set by anyone, it does not open a new scope
+	rec := postIdempotent(e, "/items", `{}`, map[string]string{utils.HeaderXMemberID: "7"})
+	if rec.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Errorf("same key with another X-Member-ID = %d %s, want a replay", rec.Code, rec.Body)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsLargeBodies(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Idempotency.MaxBodySize = 8
+	e, calls := newIdempotentServer(t, cfg)
+
+	if rec := postIdempotent(e, "/items", `{"a":1}`, nil); rec.Code != http.StatusCreated {
+		t.Errorf("body of 7 bytes status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	rec := postIdempotent(e, "/other", `{"a":"long"}`, nil)
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), utils.IdempotentBodyTooBigCode) {
+		t.Errorf("body over MaxBodySize = %d %s, want %d %s", rec.Code, rec.Body, http.StatusRequestEntityTooLarge, utils.IdempotentBodyTooBigCode)
+	}
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want 1", *calls)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsReusedKeys(t *testing.T) {
+	e, calls := newIdempotentServer(t, &config.Config{})
+
+	postIdempotent(e, "/items", `{"name":"a"}`, nil)
+	rec := postIdempotent(e, "/items", `{"name":"b"}`, nil)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), utils.IdempotencyKeyReusedCode) {
+		t.Errorf("same key with another body = %d %s, want %d %s", rec.Code, rec.Body, http.StatusUnprocessableEntity, utils.IdempotencyKeyReusedCode)
+	}
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want 1", *calls)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsConcurrentRepeats(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	e := serveIdempotent(&config.Config{}, func(c echo.Context) error {
+		close(started)
+		<-release
+		return c.NoContent(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- postIdempotent(e, "/items", `{}`, nil)
+	}()
+	<-started
+
+	rec := postIdempotent(e, "/items", `{}`, nil)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), utils.IdempotencyInProgressCode) {
+		t.Errorf("repeat while in progress = %d %s, want %d %s", rec.Code, rec.Body, http.StatusConflict, utils.IdempotencyInProgressCode)
+	}
+
+	close(release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("first request status = %d, want %d", first.Code, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyMiddlewareDoesNotKeepServerErrors(t *testing.T) {
+	calls := 0
+	e := serveIdempotent(&config.Config{}, func(c echo.Context) error {
+		calls++
+		if calls == 1 {
+			return c.NoContent(http.StatusServiceUnavailable)
+		}
+		return c.NoContent(http.StatusCreated)
+	})
+
+	if rec := postIdempotent(e, "/items", `{}`, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first request status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if rec := postIdempotent(e, "/items", `{}`, nil); rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("retry after a server error = %d, want a new %d", rec.Code, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyMiddlewareSkipsOtherRequests(t *testing.T) {
+	e, calls := newIdempotentServer(t, &config.Config{})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set(HeaderIdempotencyKey, "key-1")
+		e.ServeHTTP(httptest.NewRecorder(), req)
+
+		postIdempotent(e, "/items", `{}`, map[string]string{HeaderIdempotencyKey: ""})
+	}
+	if *calls != 4 {
+		t.Errorf("handler called %d times, want 4", *calls)
+	}
+
+	rec := postIdempotent(e, "/items", `{}`, map[string]string{HeaderIdempotencyKey: strings.Repeat("k", maxIdempotencyKeyLength+1)})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), utils.IdempotencyKeyInvalidCode) {
+		t.Errorf("key over the length limit = %d %s, want %d %s", rec.Code, rec.Body, http.StatusBadRequest, utils.IdempotencyKeyInvalidCode)
+	}
+}
@@ -6,11 +6,35 @@ import (
 )
 
 type MiddlewareManager struct {
-	cfg     *config.Config
-	origins []string
-	logger  zap.Logger
+	cfg              *config.Config
+	origins          []string
+	logger           zap.Logger
+	idempotencyStore IdempotencyStore
+	rateLimitStore   RateLimitStore
+	redactPaths      [][]string
 }
 
 func NewMiddlewareManager(cfg *config.Config, origins []string, logger zap.Logger) *MiddlewareManager {
-	return &MiddlewareManager{cfg: cfg, origins: origins, logger: logger}
+	return &MiddlewareManager{
+		cfg:              cfg,
+		origins:          origins,
+		logger:           logger,
+		idempotencyStore: NewMemoryIdempotencyStore(),
+		rateLimitStore:   NewMemoryRateLimitStore(),
+		redactPaths:      parseRedactPaths(cfg.RequestLogger.RedactFields),
+	}
+}
+
+// WithIdempotencyStore replaces the in-memory store, e.g. with one shared by
+// every instance of the service.
+func (mw *MiddlewareManager) WithIdempotencyStore(store IdempotencyStore) *MiddlewareManager {
+	mw.idempotencyStore = store
+	return mw
+}
+
+// WithRateLimitStore replaces the in-memory store so limits are shared
+// across instances.
+func (mw *MiddlewareManager) WithRateLimitStore(store RateLimitStore) *MiddlewareManager {
+	mw.rateLimitStore = store
+	return mw
 }
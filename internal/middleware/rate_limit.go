@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"math"
+	"social_media/config"
+	"social_media/pkg/utils"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderXRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderXRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderXRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter          = "Retry-After"
+)
+
+// RateLimitMiddleware applies a token bucket per route policy and client IP.
+// X-Member-ID is not authenticated, so it cannot name the client, and the IP
+// only comes from X-Forwarded-For behind server.TrustedProxies.
+func (mw *MiddlewareManager) RateLimitMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !mw.cfg.RateLimit.Enabled {
+			return next(c)
+		}
+
+		route, policy := mw.rateLimitPolicy(c.Request().Method, c.Path())
+		if policy.Requests <= 0 || policy.Per <= 0 {
+			return next(c)
+		}
+
+		burst := policy.Burst
+		if burst <= 0 {
+			burst = policy.Requests
+		}
+		rate := float64(policy.Requests) / policy.Per.Seconds()
+
+		result := mw.rateLimitStore.Take(route+"|ip:"+c.RealIP(), rate, burst)
+
+		header := c.Response().Header()
+		header.Set(HeaderXRateLimitLimit, strconv.Itoa(result.Limit))
+		header.Set(HeaderXRateLimitRemaining, strconv.Itoa(result.Remaining))
+		header.Set(HeaderXRateLimitReset, strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+
+		if !result.Allowed {
+			header.Set(HeaderRetryAfter, strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			return c.JSON(utils.ErrorResponse(c, utils.ErrTooManyRequests))
+		}
+
+		return next(c)
+	}
+}
+
+// rateLimitPolicy returns the policy configured for "METHOD /path", falling
+// back to the default policy shared by every other route.
+func (mw *MiddlewareManager) rateLimitPolicy(method string, path string) (string, config.RateLimitPolicy) {
+	route := method + " " + path
+	for name, policy := range mw.cfg.RateLimit.Routes {
+		if strings.EqualFold(name, route) {
+			return route, policy
+		}
+	}
+	return "default", mw.cfg.RateLimit.Default
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"social_media/config"
+	"social_media/pkg/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+
+	for i, remaining := range []int{1, 0} {
+		result := store.Take("key", 1, 2)
+		if !result.Allowed || result.Limit != 2 || result.Remaining != remaining {
+			t.Errorf("Take() #%d = %+v, want allowed with %d remaining", i+1, result, remaining)
+		}
+	}
+
+	result := store.Take("key", 1, 2)
+	if result.Allowed || result.RetryAfter <= 0 || result.RetryAfter > time.Second {
+		t.Errorf("Take() on an empty bucket = %+v, want denied with a retry within a second", result)
+	}
+	if other := store.Take("other", 1, 2); !other.Allowed {
+		t.Errorf("Take() on another key = %+v, want allowed", other)
+	}
+}
+
+func TestMemoryRateLimitStoreRefills(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+
+	store.Take("key", 100, 1)
+	if result := store.Take("key", 100, 1); result.Allowed {
+		t.Fatalf("Take() on an empty bucket = %+v, want denied", result)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if result := store.Take("key", 100, 1); !result.Allowed {
+		t.Errorf("Take() after a refill = %+v, want allowed", result)
+	}
+}
+
+// serveLimited sends count GET /items requests through the rate limiter and
+// returns the last response.
+func serveLimited(cfg *config.Config, count int) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.GET("/items", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, NewMiddlewareManager(cfg, nil, nil).RateLimitMiddleware)
+
+	var rec *httptest.ResponseRecorder
+	for i := 0; i < count; i++ {
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+	}
+	return rec
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Run("sets the limit headers", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Default = config.RateLimitPolicy{Requests: 3, Per: time.Minute}
+
+		rec := serveLimited(cfg, 1)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+		}
+		for name, want := range map[string]string{HeaderXRateLimitLimit: "3", HeaderXRateLimitRemaining: "2", HeaderXRateLimitReset: "20"} {
+			if got := rec.Header().Get(name); got != want {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+	})
+
+	t.Run("rejects requests over the limit", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Default = config.RateLimitPolicy{Requests: 1, Per: time.Minute}
+
+		rec := serveLimited(cfg, 2)
+		if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), utils.RateLimitedCode) {
+			t.Errorf("second request = %d %s, want %d %s", rec.Code, rec.Body, http.StatusTooManyRequests, utils.RateLimitedCode)
+		}
+		if got := rec.Header().Get(HeaderRetryAfter); got != "60" {
+			t.Errorf("%s = %q, want 60", HeaderRetryAfter, got)
+		}
+	})
+
+	t.Run("prefers the route policy", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Default = config.RateLimitPolicy{Requests: 1, Per: time.Minute}
+		cfg.RateLimit.Routes = map[string]config.RateLimitPolicy{"get /ITEMS": {Requests: 5, Per: time.Minute, Burst: 2}}
+
+		if rec := serveLimited(cfg, 2); rec.Code != http.StatusNoContent || rec.Header().Get(HeaderXRateLimitLimit) != "2" {
+			t.Errorf("second request = %d with limit %q, want %d with the route's burst of 2", rec.Code, rec.Header().Get(HeaderXRateLimitLimit), http.StatusNoContent)
+		}
+	})
+
+	t.Run("does nothing when disabled", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.RateLimit.Default = config.RateLimitPolicy{Requests: 1, Per: time.Minute}
+
+		if rec := serveLimited(cfg, 3); rec.Code != http.StatusNoContent || rec.Header().Get(HeaderXRateLimitLimit) != "" {
+			t.Errorf("third request = %d with limit %q, want %d without limit headers", rec.Code, rec.Header().Get(HeaderXRateLimitLimit), http.StatusNoContent)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// redactBody redacts a body according to its content type: JSON documents
+// with redactJSON, NDJSON line by line, and form-encoded and CSV bodies by
+// field or column name, which only a single segment path can match. It
+// reports false when the body could not be redacted and must not be logged.
+func redactBody(body []byte, contentType string, paths [][]string) ([]byte, bool) {
+	if len(paths) == 0 || len(body) == 0 {
+		return compactJSON(body), true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return redactDocument(body, paths)
+	case mediaType == "application/x-ndjson" || mediaType == "application/ndjson" || mediaType == "application/jsonl":
+		return redactNDJSON(body, paths)
+	case mediaType == "application/x-www-form-urlencoded":
+		return redactForm(body, paths)
+	case mediaType == "text/csv" || mediaType == "application/csv":
+		return redactCSV(body, paths)
+	}
+	return nil, false
+}
+
+func redactDocument(body []byte, paths [][]string) ([]byte, bool) {
+	if !json.Valid(body) {
+		return nil, false
+	}
+	return redactJSON(body, paths), true
+}
+
+func redactNDJSON(body []byte, paths [][]string) ([]byte, bool) {
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		redacted, ok := redactDocument(line, paths)
+		if !ok {
+			return nil, false
+		}
+		buf.Write(redacted)
+		buf.WriteByte('\n')
+	}
+	if scanner.Err() != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+func redactForm(body []byte, paths [][]string) ([]byte, bool) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, false
+	}
+	for key, fieldValues := range values {
+		if redactsField(key, paths) {
+			for i := range fieldValues {
+				fieldValues[i] = redactedValue
+			}
+		}
+	}
+	return []byte(values.Encode()), true
+}
+
+func redactCSV(body []byte, paths [][]string) ([]byte, bool) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, false
+	}
+	if len(records) == 0 {
+		return body, true
+	}
+
+	var redacted []int
+	for i, column := range records[0] {
+		if redactsField(strings.TrimSpace(column), paths) {
+			redacted = append(redacted, i)
+		}
+	}
+	for _, record := range records[1:] {
+		for _, i := range redacted {
+			if i < len(record) {
+				record[i] = redactedValue
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(records); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// redactsField reports whether a flat field, a form key or a CSV column, is
+// matched by a single segment path.
+func redactsField(field string, paths [][]string) bool {
+	for _, path := range paths {
+		if len(path) == 1 && (path[0] == "*" || strings.EqualFold(field, path[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON replaces the values at the given paths with [REDACTED]. A path
+// is a dot separated list of object keys matched case-insensitively, "*"
+// matches any key, and arrays are walked transparently so "items.token"
+// redacts the token of every item. Bodies that are not JSON are returned
+// unchanged.
+func redactJSON(body []byte, paths [][]string) []byte {
+	if len(paths) == 0 || len(body) == 0 {
+		return compactJSON(body)
+	}
+
+	// Numbers are kept as written, float64 would round IDs above 2^53
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return body
+	}
+
+	for _, path := range paths {
+		doc = redactPath(doc, path)
+	}
+
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func redactPath(node interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return redactedValue
+	}
+
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if path[0] == "*" || strings.EqualFold(key, path[0]) {
+				value[key] = redactPath(child, path[1:])
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = redactPath(child, path)
+		}
+	}
+	return node
+}
+
+func compactJSON(body []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
+
+func parseRedactPaths(fields []string) [][]string {
+	paths := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.TrimPrefix(strings.TrimSpace(field), "$.")
+		if field == "" {
+			continue
+		}
+		paths = append(paths, strings.Split(field, "."))
+	}
+	return paths
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"social_media/config"
+	"testing"
+)
+
+func TestRedactJSONKeepsNumbers(t *testing.T) {
+	body := []byte(`{"id":9007199254740993,"price":19.990,"username":"alice"}`)
+
+	got := string(redactJSON(body, parseRedactPaths([]string{"username"})))
+	want := `{"id":9007199254740993,"price":19.990,"username":"[REDACTED]"}`
+	if got != want {
+		t.Errorf("redactJSON() = %s, want %s", got, want)
+	}
+}
+
+func TestRedactJSONPaths(t *testing.T) {
+	body := []byte(`{"Password":"p","items":[{"token":"a"},{"token":"b"}],"card":{"number":"4242","brand":"visa"}}`)
+
+	got := string(redactJSON(body, parseRedactPaths([]string{"password", "$.items.token", "card.*", " "})))
+	want := `{"Password":"[REDACTED]","card":{"brand":"[REDACTED]","number":"[REDACTED]"},"items":[{"token":"[REDACTED]"},{"token":"[REDACTED]"}]}`
+	if got != want {
+		t.Errorf("redactJSON() = %s, want %s", got, want)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	paths := parseRedactPaths([]string{"password"})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+		wantOK      bool
+	}{
+		{"json", "application/json; charset=UTF-8", `{"password": "p"}`, `{"password":"[REDACTED]"}`, true},
+		{"merge patch", "application/merge-patch+json", `{"password":"p"}`, `{"password":"[REDACTED]"}`, true},
+		{"invalid json", "application/json", `{"password":`, "", false},
+		{"ndjson", "application/x-ndjson", "{\"password\":\"p\"}\n\n{\"name\":\"a\"}\n", "{\"password\":\"[REDACTED]\"}\n{\"name\":\"a\"}\n", true},
+		{"form", "application/x-www-form-urlencoded", "name=a&Password=p", "Password=%5BREDACTED%5D&name=a", true},
+		{"csv", "text/csv", "name,password\na,p\nb\n", "name,password\na,[REDACTED]\nb\n", true},
+		{"unknown", "application/octet-stream", "password=p", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := redactBody([]byte(tt.body), tt.contentType, paths)
+			if string(got) != tt.want || ok != tt.wantOK {
+				t.Errorf("redactBody() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoggedBody(t *testing.T) {
+	redacting := &config.Config{}
+	redacting.RequestLogger.RedactFields = []string{"password"}
+
+	tests := []struct {
+		name        string
+		cfg         *config.Config
+		body        string
+		contentType string
+		maxSize     int
+		truncated   int
+		want        string
+	}{
+		{"redacted", redacting, `{"password":"p"}`, "application/json", 100, 0, `{"password":"[REDACTED]"}`},
+		{"cut with redactions", redacting, `{"password":"p"}`, "application/json", 4, 0, "[16 bytes omitted]"},
+		{"truncated before logging with redactions", redacting, `{}`, "application/json", 100, 10, "[12 bytes omitted]"},
+		{"unknown type with redactions", redacting, "secret", "application/octet-stream", 100, 0, "[6 bytes omitted]"},
+		{"cut without redactions", &config.Config{}, `{"name":"alice"}`, "application/json", 4, 0, `{"na...[12 bytes truncated]`},
+		{"compacted without redactions", &config.Config{}, `{ "name": "alice" }`, "application/json", 100, 0, `{"name":"alice"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := NewMiddlewareManager(tt.cfg, nil, nil)
+			if got := mw.loggedBody([]byte(tt.body), tt.contentType, tt.maxSize, tt.truncated); got != tt.want {
+				t.Errorf("loggedBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
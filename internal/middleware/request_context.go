@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"social_media/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestContextMiddleware builds the request-scoped context used by every
+// layer: request ID, principal and a deadline taken from the per-route query
+// timeout. The context is derived from the request's own context, so it is
+// also cancelled when the client disconnects.
+func (mw *MiddlewareManager) RequestContextMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		ctx := context.WithValue(req.Context(), utils.ReqIDCtxKey{}, utils.GetRequestID(c))
+		if memberID, ok := utils.GetPrincipal(c); ok {
+			ctx = context.WithValue(ctx, utils.PrincipalCtxKey{}, memberID)
+		}
+
+		if timeout := mw.queryTimeout(c.Path()); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		c.SetRequest(req.WithContext(ctx))
+
+		return next(c)
+	}
+}
+
+func (mw *MiddlewareManager) queryTimeout(path string) time.Duration {
+	for route, timeout := range mw.cfg.Server.RouteTimeouts {
+		if strings.EqualFold(route, path) {
+			return timeout
+		}
+	}
+	return mw.cfg.Server.QueryTimeout
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"social_media/config"
+	"social_media/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// requestContext returns the context a handler on path sees for req.
+func requestContext(t *testing.T, cfg *config.Config, path string, req *http.Request) context.Context {
+	t.Helper()
+
+	var got context.Context
+	e := echo.New()
+	e.GET(path, func(c echo.Context) error {
+		got = utils.GetRequestCtx(c)
+		return c.NoContent(http.StatusNoContent)
+	}, NewMiddlewareManager(cfg, nil, nil).RequestContextMiddleware)
+
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	if got == nil {
+		t.Fatal("handler was not called")
+	}
+	return got
+}
+
+func TestRequestContextMiddleware(t *testing.T) {
+	t.Run("carries the request ID and principal", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/members/1", nil)
+		req.Header.Set(echo.HeaderXRequestID, "req-1")
+		req.Header.Set(utils.HeaderXMemberID, "7")
+
+		ctx := requestContext(t, &config.Config{}, "/members/:id", req)
+		if got := utils.RequestIDFromCtx(ctx); got != "req-1" {
+			t.Errorf("RequestIDFromCtx() = %q, want req-1", got)
+		}
+		if got, ok := utils.PrincipalFromCtx(ctx); !ok || got != 7 {
+			t.Errorf("PrincipalFromCtx() = %d, %v, want 7", got, ok)
+		}
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("context has a deadline without a query timeout")
+		}
+	})
+
+	t.Run("ignores a malformed principal", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/members/1", nil)
+		req.Header.Set(utils.HeaderXMemberID, "seven")
+
+		if _, ok := utils.PrincipalFromCtx(requestContext(t, &config.Config{}, "/members/:id", req)); ok {
+			t.Error("PrincipalFromCtx() found a principal in a malformed header")
+		}
+	})
+
+	t.Run("applies the route timeout over the default", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Server.QueryTimeout = time.Hour
+		cfg.Server.RouteTimeouts = map[string]time.Duration{"/MEMBERS/:id": time.Minute}
+
+		start := time.Now()
+		deadline, ok := requestContext(t, cfg, "/members/:id", httptest.NewRequest(http.MethodGet, "/members/1", nil)).Deadline()
+		if !ok || deadline.Sub(start) > 2*time.Minute {
+			t.Errorf("deadline = %v, %v, want within the route timeout of a minute", deadline, ok)
+		}
+
+		deadline, ok = requestContext(t, cfg, "/products/:id", httptest.NewRequest(http.MethodGet, "/products/1", nil)).Deadline()
+		if !ok || deadline.Sub(start) < 59*time.Minute {
+			t.Errorf("deadline = %v, %v, want the default timeout of an hour", deadline, ok)
+		}
+	})
+
+	t.Run("is cancelled with the request", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/members/1", nil).WithContext(parent)
+
+		ctx := requestContext(t, &config.Config{}, "/members/:id", req)
+		cancel()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Error("context not cancelled when the client went away")
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Client supplied IDs end up in every log line, so anything that does not
+// look like an identifier is replaced.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestIDMiddleware keeps the client's X-Request-ID or generates one, and
+// echoes it in the response. It runs before routing so every response,
+// including 404s, carries the ID.
+func (mw *MiddlewareManager) RequestIDMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		requestID := req.Header.Get(echo.HeaderXRequestID)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+			req.Header.Set(echo.HeaderXRequestID, requestID)
+		}
+
+		c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+
+		return next(c)
+	}
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"social_media/config"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{"keeps a client ID", "client-id.1:a_B", true},
+		{"generates a missing ID", "", false},
+		{"replaces an ID with other characters", "id\nforged log line", false},
+		{"replaces a long ID", strings.Repeat("a", 65), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			e := echo.New()
+			e.Pre(NewMiddlewareManager(&config.Config{}, nil, nil).RequestIDMiddleware)
+			e.GET("/items", func(c echo.Context) error {
+				seen = c.Request().Header.Get(echo.HeaderXRequestID)
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/items", nil)
+			req.Header.Set(echo.HeaderXRequestID, tt.requestID)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(echo.HeaderXRequestID)
+			if got != seen {
+				t.Errorf("response ID %q, handler saw %q, want the same", got, seen)
+			}
+			if tt.keep && got != tt.requestID {
+				t.Errorf("X-Request-ID = %q, want %q", got, tt.requestID)
+			}
+			if !tt.keep && !uuidV4.MatchString(got) {
+				t.Errorf("X-Request-ID = %q, want a generated UUID", got)
+			}
+		})
+	}
+}
@@ -2,47 +2,161 @@ package middleware
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
-	"regexp"
 	"social_media/pkg/utils"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	defaultMaxLoggedBodySize = 4 << 10
+)
+
 type responseBodyWriter struct {
 	http.ResponseWriter
 	body *bytes.Buffer
+	// limit caps how much of the body is buffered, 0 buffers everything
+	limit     int
+	truncated int
 }
 
 func (w *responseBodyWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	keep := len(b)
+	if w.limit > 0 {
+		if room := w.limit - w.body.Len(); room < keep {
+			if room < 0 {
+				room = 0
+			}
+			keep = room
+		}
+	}
+	w.body.Write(b[:keep])
+	w.truncated += len(b) - keep
 	return w.ResponseWriter.Write(b)
 }
 
+// Flush lets streaming handlers flush through the wrapper.
+func (w *responseBodyWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	count int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.count += n
+	return n, err
+}
+
 func (mw *MiddlewareManager) RequestLoggerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		re := regexp.MustCompile(`\r|\n|\x{0020}`)
+		if mw.skipRequestLog(c) {
+			return next(c)
+		}
 
+		maxBodySize := mw.cfg.RequestLogger.MaxBodySize
+		if maxBodySize <= 0 {
+			maxBodySize = defaultMaxLoggedBodySize
+		}
+
+		// Only the logged part of the body is buffered, the rest is streamed
+		// to the handler so large uploads are not held in memory
 		var bodyAsByteArray []byte
-		if c.Request().Body != nil {
-			bodyAsByteArray, _ = ioutil.ReadAll(c.Request().Body)
-			c.Request().Body = ioutil.NopCloser(bytes.NewBuffer(bodyAsByteArray))
+		rest := &countingReader{Reader: bytes.NewReader(nil)}
+		if body := c.Request().Body; body != nil {
+			bodyAsByteArray, _ = ioutil.ReadAll(io.LimitReader(body, int64(maxBodySize)))
+			rest.Reader = body
+			c.Request().Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(bodyAsByteArray), rest), body}
 		}
 
-		rbw := &responseBodyWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Response().Writer}
+		rbw := &responseBodyWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Response().Writer, limit: maxBodySize}
 		c.Response().Writer = rbw
 
+		start := time.Now()
 		err := next(c)
+		latency := time.Since(start)
 
 		req := c.Request()
-		requestID := utils.GetRequestID(c)
+		status := c.Response().Status
 
-		mw.logger.Infof("REQUEST-ID: %s, METHOD: %s, URI: %s, STATUS: %d, REQUEST: %s, RESPONSE: %s",
-			requestID, req.Method, req.URL, c.Response().Status, re.ReplaceAllString(strings.TrimSpace(string(bodyAsByteArray)), ""), re.ReplaceAllString(rbw.body.String(), ""),
-		)
+		// Failed requests are always logged, the rest are sampled
+		sampleRate := mw.cfg.RequestLogger.SampleRate
+		if status < http.StatusBadRequest && sampleRate > 0 && sampleRate < 1 && rand.Float64() >= sampleRate {
+			return err
+		}
+
+		fields := []interface{}{
+			"method", req.Method,
+			"uri", req.URL.String(),
+			"route", c.Path(),
+			"status", status,
+			"latency", latency,
+			"remoteIp", c.RealIP(),
+			"request", mw.loggedBody(bodyAsByteArray, req.Header.Get(echo.HeaderContentType), maxBodySize, rest.count),
+			"response", mw.loggedBody(rbw.body.Bytes(), c.Response().Header().Get(echo.HeaderContentType), maxBodySize, rbw.truncated),
+		}
+
+		logger := mw.logger.FromContext(utils.GetRequestCtx(c))
+		switch {
+		case status >= http.StatusInternalServerError:
+			logger.Errorw("request", fields...)
+		case status >= http.StatusBadRequest:
+			logger.Warnw("request", fields...)
+		default:
+			logger.Infow("request", fields...)
+		}
 
 		return err
 	}
 }
+
+// loggedBody redacts the configured fields and caps the body at maxSize.
+// truncated is the number of bytes already dropped before the body reached
+// the logger.
+func (mw *MiddlewareManager) loggedBody(body []byte, contentType string, maxSize int, truncated int) string {
+	if len(body) > maxSize {
+		truncated += len(body) - maxSize
+		body = body[:maxSize]
+	}
+
+	// A cut or unknown body cannot be parsed, so it is left out entirely
+	// rather than logged without its redactions
+	if truncated > 0 && len(mw.redactPaths) > 0 {
+		return fmt.Sprintf("[%d bytes omitted]", len(body)+truncated)
+	}
+
+	redacted, ok := redactBody(body, contentType, mw.redactPaths)
+	if !ok {
+		return fmt.Sprintf("[%d bytes omitted]", len(body))
+	}
+	body = redacted
+	if truncated > 0 {
+		return fmt.Sprintf("%s...[%d bytes truncated]", body, truncated)
+	}
+	return string(body)
+}
+
+func (mw *MiddlewareManager) skipRequestLog(c echo.Context) bool {
+	route := c.Request().Method + " " + c.Path()
+	for _, skip := range mw.cfg.RequestLogger.SkipRoutes {
+		if strings.EqualFold(skip, route) || strings.EqualFold(skip, c.Path()) {
+			return true
+		}
+	}
+	return false
+}
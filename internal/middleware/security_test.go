@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"social_media/config"
+	"social_media/pkg/utils"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// serveSecured serves req with GET and POST /items behind middleware.
+func serveSecured(middleware echo.MiddlewareFunc, req *http.Request) *httptest.ResponseRecorder {
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	e := echo.New()
+	e.Use(middleware)
+	e.GET("/items", handler)
+	e.POST("/items", handler)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORS(t *testing.T) {
+	preflight := func(origin string) *http.Request {
+		req := httptest.NewRequest(http.MethodOptions, "/items", nil)
+		req.Header.Set(echo.HeaderOrigin, origin)
+		req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+		return req
+	}
+
+	t.Run("allows listed origins with credentials", func(t *testing.T) {
+		mw := NewMiddlewareManager(&config.Config{}, []string{"https://shop.example"}, nil)
+
+		rec := serveSecured(mw.CORS(), preflight("https://shop.example"))
+		if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != "https://shop.example" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want the origin", got)
+		}
+		if got := rec.Header().Get(echo.HeaderAccessControlAllowCredentials); got != "true" {
+			t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+		}
+	})
+
+	t.Run("rejects other origins", func(t *testing.T) {
+		mw := NewMiddlewareManager(&config.Config{}, []string{"https://shop.example"}, nil)
+
+		rec := serveSecured(mw.CORS(), preflight("https://evil.example"))
+		if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != "" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+		}
+	})
+
+	t.Run("never allows credentials with a wildcard", func(t *testing.T) {
+		mw := NewMiddlewareManager(&config.Config{}, []string{"*"}, nil)
+
+		rec := serveSecured(mw.CORS(), preflight("https://evil.example"))
+		if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != "*" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+		}
+		if got := rec.Header().Get(echo.HeaderAccessControlAllowCredentials); got != "" {
+			t.Errorf("Access-Control-Allow-Credentials = %q, want none", got)
+		}
+	})
+}
+
+func TestCSRF(t *testing.T) {
+	mw := NewMiddlewareManager(&config.Config{}, nil, nil)
+
+	t.Run("rejects a session without a matching token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/items", nil)
+		req.AddCookie(&http.Cookie{Name: defaultSessionCookie, Value: "abc"})
+		req.AddCookie(&http.Cookie{Name: "_csrf", Value: "token"})
+		req.Header.Set(echo.HeaderXCSRFToken, "other")
+
+		rec := serveSecured(mw.CSRF(), req)
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), utils.CSRFTokenInvalidCode) {
+			t.Errorf("POST with a session and a wrong token = %d %s, want 403 %s", rec.Code, rec.Body, utils.CSRFTokenInvalidCode)
+		}
+	})
+
+	t.Run("accepts a session with the token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/items", nil)
+		req.AddCookie(&http.Cookie{Name: defaultSessionCookie, Value: "abc"})
+		req.AddCookie(&http.Cookie{Name: "_csrf", Value: "token"})
+		req.Header.Set(echo.HeaderXCSRFToken, "token")
+
+		if rec := serveSecured(mw.CSRF(), req); rec.Code != http.StatusNoContent {
+			t.Errorf("POST with a session and its token = %d %s, want 204", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("skips requests without a session", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/items", nil)
+
+		if rec := serveSecured(mw.CSRF(), req); rec.Code != http.StatusNoContent {
+			t.Errorf("POST without a session = %d %s, want 204", rec.Code, rec.Body)
+		}
+	})
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	for _, ssl := range []bool{false, true} {
+		cfg := &config.Config{}
+		cfg.Server.SSL = ssl
+		mw := NewMiddlewareManager(cfg, nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.TLS = &tls.ConnectionState{}
+		rec := serveSecured(mw.SecurityHeaders(), req)
+
+		if got := rec.Header().Get(echo.HeaderXFrameOptions); got != "DENY" {
+			t.Errorf("SSL %v: X-Frame-Options = %q, want DENY", ssl, got)
+		}
+		if got := rec.Header().Get(echo.HeaderStrictTransportSecurity); (got != "") != ssl {
+			t.Errorf("SSL %v: Strict-Transport-Security = %q", ssl, got)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"social_media/internal/privacy/models"
+	"social_media/internal/privacy/usecase"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+type PrivacyHandler struct {
+	PrivacyUsecase usecase.PrivacyUsecaseInterface
+	logger         zap.Logger
+}
+
+// MapPrivacyAdminRoutes serves data exports behind admin keys: an archive
+// holds everything stored about a member, and X-Member-ID cannot prove who
+// is asking for it.
+func MapPrivacyAdminRoutes(adminGroup *echo.Group, logger zap.Logger, privacyUsecase usecase.PrivacyUsecaseInterface) {
+	h := &PrivacyHandler{
+		logger:         logger,
+		PrivacyUsecase: privacyUsecase,
+	}
+
+	adminGroup.GET("/members/:id/export", h.ExportMember)
+	adminGroup.GET("/members/:id/export/:jobId", h.DownloadExport)
+}
+
+// ExportMember godoc
+// @Tags Admin
+// @Summary Export a member's data
+// @Description Download everything stored about a member: profile, reviews and likes. Large accounts are exported in the background, the 202 response links to the download.
+// @ID exportMember
+// @Param id path int true "Member ID"
+// @Produce json
+// @Success 200 {object} models.Archive
+// @Success 202 {object} utils.Response{data=models.ExportJob}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/members/{id}/export [get]
+func (h *PrivacyHandler) ExportMember(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.ExportMember")
+	defer span.Finish()
+
+	var request models.MemberExportRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	archive, job, err := h.PrivacyUsecase.ExportMember(ctx, request.ID)
+	if err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	if job != nil {
+		job.DownloadURL = strings.TrimSuffix(c.Request().URL.Path, "/") + "/" + job.ID
+		c.Response().Header().Set(echo.HeaderLocation, job.DownloadURL)
+		return c.JSON(utils.SuccessResponse(c, http.StatusAccepted, "export started", job))
+	}
+
+	setDownloadHeaders(c, request.ID)
+	return c.JSON(http.StatusOK, archive)
+}
+
+// DownloadExport godoc
+// @Tags Admin
+// @Summary Download a member's data export
+// @Description Download the archive of a background export once ready, 202 while it is still running
+// @ID downloadMemberExport
+// @Param id path int true "Member ID"
+// @Param jobId path string true "Export job ID"
+// @Produce json
+// @Success 200 {object} models.Archive
+// @Success 202 {object} utils.Response{data=models.ExportJob}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 410 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/members/{id}/export/{jobId} [get]
+func (h *PrivacyHandler) DownloadExport(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.DownloadExport")
+	defer span.Finish()
+
+	var request models.ExportDownloadRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	job, err := h.PrivacyUsecase.GetExport(ctx, request.ID, request.JobID)
+	if err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	if job.Status != models.ExportReady {
+		job.DownloadURL = c.Request().URL.Path
+		return c.JSON(utils.SuccessResponse(c, http.StatusAccepted, "export in progress", job))
+	}
+
+	setDownloadHeaders(c, request.ID)
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, job.Archive)
+}
+
+// setDownloadHeaders marks the archive as a file to save that no cache may
+// keep, it holds personal data.
+func setDownloadHeaders(c echo.Context, memberID int) {
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="member-%d-export.json"`, memberID))
+	header.Set("Cache-Control", "no-store")
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// ArchiveSchemaVersion is raised whenever a section of Archive changes shape.
+const ArchiveSchemaVersion = 1
+
+// Archive is everything stored about a member, as answered to a data access
+// request. Data holds one entry per section, e.g. "profile", "reviews" and
+// "likes".
+type Archive struct {
+	SchemaVersion int                    `json:"schemaVersion"`
+	MemberID      int                    `json:"memberId"`
+	GeneratedAt   time.Time              `json:"generatedAt"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// Statuses of an ExportJob
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// ExportJob builds the Archive of a large account in the background. The
+// download link stops working at ExpiresAt; until the archive is ready it
+// holds the latest time it can expire.
+type ExportJob struct {
+	ID          string     `json:"id" gorm:"column:ID_EXPORT"`
+	MemberID    int        `json:"memberId" gorm:"column:ID_MEMBER"`
+	Status      string     `json:"status" gorm:"column:STATUS"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"column:CREATED_AT"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" gorm:"column:COMPLETED_AT"`
+	ExpiresAt   time.Time  `json:"expiresAt" gorm:"column:EXPIRES_AT"`
+	DownloadURL string     `json:"downloadUrl,omitempty" gorm:"-"`
+	// Archive is the encoded Archive once the job is ready
+	Archive []byte `json:"-" gorm:"column:ARCHIVE"`
+}
+
+func (ExportJob) TableName() string {
+	return "member_exports"
+}
+
+type MemberExportRequest struct {
+	ID int `param:"id" json:"-" validate:"required,min=1"`
+}
+
+type ExportDownloadRequest struct {
+	ID    int    `param:"id" json:"-" validate:"required,min=1"`
+	JobID string `param:"jobId" json:"-" validate:"required,hexadecimal,len=32"`
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"social_media/internal/privacy/models"
+	"social_media/pkg/utils"
+	"testing"
+	"time"
+)
+
+// testExportStore is the behaviour every ExportStore must share.
+// newStore returns an empty store in which members 1 and 2 exist.
+func testExportStore(t *testing.T, newStore func(t *testing.T) ExportStore) {
+	ctx := context.Background()
+	// Stored times may lose their fractions of a second
+	now := time.Now().Truncate(time.Second)
+
+	newJob := func(id string, memberID int, status string, expiresIn time.Duration) *models.ExportJob {
+		return &models.ExportJob{ID: id, MemberID: memberID, Status: status, CreatedAt: now, ExpiresAt: now.Add(expiresIn)}
+	}
+
+	reserve := func(t *testing.T, store ExportStore, job *models.ExportJob) {
+		t.Helper()
+
+		active, err := store.ReserveExport(ctx, job)
+		if err != nil || active != nil {
+			t.Fatalf("ReserveExport(%s) = %+v, %v, want the job saved", job.ID, active, err)
+		}
+	}
+
+	t.Run("GetExport reports unknown jobs", func(t *testing.T) {
+		if _, err := newStore(t).GetExport(ctx, "unknown"); !errors.Is(err, utils.ErrExportNotFound) {
+			t.Errorf("GetExport() error = %v, want %v", err, utils.ErrExportNotFound)
+		}
+	})
+
+	t.Run("ReserveExport saves a job", func(t *testing.T) {
+		store := newStore(t)
+		reserve(t, store, newJob("job-1", 1, models.ExportPending, time.Hour))
+
+		got, err := store.GetExport(ctx, "job-1")
+		if err != nil {
+			t.Fatalf("GetExport() error = %v", err)
+		}
+		if got.MemberID != 1 || got.Status != models.ExportPending || !got.CreatedAt.Equal(now) || !got.ExpiresAt.Equal(now.Add(time.Hour)) || got.CompletedAt != nil {
+			t.Errorf("GetExport() = %+v", got)
+		}
+	})
+
+	t.Run("ReserveExport returns the member's active job", func(t *testing.T) {
+		store := newStore(t)
+		reserve(t, store, newJob("job-1", 1, models.ExportPending, time.Hour))
+
+		active, err := store.ReserveExport(ctx, newJob("job-2", 1, models.ExportPending, time.Hour))
+		if err != nil || active == nil || active.ID != "job-1" {
+			t.Fatalf("ReserveExport() = %+v, %v, want job-1", active, err)
+		}
+		if _, err := store.GetExport(ctx, "job-2"); !errors.Is(err, utils.ErrExportNotFound) {
+			t.Errorf("GetExport(job-2) error = %v, want it not saved", err)
+		}
+
+		reserve(t, store, newJob("job-3", 2, models.ExportPending, time.Hour))
+	})
+
+	t.Run("ReserveExport ignores failed and expired jobs", func(t *testing.T) {
+		store := newStore(t)
+		reserve(t, store, newJob("job-1", 1, models.ExportPending, time.Hour))
+
+		failed := newJob("job-1", 1, models.ExportFailed, time.Hour)
+		if err := store.SaveExport(ctx, failed); err != nil {
+			t.Fatalf("SaveExport() error = %v", err)
+		}
+		reserve(t, store, newJob("job-2", 1, models.ExportPending, -time.Second))
+		reserve(t, store, newJob("job-3", 1, models.ExportPending, time.Hour))
+	})
+
+	t.Run("SaveExport completes a job", func(t *testing.T) {
+		store := newStore(t)
+		job := newJob("job-1", 1, models.ExportPending, time.Hour)
+		reserve(t, store, job)
+
+		completed := now.Add(time.Minute)
+		job.Status = models.ExportReady
+		job.CompletedAt = &completed
+		job.Archive = []byte(`{"schemaVersion":1}`)
+		if err := store.SaveExport(ctx, job); err != nil {
+			t.Fatalf("SaveExport() error = %v", err)
+		}
+
+		got, err := store.GetExport(ctx, "job-1")
+		if err != nil {
+			t.Fatalf("GetExport() error = %v", err)
+		}
+		if got.Status != models.ExportReady || got.CompletedAt == nil || !got.CompletedAt.Equal(completed) || string(got.Archive) != `{"schemaVersion":1}` {
+			t.Errorf("GetExport() = %+v, archive %s", got, got.Archive)
+		}
+
+		active, err := store.ReserveExport(ctx, newJob("job-2", 1, models.ExportPending, time.Hour))
+		if err != nil || active == nil || active.ID != "job-1" || active.Status != models.ExportReady {
+			t.Errorf("ReserveExport() = %+v, %v, want the ready job-1", active, err)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"social_media/internal/privacy/models"
+	"social_media/pkg/utils"
+	"sync"
+	"time"
+)
+
+// expiredJobRetention is how long an expired job is remembered, without its
+// archive, so its link answers utils.ErrExportExpired rather than not found.
+const expiredJobRetention = 24 * time.Hour
+
+// ExportStore keeps the data export jobs and their archives.
+type ExportStore interface {
+	SaveExport(ctx context.Context, job *models.ExportJob) error
+	// GetExport returns utils.ErrExportNotFound for an unknown id
+	GetExport(ctx context.Context, id string) (*models.ExportJob, error)
+	// ReserveExport saves job unless its member already has a job that is
+	// pending or ready and not expired, returning that job instead. It
+	// returns nil when job was saved, checking and saving in one step so two
+	// requests cannot both start an export.
+	ReserveExport(ctx context.Context, job *models.ExportJob) (*models.ExportJob, error)
+}
+
+// MemoryExportStore is an ExportStore kept in process memory. Jobs are lost
+// on restart and are not shared between instances.
+type MemoryExportStore struct {
+	mu        sync.Mutex
+	jobs      map[string]*models.ExportJob
+	lastSweep time.Time
+}
+
+func NewMemoryExportStore() *MemoryExportStore {
+	return &MemoryExportStore{jobs: make(map[string]*models.ExportJob)}
+}
+
+func (s *MemoryExportStore) SaveExport(ctx context.Context, job *models.ExportJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	stored := *job
+	s.jobs[job.ID] = &stored
+	return nil
+}
+
+func (s *MemoryExportStore) GetExport(ctx context.Context, id string) (*models.ExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, utils.ErrExportNotFound
+	}
+
+	result := *job
+	return &result, nil
+}
+
+func (s *MemoryExportStore) ReserveExport(ctx context.Context, job *models.ExportJob) (*models.ExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	var active *models.ExportJob
+	for _, stored := range s.jobs {
+		if stored.MemberID != job.MemberID || stored.Status == models.ExportFailed || !now.Before(stored.ExpiresAt) {
+			continue
+		}
+		if active == nil || stored.CreatedAt.After(active.CreatedAt) {
+			active = stored
+		}
+	}
+	if active != nil {
+		result := *active
+		return &result, nil
+	}
+
+	stored := *job
+	s.jobs[job.ID] = &stored
+	return nil, nil
+}
+
+// sweep frees the archives of expired jobs and forgets the jobs once their
+// retention has passed, at most once a minute.
+func (s *MemoryExportStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for id, job := range s.jobs {
+		switch {
+		case now.After(job.ExpiresAt.Add(expiredJobRetention)):
+			delete(s.jobs, id)
+		case !now.Before(job.ExpiresAt):
+			job.Archive = nil
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"social_media/internal/privacy/models"
+	"social_media/pkg/utils"
+	"testing"
+	"time"
+)
+
+func TestMemoryExportStore(t *testing.T) {
+	testExportStore(t, func(t *testing.T) ExportStore {
+		return NewMemoryExportStore()
+	})
+}
+
+func TestMemoryExportStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryExportStore()
+
+	store.SaveExport(ctx, &models.ExportJob{ID: "expired", MemberID: 1, Status: models.ExportReady, ExpiresAt: now.Add(-time.Second), Archive: []byte("{}")})
+	store.SaveExport(ctx, &models.ExportJob{ID: "forgotten", MemberID: 1, Status: models.ExportReady, ExpiresAt: now.Add(-expiredJobRetention - time.Second), Archive: []byte("{}")})
+
+	store.lastSweep = time.Time{}
+	got, err := store.GetExport(ctx, "expired")
+	if err != nil || got.Archive != nil {
+		t.Errorf("GetExport(expired) = %+v, %v, want the job without its archive", got, err)
+	}
+	if _, err := store.GetExport(ctx, "forgotten"); !errors.Is(err, utils.ErrExportNotFound) {
+		t.Errorf("GetExport(forgotten) error = %v, want %v", err, utils.ErrExportNotFound)
+	}
+}
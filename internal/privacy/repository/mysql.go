@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"social_media/internal/privacy/models"
+	"social_media/pkg/utils"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MySQLExportStore is an ExportStore kept in the member_exports table, so
+// jobs survive restarts and are shared between instances.
+type MySQLExportStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewMySQLExportStore(db *gorm.DB) *MySQLExportStore {
+	return &MySQLExportStore{db: db}
+}
+
+func (s *MySQLExportStore) SaveExport(ctx context.Context, job *models.ExportJob) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.SaveExport")
+	defer span.Finish()
+
+	if err := s.sweep(ctx, time.Now()); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Save(job).Error
+}
+
+func (s *MySQLExportStore) GetExport(ctx context.Context, id string) (*models.ExportJob, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetExport")
+	defer span.Finish()
+
+	if err := s.sweep(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var job models.ExportJob
+	err := s.db.WithContext(ctx).Where("ID_EXPORT = ?", id).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *MySQLExportStore) ReserveExport(ctx context.Context, job *models.ExportJob) (*models.ExportJob, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.ReserveExport")
+	defer span.Finish()
+
+	now := time.Now()
+	if err := s.sweep(ctx, now); err != nil {
+		return nil, err
+	}
+
+	var active *models.ExportJob
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the member row makes concurrent exports of a member wait
+		// for each other, so only the first one starts a job
+		var memberIDs []int
+		err := tx.Table("members").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("ID_MEMBER = ?", job.MemberID).
+			Pluck("ID_MEMBER", &memberIDs).Error
+		if err != nil {
+			return err
+		}
+
+		// The archive of the active job is not needed, only its link
+		var jobs []models.ExportJob
+		err = tx.Omit("ARCHIVE").
+			Where("ID_MEMBER = ? AND STATUS <> ? AND EXPIRES_AT > ?", job.MemberID, models.ExportFailed, now).
+			Order("CREATED_AT DESC").
+			Limit(1).
+			Find(&jobs).Error
+		if err != nil {
+			return err
+		}
+		if len(jobs) > 0 {
+			active = &jobs[0]
+			return nil
+		}
+
+		return tx.Create(job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return active, nil
+}
+
+// sweep frees the archives of expired jobs and forgets the jobs once their
+// retention has passed, at most once a minute.
+func (s *MySQLExportStore) sweep(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < time.Minute {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	db := s.db.WithContext(ctx)
+	err := db.Where("EXPIRES_AT < ?", now.Add(-expiredJobRetention)).Delete(&models.ExportJob{}).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&models.ExportJob{}).
+		Where("EXPIRES_AT <= ? AND ARCHIVE IS NOT NULL", now).
+		Update("ARCHIVE", nil).Error
+}
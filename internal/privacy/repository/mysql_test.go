@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	memberModels "social_media/internal/member/models"
+	"social_media/internal/privacy/models"
+	"social_media/internal/testutil"
+	"social_media/pkg/utils"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newExportDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := testutil.NewDatabase(t)
+	testutil.Seed(t, db, testutil.Fixtures{Members: []memberModels.Member{
+		{ID: 1, Username: "User1", Gender: memberModels.GenderMale, SkinType: memberModels.SkinTypeOily, SkinColor: memberModels.SkinColorFair},
+		{ID: 2, Username: "User2", Gender: memberModels.GenderFemale, SkinType: memberModels.SkinTypeDry, SkinColor: memberModels.SkinColorDark},
+	}})
+	return db
+}
+
+func TestMySQLExportStore_Conformance(t *testing.T) {
+	testExportStore(t, func(t *testing.T) ExportStore {
+		return NewMySQLExportStore(newExportDatabase(t))
+	})
+}
+
+func TestMySQLExportStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	db := newExportDatabase(t)
+
+	writer := NewMySQLExportStore(db)
+	for _, job := range []*models.ExportJob{
+		{ID: "expired", MemberID: 1, Status: models.ExportReady, CreatedAt: now, ExpiresAt: now.Add(-time.Second), Archive: []byte("{}")},
+		{ID: "forgotten", MemberID: 1, Status: models.ExportReady, CreatedAt: now, ExpiresAt: now.Add(-expiredJobRetention - time.Second), Archive: []byte("{}")},
+	} {
+		if err := writer.SaveExport(ctx, job); err != nil {
+			t.Fatalf("SaveExport(%s) error = %v", job.ID, err)
+		}
+	}
+
+	// A new store sweeps on its first call
+	store := NewMySQLExportStore(db)
+	got, err := store.GetExport(ctx, "expired")
+	if err != nil || got.Archive != nil {
+		t.Errorf("GetExport(expired) = %+v, %v, want the job without its archive", got, err)
+	}
+	if _, err := store.GetExport(ctx, "forgotten"); !errors.Is(err, utils.ErrExportNotFound) {
+		t.Errorf("GetExport(forgotten) error = %v, want %v", err, utils.ErrExportNotFound)
+	}
+}
+
+func TestMySQLExportStore_PurgedMember(t *testing.T) {
+	ctx := context.Background()
+	db := newExportDatabase(t)
+	store := NewMySQLExportStore(db)
+
+	job := &models.ExportJob{ID: "job-1", MemberID: 1, Status: models.ExportPending, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if active, err := store.ReserveExport(ctx, job); err != nil || active != nil {
+		t.Fatalf("ReserveExport() = %+v, %v", active, err)
+	}
+
+	if err := db.Exec("DELETE FROM members WHERE ID_MEMBER = 1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetExport(ctx, "job-1"); !errors.Is(err, utils.ErrExportNotFound) {
+		t.Errorf("GetExport() of a purged member's job error = %v, want %v", err, utils.ErrExportNotFound)
+	}
+}
@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	memberRepo "social_media/internal/member/repository"
+	"social_media/internal/privacy/models"
+	"social_media/internal/privacy/repository"
+	productRepo "social_media/internal/product/repository"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	defaultSyncLimit = 1000
+	defaultWorkers   = 2
+	defaultTimeout   = 10 * time.Minute
+	defaultTTL       = 24 * time.Hour
+)
+
+// ExportOptions mirror config.DataExportConfig, zero values take the
+// defaults.
+type ExportOptions struct {
+	SyncLimit int
+	Workers   int
+	Timeout   time.Duration
+	TTL       time.Duration
+}
+
+// Section collects one part of a member's archive. Size counts its rows
+// cheaply, deciding whether the export runs in the background. A new kind of
+// social data is added to exports by registering its Section.
+type Section interface {
+	Name() string
+	Size(ctx context.Context, memberID int) (int, error)
+	Collect(ctx context.Context, memberID int) (interface{}, error)
+}
+
+type PrivacyUsecase struct {
+	MemberRepository memberRepo.MemberRepository
+	ExportStore      repository.ExportStore
+	logger           zap.Logger
+	opts             ExportOptions
+	sections         []Section
+	// workers bounds the background exports running at once
+	workers chan struct{}
+	// running counts the background exports started and not yet saved,
+	// background is their context, cancelled by Shutdown
+	running          sync.WaitGroup
+	background       context.Context
+	cancelBackground context.CancelFunc
+}
+
+type PrivacyUsecaseInterface interface {
+	ExportMember(ctx context.Context, memberID int) (*models.Archive, *models.ExportJob, error)
+	GetExport(ctx context.Context, memberID int, jobID string) (*models.ExportJob, error)
+}
+
+// NewPrivacyUsecase exports the profile, reviews and likes of members.
+func NewPrivacyUsecase(memberRepository memberRepo.MemberRepository, productRepository productRepo.ProductRepository, store repository.ExportStore, logger zap.Logger, opts ExportOptions) *PrivacyUsecase {
+	if opts.SyncLimit <= 0 {
+		opts.SyncLimit = defaultSyncLimit
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultTTL
+	}
+
+	u := &PrivacyUsecase{
+		MemberRepository: memberRepository,
+		ExportStore:      store,
+		logger:           logger,
+		opts:             opts,
+		workers:          make(chan struct{}, opts.Workers),
+	}
+	u.background, u.cancelBackground = context.WithCancel(context.Background())
+	u.AddSection(&profileSection{members: memberRepository})
+	u.AddSection(&reviewsSection{products: productRepository})
+	u.AddSection(&likesSection{products: productRepository})
+	return u
+}
+
+// AddSection adds a section to every archive built afterwards.
+func (u *PrivacyUsecase) AddSection(section Section) {
+	u.sections = append(u.sections, section)
+}
+
+// ExportMember returns the archive of a member right away when their
+// sections hold at most SyncLimit rows. Larger accounts get an ExportJob
+// instead, building the archive in the background; a member with a pending
+// or ready job gets that job again.
+func (u *PrivacyUsecase) ExportMember(ctx context.Context, memberID int) (*models.Archive, *models.ExportJob, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.ExportMember")
+	defer span.Finish()
+
+	if _, err := u.MemberRepository.GetMemberByID(ctx, memberID); err != nil {
+		return nil, nil, err
+	}
+
+	size := 0
+	for _, section := range u.sections {
+		rows, err := section.Size(ctx, memberID)
+		if err != nil {
+			return nil, nil, err
+		}
+		size += rows
+	}
+
+	if size <= u.opts.SyncLimit {
+		archive, err := u.collect(ctx, memberID)
+		return archive, nil, err
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	job := &models.ExportJob{
+		ID:        id,
+		MemberID:  memberID,
+		Status:    models.ExportPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(u.opts.Timeout + u.opts.TTL),
+	}
+	active, err := u.ExportStore.ReserveExport(ctx, job)
+	if err != nil || active != nil {
+		return nil, active, err
+	}
+
+	u.running.Add(1)
+	go u.runExport(*job)
+
+	return nil, job, nil
+}
+
+// Shutdown waits for the background exports to be saved. When ctx is done
+// first, the exports still running are cancelled and saved as failed.
+func (u *PrivacyUsecase) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		u.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		u.cancelBackground()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// GetExport returns an export job of a member, with its archive once ready.
+func (u *PrivacyUsecase) GetExport(ctx context.Context, memberID int, jobID string) (*models.ExportJob, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.GetExport")
+	defer span.Finish()
+
+	job, err := u.ExportStore.GetExport(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	// Another member's job is reported as missing, not forbidden
+	if job.MemberID != memberID {
+		return nil, utils.ErrExportNotFound
+	}
+
+	switch {
+	case !time.Now().Before(job.ExpiresAt):
+		return nil, utils.ErrExportExpired
+	case job.Status == models.ExportFailed:
+		return nil, utils.ErrExportFailed
+	}
+	return job, nil
+}
+
+// runExport builds the archive of job outside of any request, waiting for a
+// free worker first. The wait counts towards the export's Timeout.
+func (u *PrivacyUsecase) runExport(job models.ExportJob) {
+	defer u.running.Done()
+
+	span := opentracing.StartSpan("usecase.runExport")
+	defer span.Finish()
+
+	ctx, cancel := context.WithTimeout(opentracing.ContextWithSpan(u.background, span), u.opts.Timeout)
+	defer cancel()
+
+	var archive *models.Archive
+	var err error
+	select {
+	case u.workers <- struct{}{}:
+		archive, err = u.collect(ctx, job.MemberID)
+		<-u.workers
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err == nil {
+		job.Archive, err = json.Marshal(archive)
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		u.logger.Errorf("Error exporting the data of member %d: %v", job.MemberID, err)
+		job.Status = models.ExportFailed
+	} else {
+		job.Status = models.ExportReady
+		job.ExpiresAt = now.Add(u.opts.TTL)
+	}
+
+	// The job is saved even when ctx timed out
+	if err := u.ExportStore.SaveExport(context.Background(), &job); err != nil {
+		u.logger.Errorf("Error saving the data export of member %d: %v", job.MemberID, err)
+	}
+}
+
+func (u *PrivacyUsecase) collect(ctx context.Context, memberID int) (*models.Archive, error) {
+	archive := &models.Archive{
+		SchemaVersion: models.ArchiveSchemaVersion,
+		MemberID:      memberID,
+		GeneratedAt:   time.Now().UTC(),
+		Data:          make(map[string]interface{}, len(u.sections)),
+	}
+	for _, section := range u.sections {
+		data, err := section.Collect(ctx, memberID)
+		if err != nil {
+			return nil, err
+		}
+		archive.Data[section.Name()] = data
+	}
+	return archive, nil
+}
+
+// newJobID returns 128 random bits, the ID is the only secret of the
+// download link.
+func newJobID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+type profileSection struct {
+	members memberRepo.MemberRepository
+}
+
+func (s *profileSection) Name() string { return "profile" }
+
+func (s *profileSection) Size(ctx context.Context, memberID int) (int, error) { return 1, nil }
+
+func (s *profileSection) Collect(ctx context.Context, memberID int) (interface{}, error) {
+	return s.members.GetMemberByID(ctx, memberID)
+}
+
+type reviewsSection struct {
+	products productRepo.ProductRepository
+}
+
+func (s *reviewsSection) Name() string { return "reviews" }
+
+// Size counts the reviews and likes together, likesSection adds nothing.
+func (s *reviewsSection) Size(ctx context.Context, memberID int) (int, error) {
+	count, err := s.products.CountMemberActivity(ctx, memberID)
+	return int(count), err
+}
+
+func (s *reviewsSection) Collect(ctx context.Context, memberID int) (interface{}, error) {
+	return s.products.GetReviewsByMemberID(ctx, memberID)
+}
+
+type likesSection struct {
+	products productRepo.ProductRepository
+}
+
+func (s *likesSection) Name() string { return "likes" }
+
+func (s *likesSection) Size(ctx context.Context, memberID int) (int, error) { return 0, nil }
+
+func (s *likesSection) Collect(ctx context.Context, memberID int) (interface{}, error) {
+	return s.products.GetLikesByMemberID(ctx, memberID)
+}
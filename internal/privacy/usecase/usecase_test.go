@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"social_media/config"
+	memberModels "social_media/internal/member/models"
+	memberRepo "social_media/internal/member/repository"
+	"social_media/internal/privacy/models"
+	"social_media/internal/privacy/repository"
+	productModels "social_media/internal/product/models"
+	productRepo "social_media/internal/product/repository"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestUsecase(t *testing.T, opts ExportOptions) *PrivacyUsecase {
+	t.Helper()
+
+	members := memberRepo.NewMemoryMemberRepository(
+		&memberModels.Member{ID: 1, Username: "alice", Gender: "Female", SkinType: "Dry", SkinColor: "Fair"},
+		&memberModels.Member{ID: 2, Username: "bob", Gender: "Male", SkinType: "Oily", SkinColor: "Dark"},
+	)
+	products := productRepo.NewMemoryProductRepository(members)
+	products.AddProduct(&productModels.Product{ID: 1, Name: "Serum", Price: 25})
+	products.AddReview(&productModels.ReviewData{ID: 1, MemberID: 1, ProductID: 1, Description: "Lovely"})
+	products.AddReview(&productModels.ReviewData{ID: 2, MemberID: 2, ProductID: 1, Description: "Sticky"})
+	products.AddLike(productModels.LikeReview{ReviewID: 2, MemberID: 1})
+
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	cfg.Logger.Encoding = "json"
+	logger := zap.NewAppLogger(cfg)
+	logger.InitLogger()
+
+	return NewPrivacyUsecase(members, products, repository.NewMemoryExportStore(), logger, opts)
+}
+
+// waitForExport polls a job until it is no longer pending.
+func waitForExport(t *testing.T, u *PrivacyUsecase, memberID int, jobID string) (*models.ExportJob, error) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := u.GetExport(context.Background(), memberID, jobID)
+		if err != nil || job.Status != models.ExportPending || time.Now().After(deadline) {
+			return job, err
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExportMember(t *testing.T) {
+	ctx := context.Background()
+	u := newTestUsecase(t, ExportOptions{})
+
+	archive, job, err := u.ExportMember(ctx, 1)
+	if err != nil || job != nil {
+		t.Fatalf("ExportMember() = %+v, %+v, %v, want an archive", archive, job, err)
+	}
+	if archive.SchemaVersion != models.ArchiveSchemaVersion || archive.MemberID != 1 {
+		t.Errorf("archive = %+v", archive)
+	}
+
+	profile := archive.Data["profile"].(*memberModels.Member)
+	reviews := archive.Data["reviews"].([]*productModels.MemberReview)
+	likes := archive.Data["likes"].([]*productModels.MemberLike)
+	if profile.Username != "alice" || len(reviews) != 1 || reviews[0].ProductName != "Serum" || len(likes) != 1 || likes[0].ReviewID != 2 {
+		t.Errorf("archive data = %+v, %+v, %+v", profile, reviews, likes)
+	}
+
+	if _, _, err := u.ExportMember(ctx, 42); !errors.Is(err, utils.ErrMemberNotFound) {
+		t.Errorf("ExportMember() of an unknown member error = %v, want ErrMemberNotFound", err)
+	}
+}
+
+func TestExportMemberInBackground(t *testing.T) {
+	ctx := context.Background()
+	u := newTestUsecase(t, ExportOptions{SyncLimit: 1})
+
+	archive, job, err := u.ExportMember(ctx, 1)
+	if err != nil || archive != nil || job.Status != models.ExportPending || len(job.ID) != 32 {
+		t.Fatalf("ExportMember() = %+v, %+v, %v, want a pending job", archive, job, err)
+	}
+
+	ready, err := waitForExport(t, u, 1, job.ID)
+	if err != nil || ready.Status != models.ExportReady || ready.CompletedAt == nil {
+		t.Fatalf("GetExport() = %+v, %v, want a ready job", ready, err)
+	}
+
+	var decoded models.Archive
+	if err := json.Unmarshal(ready.Archive, &decoded); err != nil || decoded.MemberID != 1 || len(decoded.Data) != 3 {
+		t.Errorf("archive = %s, %v", ready.Archive, err)
+	}
+
+	// The ready job is handed out again rather than building a new one
+	_, again, err := u.ExportMember(ctx, 1)
+	if err != nil || again.ID != job.ID {
+		t.Errorf("ExportMember() again = %+v, %v, want job %s", again, err, job.ID)
+	}
+
+	if _, err := u.GetExport(ctx, 2, job.ID); !errors.Is(err, utils.ErrExportNotFound) {
+		t.Errorf("GetExport() by another member error = %v, want ErrExportNotFound", err)
+	}
+}
+
+func TestExportExpires(t *testing.T) {
+	u := newTestUsecase(t, ExportOptions{SyncLimit: 1, TTL: 20 * time.Millisecond})
+
+	_, job, err := u.ExportMember(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := waitForExport(t, u, 1, job.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := u.GetExport(context.Background(), 1, job.ID); !errors.Is(err, utils.ErrExportExpired) {
+		t.Errorf("GetExport() after the TTL error = %v, want ErrExportExpired", err)
+	}
+}
+
+// failingSection stands in for a section whose storage is down.
+type failingSection struct{}
+
+func (failingSection) Name() string { return "broken" }
+
+func (failingSection) Size(ctx context.Context, memberID int) (int, error) { return 0, nil }
+
+func (failingSection) Collect(ctx context.Context, memberID int) (interface{}, error) {
+	return nil, errors.New("storage is down")
+}
+
+func TestExportFails(t *testing.T) {
+	ctx := context.Background()
+	u := newTestUsecase(t, ExportOptions{SyncLimit: 1})
+	u.AddSection(failingSection{})
+
+	_, job, err := u.ExportMember(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := waitForExport(t, u, 1, job.ID); !errors.Is(err, utils.ErrExportFailed) {
+		t.Fatalf("GetExport() of a failed job error = %v, want ErrExportFailed", err)
+	}
+
+	// A failed job does not block a new request
+	_, retry, err := u.ExportMember(ctx, 1)
+	if err != nil || retry.ID == job.ID {
+		t.Errorf("ExportMember() after a failure = %+v, %v, want a new job", retry, err)
+	}
+}
+
+func TestExportMemberConcurrently(t *testing.T) {
+	u := newTestUsecase(t, ExportOptions{SyncLimit: 1})
+
+	jobs := make([]*models.ExportJob, 20)
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, job, err := u.ExportMember(context.Background(), 1)
+			if err != nil {
+				t.Error(err)
+			}
+			jobs[i] = job
+		}(i)
+	}
+	wg.Wait()
+
+	for _, job := range jobs {
+		if job == nil || job.ID != jobs[0].ID {
+			t.Fatalf("ExportMember() started jobs %+v and %+v, want a single job", jobs[0], job)
+		}
+	}
+}
+
+// blockingSection collects nothing until released or cancelled.
+type blockingSection struct {
+	release chan struct{}
+}
+
+func (blockingSection) Name() string { return "blocking" }
+
+func (blockingSection) Size(ctx context.Context, memberID int) (int, error) { return 0, nil }
+
+func (s blockingSection) Collect(ctx context.Context, memberID int) (interface{}, error) {
+	select {
+	case <-s.release:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestShutdownWaitsForExports(t *testing.T) {
+	ctx := context.Background()
+	u := newTestUsecase(t, ExportOptions{SyncLimit: 1})
+	section := blockingSection{release: make(chan struct{})}
+	u.AddSection(section)
+
+	_, job, err := u.ExportMember(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(section.release)
+	}()
+	if err := u.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if ready, err := u.GetExport(ctx, 1, job.ID); err != nil || ready.Status != models.ExportReady {
+		t.Errorf("GetExport() after Shutdown() = %+v, %v, want a ready job", ready, err)
+	}
+}
+
+func TestShutdownCancelsExports(t *testing.T) {
+	ctx := context.Background()
+	u := newTestUsecase(t, ExportOptions{SyncLimit: 1})
+	u.AddSection(blockingSection{release: make(chan struct{})})
+
+	_, job, err := u.ExportMember(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := u.Shutdown(shutdownCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want DeadlineExceeded", err)
+	}
+	if _, err := u.GetExport(ctx, 1, job.ID); !errors.Is(err, utils.ErrExportFailed) {
+		t.Errorf("GetExport() of a cancelled job error = %v, want ErrExportFailed", err)
+	}
+}
@@ -2,13 +2,13 @@ package http
 
 import (
 	"net/http"
-	_ "social_media/internal/product/models"
+	"social_media/internal/product/models"
 	"social_media/internal/product/usecase"
 	"social_media/pkg/utils"
 	"social_media/pkg/zap"
-	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
 )
 
 type ProductHandler struct {
@@ -27,30 +27,56 @@ func MapProductRoutes(productGroup *echo.Group, logger zap.Logger, productUsecas
 	productGroup.DELETE("/reviews/:userId/:id/like", h.CancelLikeReview)
 }
 
+func MapProductAdminRoutes(adminGroup *echo.Group, logger zap.Logger, productUsecase usecase.ProductUsecaseInterface) {
+	h := &ProductHandler{
+		ProductUsecase: productUsecase,
+		logger:         logger,
+	}
+
+	adminGroup.DELETE("/products/:id", h.DeleteProduct)
+	adminGroup.POST("/products/:id/restore", h.RestoreProduct)
+	adminGroup.DELETE("/reviews/:id", h.DeleteReview)
+	adminGroup.POST("/reviews/:id/restore", h.RestoreReview)
+}
+
 // GetProductWithReview godoc
 // @Tags Product
 // @Summary Get product with reviews
 // @Description Get a product along with its reviews by ID
 // @ID getProductWithReview
 // @Param id path int true "Product ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Produce json
 // @Success 200 {object} utils.Response{data=models.ProductWithReview{models.Product, []models.Review}}
+// @Success 304
 // @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /products/{id} [get]
 func (h *ProductHandler) GetProductWithReview(c echo.Context) error {
-	productID, err := strconv.Atoi(c.Param("id"))
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.GetProductWithReview")
+	defer span.Finish()
+
+	var request models.ProductIDRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.GetProductWithReview", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	productWithReview, err := h.ProductUsecase.GetProductWithReview(ctx, request.ID)
 	if err != nil {
-		h.logger.Infof(err.Error())
+		zap.HandlerError(h.logger.FromContext(ctx), "http.GetProductWithReview", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
-	ctx := c.Request().Context()
-	productWithReview, err := h.ProductUsecase.GetProductWithReview(ctx, productID)
+	etag, err := utils.ContentETag(productWithReview)
 	if err != nil {
-		h.logger.Infof(err.Error())
+		zap.HandlerError(h.logger.FromContext(ctx), "http.GetProductWithReview", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
+	if utils.NotModified(c, etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
 	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "Get Product with reviews successfully", productWithReview))
 }
@@ -65,26 +91,25 @@ func (h *ProductHandler) GetProductWithReview(c echo.Context) error {
 // @Produce json
 // @Success 200 {object} utils.Response
 // @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /products/reviews/{userId}/{id}/like [post]
 func (h *ProductHandler) LikeReview(c echo.Context) error {
-	reviewID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		h.logger.Infof(err.Error())
-		return c.JSON(utils.ErrorResponse(c, err))
-	}
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.LikeReview")
+	defer span.Finish()
 
-	userID, err := strconv.Atoi(c.Param("userId"))
-	if err != nil {
+	var request models.LikeReviewRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.LikeReview", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 	// Assuming you have user authentication in place and can retrieve the user ID
 	// userID := GetUserIDFromRequest(c)
 
-	ctx := c.Request().Context()
-	err = h.ProductUsecase.LikeReview(ctx, reviewID, userID)
+	err := h.ProductUsecase.LikeReview(ctx, request.ReviewID, request.UserID)
 	if err != nil {
-		h.logger.Infof(err.Error())
+		zap.HandlerError(h.logger.FromContext(ctx), "http.LikeReview", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
@@ -101,29 +126,188 @@ func (h *ProductHandler) LikeReview(c echo.Context) error {
 // @Produce json
 // @Success 200 {object} utils.Response
 // @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /products/reviews/{userId}/{id}/like [delete]
 func (h *ProductHandler) CancelLikeReview(c echo.Context) error {
-	reviewID, err := strconv.Atoi(c.Param("id"))
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.CancelLikeReview")
+	defer span.Finish()
+
+	var request models.LikeReviewRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.CancelLikeReview", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+	// Assuming you have user authentication in place and can retrieve the user ID
+	// userID := GetUserIDFromRequest(c)
+
+	err := h.ProductUsecase.CancelLikeReview(ctx, request.ReviewID, request.UserID)
 	if err != nil {
-		h.logger.Infof(err.Error())
+		zap.HandlerError(h.logger.FromContext(ctx), "http.CancelLikeReview", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
-	userID, err := strconv.Atoi(c.Param("userId"))
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "Review like canceled successfully", nil))
+}
+
+// DeleteProduct godoc
+// @Tags Admin
+// @Summary Delete product
+// @Description Soft delete a product by ID, it can be restored until purged
+// @ID deleteProduct
+// @Param id path int true "Product ID"
+// @Param If-Match header string true "ETag of the product being deleted"
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 412 {object} utils.Response
+// @Failure 428 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/products/{id} [delete]
+func (h *ProductHandler) DeleteProduct(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.DeleteProduct")
+	defer span.Finish()
+
+	var request models.ProductIDRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteProduct", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	version, err := utils.IfMatchVersion(c)
 	if err != nil {
-		h.logger.Infof(err.Error())
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteProduct", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
-	// Assuming you have user authentication in place and can retrieve the user ID
-	// userID := GetUserIDFromRequest(c)
 
-	ctx := c.Request().Context()
-	err = h.ProductUsecase.CancelLikeReview(ctx, reviewID, userID)
+	err = h.ProductUsecase.DeleteProduct(ctx, request.ID, version)
 	if err != nil {
-		h.logger.Infof(err.Error())
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteProduct", err)
 		return c.JSON(utils.ErrorResponse(c, err))
 	}
 
-	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "Review like canceled successfully", nil))
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "Product deleted successfully", nil))
+}
+
+// RestoreProduct godoc
+// @Tags Admin
+// @Summary Restore product
+// @Description Restore a soft deleted product by ID
+// @ID restoreProduct
+// @Param id path int true "Product ID"
+// @Param If-Match header string true "ETag of the product it had when deleted"
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.Product}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 412 {object} utils.Response
+// @Failure 428 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.RestoreProduct")
+	defer span.Finish()
+
+	var request models.ProductIDRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.RestoreProduct", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	version, err := utils.IfMatchVersion(c)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.RestoreProduct", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	product, err := h.ProductUsecase.RestoreProduct(ctx, request.ID, version)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.RestoreProduct", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	c.Response().Header().Set(utils.HeaderETag, utils.VersionETag(product.Version))
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "Product restored successfully", product))
+}
+
+// DeleteReview godoc
+// @Tags Admin
+// @Summary Delete review
+// @Description Soft delete a review by ID, it can be restored until purged
+// @ID deleteReview
+// @Param id path int true "Review ID"
+// @Param If-Match header string true "ETag of the review being deleted"
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 412 {object} utils.Response
+// @Failure 428 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/reviews/{id} [delete]
+func (h *ProductHandler) DeleteReview(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.DeleteReview")
+	defer span.Finish()
+
+	var request models.ReviewIDRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteReview", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	version, err := utils.IfMatchVersion(c)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteReview", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	err = h.ProductUsecase.DeleteReview(ctx, request.ID, version)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.DeleteReview", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "Review deleted successfully", nil))
+}
+
+// RestoreReview godoc
+// @Tags Admin
+// @Summary Restore review
+// @Description Restore a soft deleted review by ID
+// @ID restoreReview
+// @Param id path int true "Review ID"
+// @Param If-Match header string true "ETag of the review it had when deleted"
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 412 {object} utils.Response
+// @Failure 428 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/reviews/{id}/restore [post]
+func (h *ProductHandler) RestoreReview(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.RestoreReview")
+	defer span.Finish()
+
+	var request models.ReviewIDRequest
+	if err := utils.ReadRequest(c, &request); err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.RestoreReview", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	version, err := utils.IfMatchVersion(c)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.RestoreReview", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	err = h.ProductUsecase.RestoreReview(ctx, request.ID, version)
+	if err != nil {
+		zap.HandlerError(h.logger.FromContext(ctx), "http.RestoreReview", err)
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "Review restored successfully", nil))
 }
@@ -1,9 +1,17 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Product struct {
-	ID    int     `json:"id" gorm:"column:ID_PRODUCT"`
-	Name  string  `json:"productName" gorm:"column:PRODUCT_NAME"`
-	Price float64 `json:"price" gorm:"column:PRICE"`
+	ID        int            `json:"id" gorm:"column:ID_PRODUCT"`
+	Name      string         `json:"productName" gorm:"column:PRODUCT_NAME"`
+	Price     float64        `json:"price" gorm:"column:PRICE"`
+	Version   int            `json:"version" gorm:"column:VERSION"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:DELETED_AT"`
 }
 
 type ProductWithReview struct {
@@ -17,15 +25,17 @@ type LikeReview struct {
 }
 
 type ReviewData struct {
-	ID          int    `gorm:"column:ID_REVIEW" json:"reviewId"`
-	ProductID   int    `gorm:"column:ID_PRODUCT" json:"productId"`
-	MemberID    int    `gorm:"column:ID_MEMBER" json:"memberId"`
-	Username    string `gorm:"column:username" json:"username"`
-	LikeCount   int    `gorm:"column:like_count" json:"likeCount"`
-	Description string `gorm:"column:DESC_REVIEW" json:"descReview"`
-	Gender      string `gorm:"column:gender" json:"gender"`
-	SkinType    string `gorm:"column:skintype" json:"skinType"`
-	SkinColor   string `gorm:"column:skincolor" json:"skinColor"`
+	ID          int            `gorm:"column:ID_REVIEW" json:"reviewId"`
+	ProductID   int            `gorm:"column:ID_PRODUCT" json:"productId"`
+	MemberID    int            `gorm:"column:ID_MEMBER" json:"memberId"`
+	Username    string         `gorm:"column:username" json:"username"`
+	LikeCount   int            `gorm:"column:like_count" json:"likeCount"`
+	Description string         `gorm:"column:DESC_REVIEW" json:"descReview"`
+	Gender      string         `gorm:"column:gender" json:"gender"`
+	SkinType    string         `gorm:"column:skintype" json:"skinType"`
+	SkinColor   string         `gorm:"column:skincolor" json:"skinColor"`
+	Version     int            `gorm:"column:VERSION" json:"version"`
+	DeletedAt   gorm.DeletedAt `gorm:"column:DELETED_AT" json:"-"`
 }
 
 type Review struct {
@@ -35,3 +45,21 @@ type Review struct {
 func (Review) TableName() string {
 	return "review_products"
 }
+
+// MemberReview is a review as listed in the data export of its author.
+// Soft deleted reviews are listed with DeletedAt until they are purged.
+type MemberReview struct {
+	ID          int        `gorm:"column:ID_REVIEW" json:"reviewId"`
+	ProductID   int        `gorm:"column:ID_PRODUCT" json:"productId"`
+	ProductName string     `gorm:"column:PRODUCT_NAME" json:"productName"`
+	Description string     `gorm:"column:DESC_REVIEW" json:"descReview"`
+	DeletedAt   *time.Time `gorm:"column:DELETED_AT" json:"deletedAt,omitempty"`
+}
+
+// MemberLike is a like as listed in the data export of the member who gave
+// it, with the review and product it is on.
+type MemberLike struct {
+	ReviewID    int    `gorm:"column:ID_REVIEW" json:"reviewId"`
+	ProductID   int    `gorm:"column:ID_PRODUCT" json:"productId"`
+	ProductName string `gorm:"column:PRODUCT_NAME" json:"productName"`
+}
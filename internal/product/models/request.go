@@ -0,0 +1,14 @@
+package models
+
+type ProductIDRequest struct {
+	ID int `param:"id" json:"-" validate:"required,min=1"`
+}
+
+type LikeReviewRequest struct {
+	ReviewID int `param:"id" json:"-" validate:"required,min=1"`
+	UserID   int `param:"userId" json:"-" validate:"required,min=1"`
+}
+
+type ReviewIDRequest struct {
+	ID int `param:"id" json:"-" validate:"required,min=1"`
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"social_media/internal/product/models"
+	"social_media/internal/seed"
+	"social_media/internal/testutil"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// benchmarkVolumes is a mid-sized community, large enough for whole-table
+// like counts to show
+var benchmarkVolumes = seed.GenerateOptions{Members: 5000, Products: 1000, Reviews: 30000, Likes: 150000, Seed: 1}
+
+// benchmarkIndexes are the indexes GetProductWithReviews was designed with
+var benchmarkIndexes = []string{"idx_review_products_product", "idx_like_reviews_review"}
+
+// benchmarkDatabase holds benchmarkVolumes, with the hottest product, whose
+// page has the most reviews, and a typical one with about ten.
+type benchmarkDatabase struct {
+	db        *gorm.DB
+	hottestID int
+	typicalID int
+}
+
+// newBenchmarkDatabase generates benchmarkVolumes, dropping benchmarkIndexes
+// unless indexed.
+func newBenchmarkDatabase(b *testing.B, indexed bool) *benchmarkDatabase {
+	b.Helper()
+
+	db := testutil.NewDatabase(b)
+	if !indexed {
+		for _, index := range benchmarkIndexes {
+			if err := db.Exec("DROP INDEX " + index).Error; err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	result, err := seed.Generate(context.Background(), db, benchmarkVolumes)
+	if err != nil {
+		b.Fatalf("Generate() error = %v", err)
+	}
+
+	var typicalID int
+	err = db.Raw("SELECT ID_PRODUCT FROM review_products GROUP BY ID_PRODUCT HAVING COUNT(*) BETWEEN 8 AND 12 ORDER BY ID_PRODUCT LIMIT 1").
+		Scan(&typicalID).
+		Error
+	if err != nil || typicalID == 0 {
+		b.Fatalf("no typical product: %v", err)
+	}
+	return &benchmarkDatabase{db: db, hottestID: result.HottestProductID, typicalID: typicalID}
+}
+
+// previousProductPage reads a product page as it was before
+// GetProductWithReviews: the product, the product again to check it exists,
+// then its reviews with likes counted over the whole like_reviews table.
+func previousProductPage(ctx context.Context, db *gorm.DB, productID int) (*models.ProductWithReview, error) {
+	var product models.Product
+	if err := db.WithContext(ctx).First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	var existing models.Product
+	if err := db.WithContext(ctx).First(&existing, productID).Error; err != nil {
+		return nil, err
+	}
+
+	var reviewData []*models.ReviewData
+	err := db.WithContext(ctx).
+		Model(&models.Review{}).
+		Select("review_products.*, COALESCE(l.like_count, 0) AS like_count, members.USERNAME AS username, members.GENDER AS gender, members.SKINTYPE AS skintype, members.SKINCOLOR AS skincolor").
+		Joins("INNER JOIN members ON review_products.id_member = members.ID_MEMBER AND (members.DELETED_AT IS NULL OR members.ANONYMIZED_AT IS NOT NULL)").
+		Joins("LEFT JOIN (SELECT like_reviews.id_review, COUNT(*) AS like_count FROM like_reviews INNER JOIN members m ON like_reviews.id_member = m.ID_MEMBER AND (m.DELETED_AT IS NULL OR m.ANONYMIZED_AT IS NOT NULL) GROUP BY like_reviews.id_review) l ON review_products.id_review = l.id_review").
+		Where("review_products.id_product = ?", productID).
+		Table("review_products").
+		Scan(&reviewData).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]*models.Review, len(reviewData))
+	for i, data := range reviewData {
+		reviews[i] = &models.Review{ReviewData: *data}
+	}
+	return &models.ProductWithReview{Product: &product, Reviews: reviews}, nil
+}
+
+// BenchmarkProductPage compares reading a product page before and after
+// GetProductWithReviews and its indexes, on a typical and the hottest
+// product:
+//
+//	go test ./internal/product/repository -run '^$' -bench ProductPage -benchmem
+func BenchmarkProductPage(b *testing.B) {
+	ctx := context.Background()
+	after := newBenchmarkDatabase(b, true)
+	// The MySQL test database is shared, it keeps its indexes
+	before := after
+	if after.db.Dialector.Name() == "sqlite" {
+		before = newBenchmarkDatabase(b, false)
+	}
+	repo := NewMySQLProductRepository(after.db)
+
+	pages := []struct {
+		name   string
+		before int
+		after  int
+	}{
+		{"typical", before.typicalID, after.typicalID},
+		{"hottest", before.hottestID, after.hottestID},
+	}
+
+	for _, page := range pages {
+		want, err := repo.GetProductWithReviews(ctx, page.after)
+		if err != nil {
+			b.Fatalf("GetProductWithReviews() error = %v", err)
+		}
+		previous, err := previousProductPage(ctx, before.db, page.before)
+		if err != nil {
+			b.Fatalf("previousProductPage() error = %v", err)
+		}
+		if len(previous.Reviews) != len(want.Reviews) {
+			b.Fatalf("%s page: previous read %d reviews, want %d", page.name, len(previous.Reviews), len(want.Reviews))
+		}
+
+		b.Run("previous/"+page.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := previousProductPage(ctx, before.db, page.before); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run("single query/"+page.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.GetProductWithReviews(ctx, page.after); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
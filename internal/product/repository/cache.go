@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"social_media/internal/product/models"
+	"social_media/pkg/cache"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// CachedProductRepository is a read-through cache in front of a
+// ProductRepository. Products and their pages with reviews are kept in a
+// cache.Backend for ttl, loaded once however many requests miss together,
+// and invalidated by the writes made through this repository that change
+// them. Writes to members are reported through InvalidateMember, other
+// changes made elsewhere show up once the entries expire. Backend errors are
+// not fatal: reads fall back to the repository and a failed invalidation
+// leaves entries until they expire.
+type CachedProductRepository struct {
+	ProductRepository
+	cache cache.Backend
+	ttl   time.Duration
+	group cache.Group
+
+	// mu orders storing loaded entries against invalidations, generation
+	// counts the invalidations so a load that overlapped one is not stored
+	mu         sync.Mutex
+	generation uint64
+}
+
+// MemberPageCache drops the cached product pages showing a member: their
+// reviews carry the member's username and skin profile, and their likes are
+// counted.
+type MemberPageCache interface {
+	InvalidateMember(ctx context.Context, memberID int)
+}
+
+func NewCachedProductRepository(repository ProductRepository, backend cache.Backend, ttl time.Duration) *CachedProductRepository {
+	return &CachedProductRepository{
+		ProductRepository: repository,
+		cache:             backend,
+		ttl:               ttl,
+	}
+}
+
+func productKey(productID int) string {
+	return fmt.Sprintf("product:%d", productID)
+}
+
+func pageKey(productID int) string {
+	return fmt.Sprintf("product:%d:page", productID)
+}
+
+func (r *CachedProductRepository) GetProductByID(ctx context.Context, productID int) (*models.Product, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.cached.GetProductByID")
+	defer span.Finish()
+
+	var product models.Product
+	err := r.load(ctx, productKey(productID), &product, func(ctx context.Context) (interface{}, error) {
+		return r.ProductRepository.GetProductByID(ctx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *CachedProductRepository) GetProductWithReviews(ctx context.Context, productID int) (*models.ProductWithReview, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.cached.GetProductWithReviews")
+	defer span.Finish()
+
+	var page models.ProductWithReview
+	err := r.load(ctx, pageKey(productID), &page, func(ctx context.Context) (interface{}, error) {
+		return r.ProductRepository.GetProductWithReviews(ctx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (r *CachedProductRepository) UpdateProductByID(ctx context.Context, product *models.Product, productID int) (*models.Product, error) {
+	result, err := r.ProductRepository.UpdateProductByID(ctx, product, productID)
+	if err == nil {
+		r.invalidate(ctx, productID)
+	}
+	return result, err
+}
+
+func (r *CachedProductRepository) DeleteProductByID(ctx context.Context, productID int, version int) error {
+	err := r.ProductRepository.DeleteProductByID(ctx, productID, version)
+	if err == nil {
+		r.invalidate(ctx, productID)
+	}
+	return err
+}
+
+func (r *CachedProductRepository) RestoreProductByID(ctx context.Context, productID int, version int) (*models.Product, error) {
+	result, err := r.ProductRepository.RestoreProductByID(ctx, productID, version)
+	if err == nil {
+		r.invalidate(ctx, productID)
+	}
+	return result, err
+}
+
+func (r *CachedProductRepository) LikeReview(ctx context.Context, reviewID int, userID int) error {
+	err := r.ProductRepository.LikeReview(ctx, reviewID, userID)
+	if err == nil {
+		r.invalidateReview(ctx, reviewID)
+	}
+	return err
+}
+
+func (r *CachedProductRepository) CancelLikeReview(ctx context.Context, reviewID int, userID int) error {
+	err := r.ProductRepository.CancelLikeReview(ctx, reviewID, userID)
+	if err == nil {
+		r.invalidateReview(ctx, reviewID)
+	}
+	return err
+}
+
+func (r *CachedProductRepository) DeleteReviewByID(ctx context.Context, reviewID int, version int) error {
+	err := r.ProductRepository.DeleteReviewByID(ctx, reviewID, version)
+	if err == nil {
+		r.invalidateReview(ctx, reviewID)
+	}
+	return err
+}
+
+func (r *CachedProductRepository) RestoreReviewByID(ctx context.Context, reviewID int, version int) error {
+	err := r.ProductRepository.RestoreReviewByID(ctx, reviewID, version)
+	if err == nil {
+		r.invalidateReview(ctx, reviewID)
+	}
+	return err
+}
+
+// load decodes the entry under key into value, fetching and storing it on a
+// miss. Errors are returned as fetched and never cached.
+//
+// The fetch is shared by every caller missing key together, so it runs on a
+// context detached from the first caller's cancellation, keeping its values
+// and deadline. A caller whose own context is live retries a shared fetch
+// that ran out of time.
+func (r *CachedProductRepository) load(ctx context.Context, key string, value interface{}, fetch func(ctx context.Context) (interface{}, error)) error {
+	if data, ok, err := r.cache.Get(ctx, key); err == nil && ok {
+		if json.Unmarshal(data, value) == nil {
+			return nil
+		}
+	}
+
+	for {
+		data, shared, err := r.group.Do(key, func() ([]byte, error) {
+			ctx, cancel := detach(ctx)
+			defer cancel()
+
+			r.mu.Lock()
+			generation := r.generation
+			r.mu.Unlock()
+
+			fetched, err := fetch(ctx)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(fetched)
+			if err != nil {
+				return nil, err
+			}
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.generation == generation {
+				_ = r.cache.Set(ctx, key, data, r.ttl)
+			}
+			return data, nil
+		})
+		if shared && isContextError(err) && ctx.Err() == nil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, value)
+	}
+}
+
+// detachedContext keeps the values of its parent but not its cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+// detach returns a context with the values and deadline of ctx that is not
+// cancelled with it.
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detachedContext{ctx}, deadline)
+	}
+	return context.WithCancel(detachedContext{ctx})
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// InvalidateMember drops the pages of the products a member reviewed or
+// liked a review of, for writes to members made outside this repository.
+func (r *CachedProductRepository) InvalidateMember(ctx context.Context, memberID int) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.cached.InvalidateMember")
+	defer span.Finish()
+
+	reviews, err := r.ProductRepository.GetReviewsByMemberID(ctx, memberID)
+	if err != nil {
+		return
+	}
+	likes, err := r.ProductRepository.GetLikesByMemberID(ctx, memberID)
+	if err != nil {
+		return
+	}
+
+	productIDs := make(map[int]struct{}, len(reviews)+len(likes))
+	for _, review := range reviews {
+		productIDs[review.ProductID] = struct{}{}
+	}
+	for _, like := range likes {
+		productIDs[like.ProductID] = struct{}{}
+	}
+	for productID := range productIDs {
+		r.invalidate(ctx, productID)
+	}
+}
+
+// invalidateReview drops the page of the product a review is on.
+func (r *CachedProductRepository) invalidateReview(ctx context.Context, reviewID int) {
+	productID, err := r.ProductRepository.GetReviewProductID(ctx, reviewID)
+	if err != nil {
+		return
+	}
+	r.invalidate(ctx, productID)
+}
+
+// invalidate drops a product and its page.
+func (r *CachedProductRepository) invalidate(ctx context.Context, productID int) {
+	keys := []string{productKey(productID), pageKey(productID)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	for _, key := range keys {
+		r.group.Forget(key)
+	}
+	_ = r.cache.Delete(ctx, keys...)
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	memberRepository "social_media/internal/member/repository"
+	"social_media/internal/product/models"
+	"social_media/internal/testutil"
+	"social_media/pkg/cache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachedProductRepository(t *testing.T) {
+	// Every write the conformance tests read back must invalidate the cache
+	testProductRepository(t, func(t *testing.T, f testutil.Fixtures) (ProductRepository, memberRepository.MemberRepository) {
+		db := testutil.NewDatabase(t)
+		testutil.Seed(t, db, f)
+
+		return NewCachedProductRepository(NewMySQLProductRepository(db), cache.NewLRU(100), time.Minute), memberRepository.NewMemberRepository(db)
+	})
+}
+
+// countingRepository counts the page loads reaching it, each one waiting for
+// release or its context.
+type countingRepository struct {
+	ProductRepository
+	loads   int32
+	release chan struct{}
+}
+
+func (r *countingRepository) GetProductWithReviews(ctx context.Context, productID int) (*models.ProductWithReview, error) {
+	atomic.AddInt32(&r.loads, 1)
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return r.ProductRepository.GetProductWithReviews(ctx, productID)
+}
+
+func TestCachedProductRepository_Stampede(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewDatabase(t)
+	testutil.Seed(t, db, conformanceFixtures)
+
+	counting := &countingRepository{ProductRepository: NewMySQLProductRepository(db), release: make(chan struct{})}
+	repo := NewCachedProductRepository(counting, cache.NewLRU(100), time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if page, err := repo.GetProductWithReviews(ctx, 1); err != nil || len(page.Reviews) != 2 {
+				t.Errorf("GetProductWithReviews() = %+v, %v", page, err)
+			}
+		}()
+	}
+	// Let the first load start before releasing it
+	for atomic.LoadInt32(&counting.loads) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(counting.release)
+	wg.Wait()
+
+	if _, err := repo.GetProductWithReviews(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if loads := atomic.LoadInt32(&counting.loads); loads != 1 {
+		t.Errorf("%d loads reached the repository, want 1", loads)
+	}
+}
+
+func TestCachedProductRepository_FirstCallerCancelled(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	testutil.Seed(t, db, conformanceFixtures)
+
+	counting := &countingRepository{ProductRepository: NewMySQLProductRepository(db), release: make(chan struct{})}
+	repo := NewCachedProductRepository(counting, cache.NewLRU(100), time.Minute)
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := repo.GetProductWithReviews(firstCtx, 1)
+		first <- err
+	}()
+	for atomic.LoadInt32(&counting.loads) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	waiter := make(chan error, 1)
+	go func() {
+		page, err := repo.GetProductWithReviews(context.Background(), 1)
+		if err == nil && len(page.Reviews) != 2 {
+			t.Errorf("GetProductWithReviews() = %+v", page)
+		}
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// The first caller giving up does not fail the load it shares
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(counting.release)
+
+	if err := <-waiter; err != nil {
+		t.Errorf("waiter error = %v, want the page", err)
+	}
+	<-first
+	if loads := atomic.LoadInt32(&counting.loads); loads != 1 {
+		t.Errorf("%d loads reached the repository, want 1", loads)
+	}
+}
+
+func TestCachedProductRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewDatabase(t)
+	testutil.Seed(t, db, conformanceFixtures)
+	repo := NewCachedProductRepository(NewMySQLProductRepository(db), cache.NewLRU(100), time.Minute)
+
+	likeCount := func(t *testing.T, reviewID int) int {
+		t.Helper()
+
+		page, err := repo.GetProductWithReviews(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, review := range page.Reviews {
+			if review.ID == reviewID {
+				return review.LikeCount
+			}
+		}
+		t.Fatalf("review %d not on the page", reviewID)
+		return 0
+	}
+
+	if got := likeCount(t, 2); got != 1 {
+		t.Fatalf("like count = %d, want 1", got)
+	}
+
+	// Writes that bypass the repository are not seen until invalidated
+	if err := db.Exec("DELETE FROM like_reviews WHERE ID_REVIEW = 2").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := likeCount(t, 2); got != 1 {
+		t.Errorf("like count = %d, want the cached 1", got)
+	}
+
+	if err := repo.LikeReview(ctx, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got := likeCount(t, 2); got != 1 {
+		t.Errorf("like count after LikeReview = %d, want 1", got)
+	}
+}
+
+func TestCachedProductRepository_InvalidateMember(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewDatabase(t)
+	testutil.Seed(t, db, conformanceFixtures)
+	repo := NewCachedProductRepository(NewMySQLProductRepository(db), cache.NewLRU(100), time.Minute)
+
+	authors := func(t *testing.T, productID int) map[int]string {
+		t.Helper()
+
+		page, err := repo.GetProductWithReviews(ctx, productID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[int]string, len(page.Reviews))
+		for _, review := range page.Reviews {
+			got[review.ID] = review.Username
+		}
+		return got
+	}
+	authors(t, 1)
+	authors(t, 2)
+
+	// Member 2 wrote review 2 on product 1 and liked review 1 there, member
+	// 3 wrote review 3 on product 2
+	if err := db.Exec("UPDATE members SET USERNAME = 'Renamed' || ID_MEMBER WHERE ID_MEMBER IN (2, 3)").Error; err != nil {
+		t.Fatal(err)
+	}
+	repo.InvalidateMember(ctx, 2)
+
+	if got := authors(t, 1); got[2] != "Renamed2" {
+		t.Errorf("product 1 authors = %v, want review 2 by Renamed2", got)
+	}
+	if got := authors(t, 2); got[3] != "User3" {
+		t.Errorf("product 2 authors = %v, want the cached User3", got)
+	}
+}
@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	memberModels "social_media/internal/member/models"
+	memberRepository "social_media/internal/member/repository"
+	"social_media/internal/product/models"
+	"social_media/internal/testutil"
+	"social_media/pkg/utils"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// conformanceFixtures has two products, three reviews and three likes:
+// review 1 is liked by members 2 and 3, review 2 by member 1.
+var conformanceFixtures = testutil.Fixtures{
+	Members: []memberModels.Member{
+		{ID: 1, Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair"},
+		{ID: 2, Username: "User2", Gender: "Female", SkinType: "Combination", SkinColor: "Medium"},
+		{ID: 3, Username: "User3", Gender: "Male", SkinType: "Dry", SkinColor: "Dark"},
+		{ID: 4, Username: "User4", Gender: "Female", SkinType: "Normal", SkinColor: "Fair"},
+	},
+	Products: []models.Product{
+		{ID: 1, Name: "Product1", Price: 9.99},
+		{ID: 2, Name: "Product2", Price: 19.99},
+	},
+	Reviews: []models.ReviewData{
+		{ID: 1, MemberID: 1, ProductID: 1, Description: "Great product! Highly recommended."},
+		{ID: 2, MemberID: 2, ProductID: 1, Description: "Average product. Could be better."},
+		{ID: 3, MemberID: 3, ProductID: 2, Description: "Excellent quality and value."},
+	},
+	Likes: []models.LikeReview{
+		{ReviewID: 1, MemberID: 2},
+		{ReviewID: 1, MemberID: 3},
+		{ReviewID: 2, MemberID: 1},
+	},
+}
+
+// repositoryFactory returns a ProductRepository holding the fixtures and
+// the MemberRepository over the same members.
+type repositoryFactory func(t *testing.T, f testutil.Fixtures) (ProductRepository, memberRepository.MemberRepository)
+
+// testProductRepository is the behaviour every ProductRepository must share.
+func testProductRepository(t *testing.T, newRepositories repositoryFactory) {
+	ctx := context.Background()
+
+	newRepository := func(t *testing.T) ProductRepository {
+		repo, _ := newRepositories(t, conformanceFixtures)
+		return repo
+	}
+
+	wantErr := func(t *testing.T, op string, err, want error) {
+		t.Helper()
+
+		if !errors.Is(err, want) {
+			t.Fatalf("%s error = %v, want %v", op, err, want)
+		}
+	}
+
+	// likeCounts maps the review IDs of a product page to their like count
+	likeCounts := func(t *testing.T, repo ProductRepository, productID int) map[int]int {
+		t.Helper()
+
+		page, err := repo.GetProductWithReviews(ctx, productID)
+		wantErr(t, "GetProductWithReviews()", err, nil)
+
+		counts := make(map[int]int, len(page.Reviews))
+		for _, review := range page.Reviews {
+			counts[review.ID] = review.LikeCount
+		}
+		return counts
+	}
+
+	wantLikeCounts := func(t *testing.T, repo ProductRepository, productID int, want map[int]int) {
+		t.Helper()
+
+		got := likeCounts(t, repo, productID)
+		if len(got) != len(want) {
+			t.Fatalf("reviews of product %d = %v, want %v", productID, got, want)
+		}
+		for id, count := range want {
+			if got[id] != count {
+				t.Fatalf("reviews of product %d = %v, want %v", productID, got, want)
+			}
+		}
+	}
+
+	t.Run("GetProductByID", func(t *testing.T) {
+		repo := newRepository(t)
+
+		product, err := repo.GetProductByID(ctx, 1)
+		wantErr(t, "GetProductByID()", err, nil)
+		if product.Name != "Product1" || product.Price != 9.99 || product.Version != 1 {
+			t.Errorf("GetProductByID() = %+v", product)
+		}
+
+		_, err = repo.GetProductByID(ctx, 42)
+		wantErr(t, "GetProductByID()", err, utils.ErrProductNotFound)
+	})
+
+	t.Run("GetProductByName", func(t *testing.T) {
+		repo := newRepository(t)
+
+		product, err := repo.GetProductByName(ctx, "Product2")
+		wantErr(t, "GetProductByName()", err, nil)
+		if product.ID != 2 {
+			t.Errorf("GetProductByName() = %+v, want product 2", product)
+		}
+
+		wantErr(t, "DeleteProductByID()", repo.DeleteProductByID(ctx, 2, utils.AnyVersion), nil)
+		_, err = repo.GetProductByName(ctx, "Product2")
+		wantErr(t, "GetProductByName()", err, utils.ErrProductNotFound)
+	})
+
+	t.Run("GetProductsAfter pages through live products in ID order", func(t *testing.T) {
+		repo := newRepository(t)
+		third := &models.Product{Name: "Product3", Price: 4.5}
+		wantErr(t, "CreateProduct()", repo.CreateProduct(ctx, third), nil)
+		wantErr(t, "DeleteProductByID()", repo.DeleteProductByID(ctx, 2, utils.AnyVersion), nil)
+
+		page, err := repo.GetProductsAfter(ctx, 0, 1)
+		wantErr(t, "GetProductsAfter()", err, nil)
+		if len(page) != 1 || page[0].ID != 1 {
+			t.Fatalf("GetProductsAfter(0, 1) = %+v, want product 1", page)
+		}
+
+		page, err = repo.GetProductsAfter(ctx, 1, 10)
+		wantErr(t, "GetProductsAfter()", err, nil)
+		if len(page) != 1 || page[0].ID != third.ID {
+			t.Fatalf("GetProductsAfter(1, 10) = %+v, want product %d", page, third.ID)
+		}
+	})
+
+	t.Run("CreateProduct and UpdateProductByID", func(t *testing.T) {
+		repo := newRepository(t)
+
+		product := &models.Product{ID: 1, Name: "Product3", Price: 4.5}
+		wantErr(t, "CreateProduct()", repo.CreateProduct(ctx, product), nil)
+		if product.ID <= 2 || product.Version != 1 {
+			t.Fatalf("CreateProduct() stored ID %d version %d, want a new ID and version 1", product.ID, product.Version)
+		}
+
+		updated, err := repo.UpdateProductByID(ctx, &models.Product{Name: "Renamed", Price: 5, Version: 1}, product.ID)
+		wantErr(t, "UpdateProductByID()", err, nil)
+		if updated.Name != "Renamed" || updated.Price != 5 || updated.Version != 2 {
+			t.Errorf("UpdateProductByID() = %+v", updated)
+		}
+
+		_, err = repo.UpdateProductByID(ctx, &models.Product{Name: "Stale", Price: 5, Version: 1}, product.ID)
+		wantErr(t, "UpdateProductByID()", err, utils.ErrVersionMismatch)
+		_, err = repo.UpdateProductByID(ctx, &models.Product{Name: "Missing", Price: 5, Version: 1}, 42)
+		wantErr(t, "UpdateProductByID()", err, utils.ErrProductNotFound)
+	})
+
+	t.Run("GetReviewsByProductID joins authors and counts likes", func(t *testing.T) {
+		repo := newRepository(t)
+
+		reviews, err := repo.GetReviewsByProductID(ctx, 1)
+		wantErr(t, "GetReviewsByProductID()", err, nil)
+		sort.Slice(reviews, func(i, j int) bool { return reviews[i].ID < reviews[j].ID })
+
+		want := []models.ReviewData{
+			{ID: 1, ProductID: 1, MemberID: 1, Username: "User1", LikeCount: 2, Description: "Great product! Highly recommended.", Gender: "Male", SkinType: "Oily", SkinColor: "Fair", Version: 1},
+			{ID: 2, ProductID: 1, MemberID: 2, Username: "User2", LikeCount: 1, Description: "Average product. Could be better.", Gender: "Female", SkinType: "Combination", SkinColor: "Medium", Version: 1},
+		}
+		if len(reviews) != len(want) {
+			t.Fatalf("GetReviewsByProductID() returned %d reviews, want %d", len(reviews), len(want))
+		}
+		for i, review := range reviews {
+			got := review.ReviewData
+			got.DeletedAt = want[i].DeletedAt
+			if got != want[i] {
+				t.Errorf("review %d = %+v, want %+v", i, got, want[i])
+			}
+		}
+
+		_, err = repo.GetReviewsByProductID(ctx, 42)
+		wantErr(t, "GetReviewsByProductID()", err, utils.NotFound)
+	})
+
+	t.Run("GetProductWithReviews reads a product page", func(t *testing.T) {
+		repo := newRepository(t)
+
+		page, err := repo.GetProductWithReviews(ctx, 1)
+		wantErr(t, "GetProductWithReviews()", err, nil)
+		if *page.Product != (models.Product{ID: 1, Name: "Product1", Price: 9.99, Version: 1}) {
+			t.Errorf("product = %+v", page.Product)
+		}
+		reviews, err := repo.GetReviewsByProductID(ctx, 1)
+		wantErr(t, "GetReviewsByProductID()", err, nil)
+		if len(page.Reviews) != len(reviews) {
+			t.Fatalf("GetProductWithReviews() returned %d reviews, want %d", len(page.Reviews), len(reviews))
+		}
+		for i, review := range page.Reviews {
+			if review.ReviewData != reviews[i].ReviewData {
+				t.Errorf("review %d = %+v, want %+v", i, review.ReviewData, reviews[i].ReviewData)
+			}
+		}
+
+		// A product without reviews still has a page
+		wantErr(t, "DeleteReviewByID()", repo.DeleteReviewByID(ctx, 3, utils.AnyVersion), nil)
+		page, err = repo.GetProductWithReviews(ctx, 2)
+		wantErr(t, "GetProductWithReviews()", err, nil)
+		if page.Product.ID != 2 || page.Reviews == nil || len(page.Reviews) != 0 {
+			t.Errorf("page without reviews = %+v, reviews %v", page.Product, page.Reviews)
+		}
+
+		wantErr(t, "DeleteProductByID()", repo.DeleteProductByID(ctx, 2, utils.AnyVersion), nil)
+		_, err = repo.GetProductWithReviews(ctx, 2)
+		wantErr(t, "GetProductWithReviews()", err, utils.ErrProductNotFound)
+		_, err = repo.GetProductWithReviews(ctx, 42)
+		wantErr(t, "GetProductWithReviews()", err, utils.ErrProductNotFound)
+	})
+
+	t.Run("GetReviewsByProductID leaves out deleted members", func(t *testing.T) {
+		repo, members := newRepositories(t, conformanceFixtures)
+		wantErr(t, "EraseMemberByID()", members.EraseMemberByID(ctx, &memberModels.Erasure{MemberID: 2, Mode: memberModels.ErasureDelete}, 1), nil)
+
+		wantLikeCounts(t, repo, 1, map[int]int{1: 1})
+	})
+
+	t.Run("GetReviewsByProductID keeps anonymized members as former members", func(t *testing.T) {
+		repo, members := newRepositories(t, conformanceFixtures)
+		erasure := &memberModels.Erasure{MemberID: 2, Mode: memberModels.ErasureAnonymize}
+		wantErr(t, "EraseMemberByID()", members.EraseMemberByID(ctx, erasure, 1), nil)
+		_, err := members.PurgeDeletedMembers(ctx, time.Now().Add(time.Hour))
+		wantErr(t, "PurgeDeletedMembers()", err, nil)
+
+		wantLikeCounts(t, repo, 1, map[int]int{1: 2, 2: 1})
+
+		reviews, err := repo.GetReviewsByProductID(ctx, 1)
+		wantErr(t, "GetReviewsByProductID()", err, nil)
+		for _, review := range reviews {
+			if review.ID == 2 && (review.Username != memberModels.FormerMemberUsername || review.Gender != "" || review.SkinType != "" || review.SkinColor != "") {
+				t.Errorf("review of the anonymized member = %+v", review.ReviewData)
+			}
+		}
+	})
+
+	t.Run("GetReviewProductID finds deleted reviews", func(t *testing.T) {
+		repo := newRepository(t)
+		wantErr(t, "DeleteReviewByID()", repo.DeleteReviewByID(ctx, 3, utils.AnyVersion), nil)
+
+		productID, err := repo.GetReviewProductID(ctx, 3)
+		wantErr(t, "GetReviewProductID()", err, nil)
+		if productID != 2 {
+			t.Errorf("GetReviewProductID() = %d, want 2", productID)
+		}
+
+		_, err = repo.GetReviewProductID(ctx, 42)
+		wantErr(t, "GetReviewProductID()", err, utils.ErrReviewNotFound)
+	})
+
+	t.Run("GetReviewsByMemberID and GetLikesByMemberID list a member's activity", func(t *testing.T) {
+		repo := newRepository(t)
+		wantErr(t, "DeleteReviewByID()", repo.DeleteReviewByID(ctx, 1, utils.AnyVersion), nil)
+		wantErr(t, "LikeReview()", repo.LikeReview(ctx, 3, 1), nil)
+
+		reviews, err := repo.GetReviewsByMemberID(ctx, 1)
+		wantErr(t, "GetReviewsByMemberID()", err, nil)
+		if len(reviews) != 1 || reviews[0].ID != 1 || reviews[0].ProductName != "Product1" || reviews[0].DeletedAt == nil {
+			t.Errorf("GetReviewsByMemberID() = %+v, want the deleted review 1 on Product1", reviews)
+		}
+
+		likes, err := repo.GetLikesByMemberID(ctx, 1)
+		wantErr(t, "GetLikesByMemberID()", err, nil)
+		want := []models.MemberLike{{ReviewID: 2, ProductID: 1, ProductName: "Product1"}, {ReviewID: 3, ProductID: 2, ProductName: "Product2"}}
+		if len(likes) != len(want) || *likes[0] != want[0] || *likes[1] != want[1] {
+			t.Errorf("GetLikesByMemberID() = %+v, want %+v", likes, want)
+		}
+
+		for id, want := range map[int]int64{1: 3, 3: 2, 4: 0} {
+			count, err := repo.CountMemberActivity(ctx, id)
+			wantErr(t, "CountMemberActivity()", err, nil)
+			if count != want {
+				t.Errorf("CountMemberActivity(%d) = %d, want %d", id, count, want)
+			}
+		}
+	})
+
+	t.Run("CheckReviewExistence", func(t *testing.T) {
+		repo := newRepository(t)
+
+		for id, want := range map[int]bool{1: true, 42: false} {
+			exists, err := repo.CheckReviewExistence(ctx, id)
+			wantErr(t, "CheckReviewExistence()", err, nil)
+			if exists != want {
+				t.Errorf("CheckReviewExistence(%d) = %v, want %v", id, exists, want)
+			}
+		}
+
+		wantErr(t, "DeleteReviewByID()", repo.DeleteReviewByID(ctx, 1, utils.AnyVersion), nil)
+		exists, err := repo.CheckReviewExistence(ctx, 1)
+		wantErr(t, "CheckReviewExistence()", err, nil)
+		if exists {
+			t.Error("CheckReviewExistence() reports a deleted review")
+		}
+	})
+
+	t.Run("LikeReview", func(t *testing.T) {
+		repo := newRepository(t)
+
+		wantErr(t, "LikeReview()", repo.LikeReview(ctx, 2, 4), nil)
+		wantLikeCounts(t, repo, 1, map[int]int{1: 2, 2: 2})
+
+		wantErr(t, "LikeReview()", repo.LikeReview(ctx, 2, 4), utils.ErrReviewAlreadyLiked)
+		wantErr(t, "LikeReview()", repo.LikeReview(ctx, 2, 42), utils.ErrMemberNotFound)
+	})
+
+	t.Run("LikeReview concurrently", func(t *testing.T) {
+		repo := newRepository(t)
+
+		errs := make([]error, 8)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = repo.LikeReview(ctx, 2, 4)
+			}(i)
+		}
+		wg.Wait()
+
+		liked := 0
+		for _, err := range errs {
+			if err == nil {
+				liked++
+			} else if !errors.Is(err, utils.ErrReviewAlreadyLiked) {
+				t.Errorf("LikeReview() error = %v, want nil or %v", err, utils.ErrReviewAlreadyLiked)
+			}
+		}
+		if liked != 1 {
+			t.Errorf("%d of %d concurrent likes succeeded, want 1", liked, len(errs))
+		}
+		wantLikeCounts(t, repo, 1, map[int]int{1: 2, 2: 2})
+	})
+
+	t.Run("CancelLikeReview", func(t *testing.T) {
+		repo := newRepository(t)
+
+		wantErr(t, "CancelLikeReview()", repo.CancelLikeReview(ctx, 1, 2), nil)
+		wantLikeCounts(t, repo, 1, map[int]int{1: 1, 2: 1})
+
+		wantErr(t, "CancelLikeReview()", repo.CancelLikeReview(ctx, 1, 2), utils.ErrReviewNotLiked)
+	})
+
+	t.Run("DeleteProductByID and RestoreProductByID", func(t *testing.T) {
+		repo := newRepository(t)
+
+		wantErr(t, "DeleteProductByID() of another version", repo.DeleteProductByID(ctx, 1, 2), utils.ErrVersionMismatch)
+		wantErr(t, "DeleteProductByID()", repo.DeleteProductByID(ctx, 1, 1), nil)
+		_, err := repo.GetProductByID(ctx, 1)
+		wantErr(t, "GetProductByID()", err, utils.ErrProductNotFound)
+		_, err = repo.GetReviewsByProductID(ctx, 1)
+		wantErr(t, "GetReviewsByProductID()", err, utils.NotFound)
+		wantErr(t, "DeleteProductByID()", repo.DeleteProductByID(ctx, 1, utils.AnyVersion), utils.ErrProductNotFound)
+		wantErr(t, "DeleteProductByID()", repo.DeleteProductByID(ctx, 42, utils.AnyVersion), utils.ErrProductNotFound)
+
+		_, err = repo.RestoreProductByID(ctx, 1, 2)
+		wantErr(t, "RestoreProductByID() of another version", err, utils.ErrVersionMismatch)
+		product, err := repo.RestoreProductByID(ctx, 1, 1)
+		wantErr(t, "RestoreProductByID()", err, nil)
+		if product.Version != 2 {
+			t.Errorf("RestoreProductByID() version = %d, want 2", product.Version)
+		}
+
+		_, err = repo.RestoreProductByID(ctx, 1, utils.AnyVersion)
+		wantErr(t, "RestoreProductByID() of a live product", err, utils.ErrProductNotFound)
+		_, err = repo.RestoreProductByID(ctx, 42, utils.AnyVersion)
+		wantErr(t, "RestoreProductByID()", err, utils.ErrProductNotFound)
+
+		wantErr(t, "DeleteProductByID() of the restored version", repo.DeleteProductByID(ctx, 1, 1), utils.ErrVersionMismatch)
+		wantErr(t, "DeleteProductByID()", repo.DeleteProductByID(ctx, 1, 2), nil)
+	})
+
+	t.Run("DeleteReviewByID and RestoreReviewByID", func(t *testing.T) {
+		repo := newRepository(t)
+
+		wantErr(t, "DeleteReviewByID() of another version", repo.DeleteReviewByID(ctx, 1, 2), utils.ErrVersionMismatch)
+		wantErr(t, "DeleteReviewByID()", repo.DeleteReviewByID(ctx, 1, 1), nil)
+		wantLikeCounts(t, repo, 1, map[int]int{2: 1})
+		wantErr(t, "DeleteReviewByID()", repo.DeleteReviewByID(ctx, 1, utils.AnyVersion), utils.ErrReviewNotFound)
+
+		wantErr(t, "RestoreReviewByID() of another version", repo.RestoreReviewByID(ctx, 1, 2), utils.ErrVersionMismatch)
+		wantErr(t, "RestoreReviewByID()", repo.RestoreReviewByID(ctx, 1, 1), nil)
+		wantLikeCounts(t, repo, 1, map[int]int{1: 2, 2: 1})
+		wantErr(t, "RestoreReviewByID() of a live review", repo.RestoreReviewByID(ctx, 1, utils.AnyVersion), utils.ErrReviewNotFound)
+		wantErr(t, "RestoreReviewByID()", repo.RestoreReviewByID(ctx, 42, utils.AnyVersion), utils.ErrReviewNotFound)
+
+		page, err := repo.GetProductWithReviews(ctx, 1)
+		wantErr(t, "GetProductWithReviews()", err, nil)
+		if version := page.Reviews[0].Version; version != 2 {
+			t.Errorf("restored review version = %d, want 2", version)
+		}
+	})
+
+	t.Run("PurgeDeleted removes rows deleted before the cutoff", func(t *testing.T) {
+		repo := newRepository(t)
+		wantErr(t, "DeleteReviewByID()", repo.DeleteReviewByID(ctx, 2, utils.AnyVersion), nil)
+		wantErr(t, "DeleteProductByID()", repo.DeleteProductByID(ctx, 2, utils.AnyVersion), nil)
+
+		purged, err := repo.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+		wantErr(t, "PurgeDeleted()", err, nil)
+		if purged != 0 {
+			t.Errorf("PurgeDeleted() before the deletions purged %d rows", purged)
+		}
+
+		purged, err = repo.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+		wantErr(t, "PurgeDeleted()", err, nil)
+		if purged != 2 {
+			t.Errorf("PurgeDeleted() purged %d rows, want 2", purged)
+		}
+
+		wantErr(t, "RestoreReviewByID()", repo.RestoreReviewByID(ctx, 2, utils.AnyVersion), utils.ErrReviewNotFound)
+		wantErr(t, "RestoreReviewByID()", repo.RestoreReviewByID(ctx, 3, utils.AnyVersion), utils.ErrReviewNotFound)
+		_, err = repo.RestoreProductByID(ctx, 2, utils.AnyVersion)
+		wantErr(t, "RestoreProductByID()", err, utils.ErrProductNotFound)
+		wantLikeCounts(t, repo, 1, map[int]int{1: 2})
+	})
+}
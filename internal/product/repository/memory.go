@@ -0,0 +1,481 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	memberModels "social_media/internal/member/models"
+	"social_media/internal/product/models"
+	"social_media/pkg/utils"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemberSource looks up the authors of reviews and likes. Members it
+// reports as not found are left out, as the SQL join leaves out deleted
+// members. GetAuthorByID also finds anonymized members, whose reviews and
+// likes are kept. The member MemberRepository satisfies it.
+type MemberSource interface {
+	GetMemberByID(ctx context.Context, id int) (*memberModels.Member, error)
+	GetAuthorByID(ctx context.Context, id int) (*memberModels.Member, error)
+}
+
+// MemoryProductRepository is a ProductRepository kept in process memory, it
+// is safe for concurrent use and returns the same errors as
+// MySQLProductRepository.
+type MemoryProductRepository struct {
+	mu       sync.RWMutex
+	members  MemberSource
+	products map[int]*models.Product
+	reviews  map[int]*models.ReviewData
+	likes    map[models.LikeReview]struct{}
+	lastID   int
+}
+
+func NewMemoryProductRepository(members MemberSource) *MemoryProductRepository {
+	return &MemoryProductRepository{
+		members:  members,
+		products: make(map[int]*models.Product),
+		reviews:  make(map[int]*models.ReviewData),
+		likes:    make(map[models.LikeReview]struct{}),
+	}
+}
+
+// AddProduct stores a product, for seeding. A zero version is stored as 1.
+func (r *MemoryProductRepository) AddProduct(product *models.Product) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *product
+	if stored.Version == 0 {
+		stored.Version = 1
+	}
+	r.products[stored.ID] = &stored
+	if stored.ID > r.lastID {
+		r.lastID = stored.ID
+	}
+}
+
+// AddReview stores a review, for seeding. Only the columns of
+// review_products are kept, author details come from the MemberSource.
+func (r *MemoryProductRepository) AddReview(review *models.ReviewData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reviews[review.ID] = &models.ReviewData{
+		ID:          review.ID,
+		ProductID:   review.ProductID,
+		MemberID:    review.MemberID,
+		Description: review.Description,
+		Version:     review.Version,
+		DeletedAt:   review.DeletedAt,
+	}
+	if review.Version == 0 {
+		r.reviews[review.ID].Version = 1
+	}
+}
+
+// AddLike stores a like, for seeding.
+func (r *MemoryProductRepository) AddLike(like models.LikeReview) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.likes[like] = struct{}{}
+}
+
+func (r *MemoryProductRepository) GetProductByID(ctx context.Context, productID int) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, ok := r.products[productID]
+	if !ok || product.DeletedAt.Valid {
+		return nil, utils.ErrProductNotFound
+	}
+
+	result := *product
+	return &result, nil
+}
+
+func (r *MemoryProductRepository) GetProductByName(ctx context.Context, name string) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id := 1; id <= r.lastID; id++ {
+		product, ok := r.products[id]
+		if ok && !product.DeletedAt.Valid && product.Name == name {
+			result := *product
+			return &result, nil
+		}
+	}
+	return nil, utils.ErrProductNotFound
+}
+
+func (r *MemoryProductRepository) GetProductsAfter(ctx context.Context, afterID int, limit int) ([]*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]*models.Product, 0, limit)
+	for id := afterID + 1; id <= r.lastID && len(products) < limit; id++ {
+		product, ok := r.products[id]
+		if !ok || product.DeletedAt.Valid {
+			continue
+		}
+		result := *product
+		products = append(products, &result)
+	}
+	return products, nil
+}
+
+func (r *MemoryProductRepository) CreateProduct(ctx context.Context, product *models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	product.ID = r.lastID
+	product.Version = 1
+	product.DeletedAt = gorm.DeletedAt{}
+
+	stored := *product
+	r.products[stored.ID] = &stored
+	return nil
+}
+
+func (r *MemoryProductRepository) UpdateProductByID(ctx context.Context, product *models.Product, productID int) (*models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.products[productID]
+	if !ok || existing.DeletedAt.Valid {
+		return nil, utils.ErrProductNotFound
+	}
+	if existing.Version != product.Version {
+		return nil, utils.ErrVersionMismatch
+	}
+
+	existing.Name = product.Name
+	existing.Price = product.Price
+	existing.Version++
+
+	result := *existing
+	return &result, nil
+}
+
+func (r *MemoryProductRepository) GetProductWithReviews(ctx context.Context, productID int) (*models.ProductWithReview, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, ok := r.products[productID]
+	if !ok || product.DeletedAt.Valid {
+		return nil, utils.ErrProductNotFound
+	}
+
+	reviews, err := r.shownReviews(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := *product
+	return &models.ProductWithReview{Product: &result, Reviews: reviews}, nil
+}
+
+func (r *MemoryProductRepository) GetReviewsByProductID(ctx context.Context, productID int) ([]*models.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, ok := r.products[productID]
+	if !ok || product.DeletedAt.Valid {
+		return nil, utils.ErrProductNotFound
+	}
+
+	return r.shownReviews(ctx, productID)
+}
+
+// shownReviews returns the live reviews of a product by shown members in ID
+// order, with the likes of shown members. Callers must hold r.mu.
+func (r *MemoryProductRepository) shownReviews(ctx context.Context, productID int) ([]*models.Review, error) {
+	reviews := make([]*models.Review, 0)
+	byID := make(map[int]*models.Review)
+	for _, review := range r.reviews {
+		if review.ProductID != productID || review.DeletedAt.Valid {
+			continue
+		}
+
+		member, err := r.members.GetAuthorByID(ctx, review.MemberID)
+		if err != nil {
+			if errors.Is(err, utils.NotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		data := *review
+		data.Username = member.Username
+		data.Gender = member.Gender
+		data.SkinType = member.SkinType
+		data.SkinColor = member.SkinColor
+		data.LikeCount = 0
+		result := &models.Review{ReviewData: data}
+		reviews = append(reviews, result)
+		byID[review.ID] = result
+	}
+
+	for like := range r.likes {
+		review, ok := byID[like.ReviewID]
+		if !ok {
+			continue
+		}
+		_, err := r.members.GetAuthorByID(ctx, like.MemberID)
+		if err != nil {
+			if errors.Is(err, utils.NotFound) {
+				continue
+			}
+			return nil, err
+		}
+		review.LikeCount++
+	}
+
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ID < reviews[j].ID })
+	return reviews, nil
+}
+
+func (r *MemoryProductRepository) GetReviewsByMemberID(ctx context.Context, memberID int) ([]*models.MemberReview, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reviews := make([]*models.MemberReview, 0)
+	for _, review := range r.reviews {
+		if review.MemberID != memberID {
+			continue
+		}
+
+		result := &models.MemberReview{ID: review.ID, ProductID: review.ProductID, Description: review.Description}
+		if product, ok := r.products[review.ProductID]; ok {
+			result.ProductName = product.Name
+		}
+		if review.DeletedAt.Valid {
+			deletedAt := review.DeletedAt.Time
+			result.DeletedAt = &deletedAt
+		}
+		reviews = append(reviews, result)
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ID < reviews[j].ID })
+
+	return reviews, nil
+}
+
+func (r *MemoryProductRepository) GetLikesByMemberID(ctx context.Context, memberID int) ([]*models.MemberLike, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	likes := make([]*models.MemberLike, 0)
+	for like := range r.likes {
+		review, ok := r.reviews[like.ReviewID]
+		if like.MemberID != memberID || !ok {
+			continue
+		}
+
+		result := &models.MemberLike{ReviewID: review.ID, ProductID: review.ProductID}
+		if product, ok := r.products[review.ProductID]; ok {
+			result.ProductName = product.Name
+		}
+		likes = append(likes, result)
+	}
+	sort.Slice(likes, func(i, j int) bool { return likes[i].ReviewID < likes[j].ReviewID })
+
+	return likes, nil
+}
+
+func (r *MemoryProductRepository) CountMemberActivity(ctx context.Context, memberID int) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, review := range r.reviews {
+		if review.MemberID == memberID {
+			count++
+		}
+	}
+	for like := range r.likes {
+		if like.MemberID == memberID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryProductRepository) CheckReviewExistence(ctx context.Context, reviewID int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	review, ok := r.reviews[reviewID]
+	return ok && !review.DeletedAt.Valid, nil
+}
+
+func (r *MemoryProductRepository) GetReviewProductID(ctx context.Context, reviewID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	review, ok := r.reviews[reviewID]
+	if !ok {
+		return 0, utils.ErrReviewNotFound
+	}
+	return review.ProductID, nil
+}
+
+func (r *MemoryProductRepository) LikeReview(ctx context.Context, reviewID int, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	live, err := r.liveMember(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !live {
+		return utils.ErrMemberNotFound
+	}
+
+	like := models.LikeReview{ReviewID: reviewID, MemberID: userID}
+	if _, exists := r.likes[like]; exists {
+		return utils.ErrReviewAlreadyLiked
+	}
+	if _, exists := r.reviews[reviewID]; !exists {
+		return utils.ErrReviewNotFound
+	}
+
+	r.likes[like] = struct{}{}
+	return nil
+}
+
+func (r *MemoryProductRepository) CancelLikeReview(ctx context.Context, reviewID int, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	like := models.LikeReview{ReviewID: reviewID, MemberID: userID}
+	if _, exists := r.likes[like]; !exists {
+		return utils.ErrReviewNotLiked
+	}
+
+	delete(r.likes, like)
+	return nil
+}
+
+func (r *MemoryProductRepository) DeleteProductByID(ctx context.Context, productID int, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[productID]
+	if !ok || product.DeletedAt.Valid {
+		return utils.ErrProductNotFound
+	}
+	if !matchesVersion(product.Version, version) {
+		return utils.ErrVersionMismatch
+	}
+
+	product.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (r *MemoryProductRepository) RestoreProductByID(ctx context.Context, productID int, version int) (*models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[productID]
+	if !ok || !product.DeletedAt.Valid {
+		return nil, utils.ErrProductNotFound
+	}
+	if !matchesVersion(product.Version, version) {
+		return nil, utils.ErrVersionMismatch
+	}
+	product.DeletedAt = gorm.DeletedAt{}
+	product.Version++
+
+	result := *product
+	return &result, nil
+}
+
+func (r *MemoryProductRepository) DeleteReviewByID(ctx context.Context, reviewID int, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	review, ok := r.reviews[reviewID]
+	if !ok || review.DeletedAt.Valid {
+		return utils.ErrReviewNotFound
+	}
+	if !matchesVersion(review.Version, version) {
+		return utils.ErrVersionMismatch
+	}
+
+	review.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (r *MemoryProductRepository) RestoreReviewByID(ctx context.Context, reviewID int, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	review, ok := r.reviews[reviewID]
+	if !ok || !review.DeletedAt.Valid {
+		return utils.ErrReviewNotFound
+	}
+	if !matchesVersion(review.Version, version) {
+		return utils.ErrVersionMismatch
+	}
+	review.DeletedAt = gorm.DeletedAt{}
+	review.Version++
+	return nil
+}
+
+// matchesVersion reports whether the version a caller expects, possibly
+// utils.AnyVersion, is the stored one.
+func matchesVersion(stored, version int) bool {
+	return version == utils.AnyVersion || version == stored
+}
+
+// PurgeDeleted permanently removes reviews and products soft deleted before
+// the given time. As with the SQL foreign keys, the reviews of a purged
+// product and the likes of a purged review go with it without being counted.
+func (r *MemoryProductRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, review := range r.reviews {
+		if review.DeletedAt.Valid && review.DeletedAt.Time.Before(before) {
+			delete(r.reviews, id)
+			purged++
+		}
+	}
+	for id, product := range r.products {
+		if product.DeletedAt.Valid && product.DeletedAt.Time.Before(before) {
+			delete(r.products, id)
+			purged++
+		}
+	}
+
+	for id, review := range r.reviews {
+		if _, ok := r.products[review.ProductID]; !ok {
+			delete(r.reviews, id)
+		}
+	}
+	for like := range r.likes {
+		if _, ok := r.reviews[like.ReviewID]; !ok {
+			delete(r.likes, like)
+		}
+	}
+
+	return purged, nil
+}
+
+// liveMember reports whether the member exists and is not deleted.
+func (r *MemoryProductRepository) liveMember(ctx context.Context, memberID int) (bool, error) {
+	_, err := r.members.GetMemberByID(ctx, memberID)
+	if err != nil {
+		if errors.Is(err, utils.NotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
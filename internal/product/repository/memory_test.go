@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	memberModels "social_media/internal/member/models"
+	memberRepository "social_media/internal/member/repository"
+	"social_media/internal/product/models"
+	"social_media/internal/testutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemoryProductRepository(t *testing.T) {
+	testProductRepository(t, func(t *testing.T, f testutil.Fixtures) (ProductRepository, memberRepository.MemberRepository) {
+		seed := make([]*memberModels.Member, len(f.Members))
+		for i := range f.Members {
+			seed[i] = &f.Members[i]
+		}
+		members := memberRepository.NewMemoryMemberRepository(seed...)
+
+		repo := NewMemoryProductRepository(members)
+		for i := range f.Products {
+			repo.AddProduct(&f.Products[i])
+		}
+		for i := range f.Reviews {
+			repo.AddReview(&f.Reviews[i])
+		}
+		for _, like := range f.Likes {
+			repo.AddLike(like)
+		}
+
+		return repo, members
+	})
+}
+
+func TestMemoryProductRepository_ConcurrentLikes(t *testing.T) {
+	members := memberRepository.NewMemoryMemberRepository(&memberModels.Member{ID: 1, Username: "User1"})
+	repo := NewMemoryProductRepository(members)
+	repo.AddProduct(&models.Product{ID: 1, Name: "Product1"})
+	repo.AddReview(&models.ReviewData{ID: 1, ProductID: 1, MemberID: 1})
+
+	var (
+		wg    sync.WaitGroup
+		liked int32
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if repo.LikeReview(context.Background(), 1, 1) == nil {
+				atomic.AddInt32(&liked, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if liked != 1 {
+		t.Errorf("%d concurrent likes by the same member succeeded, want 1", liked)
+	}
+}
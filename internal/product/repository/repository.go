@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"social_media/internal/product/models"
+	"social_media/pkg/utils"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"gorm.io/gorm"
@@ -12,10 +14,24 @@ import (
 
 type ProductRepository interface {
 	GetProductByID(ctx context.Context, productID int) (*models.Product, error)
+	GetProductByName(ctx context.Context, name string) (*models.Product, error)
+	GetProductsAfter(ctx context.Context, afterID int, limit int) ([]*models.Product, error)
+	CreateProduct(ctx context.Context, product *models.Product) error
+	UpdateProductByID(ctx context.Context, product *models.Product, productID int) (*models.Product, error)
+	GetProductWithReviews(ctx context.Context, productID int) (*models.ProductWithReview, error)
 	GetReviewsByProductID(ctx context.Context, productID int) ([]*models.Review, error)
+	GetReviewsByMemberID(ctx context.Context, memberID int) ([]*models.MemberReview, error)
+	GetLikesByMemberID(ctx context.Context, memberID int) ([]*models.MemberLike, error)
+	CountMemberActivity(ctx context.Context, memberID int) (int64, error)
 	CheckReviewExistence(ctx context.Context, reviewID int) (bool, error)
+	GetReviewProductID(ctx context.Context, reviewID int) (int, error)
 	LikeReview(ctx context.Context, reviewID int, userID int) error
 	CancelLikeReview(ctx context.Context, reviewID int, userID int) error
+	DeleteProductByID(ctx context.Context, productID int, version int) error
+	RestoreProductByID(ctx context.Context, productID int, version int) (*models.Product, error)
+	DeleteReviewByID(ctx context.Context, reviewID int, version int) error
+	RestoreReviewByID(ctx context.Context, reviewID int, version int) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
 type MySQLProductRepository struct {
@@ -36,7 +52,7 @@ func (r *MySQLProductRepository) GetProductByID(ctx context.Context, productID i
 	err := r.db.WithContext(ctx).First(&product, productID).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("product not found")
+			return nil, utils.ErrProductNotFound
 		}
 		return nil, err
 	}
@@ -44,43 +60,232 @@ func (r *MySQLProductRepository) GetProductByID(ctx context.Context, productID i
 	return &product, nil
 }
 
-func (r *MySQLProductRepository) GetReviewsByProductID(ctx context.Context, productID int) ([]*models.Review, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetReviewsByProductID")
+// GetProductByName returns the live product with the lowest ID named name.
+func (r *MySQLProductRepository) GetProductByName(ctx context.Context, name string) (*models.Product, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetProductByName")
 	defer span.Finish()
 
-	// Check if the product exists
 	var product models.Product
-	err := r.db.WithContext(ctx).First(&product, productID).Error
+	err := r.db.WithContext(ctx).Where("PRODUCT_NAME = ?", name).Order("ID_PRODUCT").First(&product).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("product with ID %d not found", productID)
+			return nil, utils.ErrProductNotFound
 		}
 		return nil, err
 	}
-	var reviewData []*models.ReviewData
-	err = r.db.WithContext(ctx).
-		Model(&models.Review{}).
-		Select("review_products.*, COALESCE(l.like_count, 0) AS like_count, members.username, members.gender, members.skintype, members.skincolor").
-		Joins("INNER JOIN members ON review_products.id_member = members.ID_MEMBER").
-		Joins("LEFT JOIN (SELECT id_review, COUNT(*) AS like_count FROM like_reviews GROUP BY id_review) l ON review_products.id_review = l.id_review").
-		Where("review_products.id_product = ?", productID).
-		Table("review_products").
-		Scan(&reviewData).
+
+	return &product, nil
+}
+
+// GetProductsAfter returns up to limit live products with an ID above
+// afterID, in ID order, to page through every product.
+func (r *MySQLProductRepository) GetProductsAfter(ctx context.Context, afterID int, limit int) ([]*models.Product, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetProductsAfter")
+	defer span.Finish()
+
+	products := make([]*models.Product, 0, limit)
+	err := r.db.WithContext(ctx).
+		Where("ID_PRODUCT > ?", afterID).
+		Order("ID_PRODUCT").
+		Limit(limit).
+		Find(&products).
 		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// CreateProduct stores a new product, assigning its ID and first version.
+func (r *MySQLProductRepository) CreateProduct(ctx context.Context, product *models.Product) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.CreateProduct")
+	defer span.Finish()
+
+	product.ID = 0
+	product.Version = 1
+	return r.db.WithContext(ctx).Select("PRODUCT_NAME", "PRICE", "VERSION").Create(product).Error
+}
+
+// UpdateProductByID replaces the name and price of a product. product.Version
+// must hold the stored version, otherwise utils.ErrVersionMismatch is returned.
+func (r *MySQLProductRepository) UpdateProductByID(ctx context.Context, product *models.Product, productID int) (*models.Product, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.UpdateProductByID")
+	defer span.Finish()
+
+	if _, err := r.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.Product{}).
+		Where("ID_PRODUCT = ? AND VERSION = ?", productID, product.Version).
+		Updates(map[string]interface{}{
+			"PRODUCT_NAME": product.Name,
+			"PRICE":        product.Price,
+			"VERSION":      gorm.Expr("VERSION + 1"),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, utils.ErrVersionMismatch
+	}
+
+	return r.GetProductByID(ctx, productID)
+}
+
+// shownMember keeps the members whose reviews and likes are shown: deleted
+// members are left out, anonymized ones are kept as former members
+const shownMember = "(%[1]s.DELETED_AT IS NULL OR %[1]s.ANONYMIZED_AT IS NOT NULL)"
+
+// productPageQuery reads a live product and its live reviews in one round
+// trip, a product without any coming back as a single row without a review.
+// Authors are left joined so that a product whose reviews are all hidden
+// still has its row, author_id is NULL on the reviews to leave out. Likes are
+// counted per review through idx_like_reviews_review rather than aggregated
+// over the whole table.
+var productPageQuery = "SELECT p.ID_PRODUCT, p.PRODUCT_NAME, p.PRICE, p.VERSION, " +
+	"r.ID_REVIEW AS review_id, r.ID_MEMBER AS member_id, r.DESC_REVIEW AS description, r.VERSION AS review_version, m.ID_MEMBER AS author_id, " +
+	"m.USERNAME AS username, m.GENDER AS gender, m.SKINTYPE AS skintype, m.SKINCOLOR AS skincolor, " +
+	"(SELECT COUNT(*) FROM like_reviews l INNER JOIN members lm ON lm.ID_MEMBER = l.ID_MEMBER AND " + fmt.Sprintf(shownMember, "lm") +
+	" WHERE l.ID_REVIEW = r.ID_REVIEW) AS like_count " +
+	"FROM products p " +
+	"LEFT JOIN review_products r ON r.ID_PRODUCT = p.ID_PRODUCT AND r.DELETED_AT IS NULL " +
+	"LEFT JOIN members m ON m.ID_MEMBER = r.ID_MEMBER AND " + fmt.Sprintf(shownMember, "m") + " " +
+	"WHERE p.ID_PRODUCT = ? AND p.DELETED_AT IS NULL " +
+	"ORDER BY r.ID_REVIEW"
+
+// productPageRow is a row of productPageQuery
+type productPageRow struct {
+	ID            int     `gorm:"column:ID_PRODUCT"`
+	Name          string  `gorm:"column:PRODUCT_NAME"`
+	Price         float64 `gorm:"column:PRICE"`
+	Version       int     `gorm:"column:VERSION"`
+	ReviewID      *int    `gorm:"column:review_id"`
+	MemberID      int     `gorm:"column:member_id"`
+	AuthorID      *int    `gorm:"column:author_id"`
+	Description   string  `gorm:"column:description"`
+	ReviewVersion int     `gorm:"column:review_version"`
+	Username      string  `gorm:"column:username"`
+	Gender        string  `gorm:"column:gender"`
+	SkinType      string  `gorm:"column:skintype"`
+	SkinColor     string  `gorm:"column:skincolor"`
+	LikeCount     int     `gorm:"column:like_count"`
+}
+
+// GetProductWithReviews returns a live product with its shown reviews in ID
+// order, as on its page.
+func (r *MySQLProductRepository) GetProductWithReviews(ctx context.Context, productID int) (*models.ProductWithReview, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetProductWithReviews")
+	defer span.Finish()
+
+	var rows []*productPageRow
+	err := r.db.WithContext(ctx).Raw(productPageQuery, productID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, utils.ErrProductNotFound
+	}
 
-	reviews := make([]*models.Review, len(reviewData))
-	for i, data := range reviewData {
-		reviews[i] = &models.Review{
-			ReviewData: *data,
+	page := &models.ProductWithReview{
+		Product: &models.Product{ID: rows[0].ID, Name: rows[0].Name, Price: rows[0].Price, Version: rows[0].Version},
+		Reviews: make([]*models.Review, 0, len(rows)),
+	}
+	for _, row := range rows {
+		if row.ReviewID == nil || row.AuthorID == nil {
+			continue
 		}
+		page.Reviews = append(page.Reviews, &models.Review{ReviewData: models.ReviewData{
+			ID:          *row.ReviewID,
+			ProductID:   row.ID,
+			MemberID:    row.MemberID,
+			Username:    row.Username,
+			LikeCount:   row.LikeCount,
+			Description: row.Description,
+			Gender:      row.Gender,
+			SkinType:    row.SkinType,
+			SkinColor:   row.SkinColor,
+			Version:     row.ReviewVersion,
+		}})
 	}
 
+	return page, nil
+}
+
+func (r *MySQLProductRepository) GetReviewsByProductID(ctx context.Context, productID int) ([]*models.Review, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetReviewsByProductID")
+	defer span.Finish()
+
+	page, err := r.GetProductWithReviews(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Reviews, nil
+}
+
+// GetReviewsByMemberID returns every review written by a member in ID order,
+// soft deleted ones and those on deleted products included.
+func (r *MySQLProductRepository) GetReviewsByMemberID(ctx context.Context, memberID int) ([]*models.MemberReview, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetReviewsByMemberID")
+	defer span.Finish()
+
+	reviews := make([]*models.MemberReview, 0)
+	err := r.db.WithContext(ctx).
+		Table("review_products").
+		Select("review_products.ID_REVIEW, review_products.ID_PRODUCT, products.PRODUCT_NAME, review_products.DESC_REVIEW, review_products.DELETED_AT").
+		Joins("LEFT JOIN products ON products.ID_PRODUCT = review_products.ID_PRODUCT").
+		Where("review_products.ID_MEMBER = ?", memberID).
+		Order("review_products.ID_REVIEW").
+		Scan(&reviews).
+		Error
 	if err != nil {
 		return nil, err
 	}
 
 	return reviews, nil
+}
+
+// GetLikesByMemberID returns every like given by a member, ordered by review.
+func (r *MySQLProductRepository) GetLikesByMemberID(ctx context.Context, memberID int) ([]*models.MemberLike, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetLikesByMemberID")
+	defer span.Finish()
+
+	likes := make([]*models.MemberLike, 0)
+	err := r.db.WithContext(ctx).
+		Table("like_reviews").
+		Select("like_reviews.ID_REVIEW, review_products.ID_PRODUCT, products.PRODUCT_NAME").
+		Joins("INNER JOIN review_products ON review_products.ID_REVIEW = like_reviews.ID_REVIEW").
+		Joins("LEFT JOIN products ON products.ID_PRODUCT = review_products.ID_PRODUCT").
+		Where("like_reviews.ID_MEMBER = ?", memberID).
+		Order("like_reviews.ID_REVIEW").
+		Scan(&likes).
+		Error
+	if err != nil {
+		return nil, err
+	}
 
+	return likes, nil
+}
+
+// CountMemberActivity returns how many reviews and likes a member has,
+// the rows GetReviewsByMemberID and GetLikesByMemberID would return.
+func (r *MySQLProductRepository) CountMemberActivity(ctx context.Context, memberID int) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.CountMemberActivity")
+	defer span.Finish()
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Raw("SELECT (SELECT COUNT(*) FROM review_products WHERE ID_MEMBER = ?) + (SELECT COUNT(*) FROM like_reviews WHERE ID_MEMBER = ?)", memberID, memberID).
+		Row().
+		Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
 }
 
 func (r *MySQLProductRepository) CheckReviewExistence(ctx context.Context, reviewID int) (bool, error) {
@@ -100,25 +305,57 @@ func (r *MySQLProductRepository) CheckReviewExistence(ctx context.Context, revie
 	return count > 0, nil
 }
 
+// GetReviewProductID returns the product a review is on, soft deleted
+// reviews included.
+func (r *MySQLProductRepository) GetReviewProductID(ctx context.Context, reviewID int) (int, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.GetReviewProductID")
+	defer span.Finish()
+
+	var productIDs []int
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Review{}).
+		Where("ID_REVIEW = ?", reviewID).
+		Pluck("ID_PRODUCT", &productIDs).
+		Error
+	if err != nil {
+		return 0, err
+	}
+	if len(productIDs) == 0 {
+		return 0, utils.ErrReviewNotFound
+	}
+	return productIDs[0], nil
+}
+
 func (r *MySQLProductRepository) LikeReview(ctx context.Context, reviewID int, userID int) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.LikeReview")
 	defer span.Finish()
 
-	// Check if the user has already liked the review
-	exists, err := r.checkLikeExistence(ctx, reviewID, userID)
+	// Check if the member exists, rather than failing on the foreign key
+	var members int64
+	err := r.db.WithContext(ctx).
+		Table("members").
+		Where("ID_MEMBER = ? AND DELETED_AT IS NULL", userID).
+		Count(&members).
+		Error
 	if err != nil {
 		return err
 	}
-	if exists {
-		return errors.New("user has already liked the review")
+	if members == 0 {
+		return utils.ErrMemberNotFound
 	}
 
+	// The unique like index turns a repeated like, even a concurrent one,
+	// into a duplicate key
 	like := models.LikeReview{
 		ReviewID: reviewID,
 		MemberID: userID,
 	}
 
 	err = r.db.WithContext(ctx).Create(&like).Error
+	if isDuplicateKey(r.db, err) {
+		return utils.ErrReviewAlreadyLiked
+	}
 	if err != nil {
 		return err
 	}
@@ -136,7 +373,7 @@ func (r *MySQLProductRepository) CancelLikeReview(ctx context.Context, reviewID
 		return err
 	}
 	if !exists {
-		return errors.New("user has not liked the review")
+		return utils.ErrReviewNotLiked
 	}
 
 	err = r.db.WithContext(ctx).
@@ -150,6 +387,150 @@ func (r *MySQLProductRepository) CancelLikeReview(ctx context.Context, reviewID
 	return nil
 }
 
+// DeleteProductByID soft deletes a live product, the row stays until
+// PurgeDeleted removes it. version must be the stored version or
+// utils.AnyVersion, otherwise utils.ErrVersionMismatch is returned. The
+// version is kept, so the same ETag restores the product.
+func (r *MySQLProductRepository) DeleteProductByID(ctx context.Context, productID int, version int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.DeleteProductByID")
+	defer span.Finish()
+
+	result := withVersion(r.db.WithContext(ctx).Where("ID_PRODUCT = ?", productID), version).
+		Delete(&models.Product{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return versionMismatch(r.db.WithContext(ctx).Model(&models.Product{}).Where("ID_PRODUCT = ?", productID), utils.ErrProductNotFound)
+	}
+
+	return nil
+}
+
+// RestoreProductByID brings back a soft deleted product with a new version,
+// utils.ErrProductNotFound is returned when there is no such product to
+// restore. version is checked as by DeleteProductByID.
+func (r *MySQLProductRepository) RestoreProductByID(ctx context.Context, productID int, version int) (*models.Product, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.RestoreProductByID")
+	defer span.Finish()
+
+	deleted := func() *gorm.DB {
+		return r.db.WithContext(ctx).
+			Unscoped().
+			Model(&models.Product{}).
+			Where("ID_PRODUCT = ? AND DELETED_AT IS NOT NULL", productID)
+	}
+	result := withVersion(deleted(), version).
+		Updates(map[string]interface{}{
+			"DELETED_AT": nil,
+			"VERSION":    gorm.Expr("VERSION + 1"),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, versionMismatch(deleted(), utils.ErrProductNotFound)
+	}
+
+	return r.GetProductByID(ctx, productID)
+}
+
+// DeleteReviewByID soft deletes a live review, with the same version check
+// as DeleteProductByID.
+func (r *MySQLProductRepository) DeleteReviewByID(ctx context.Context, reviewID int, version int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.DeleteReviewByID")
+	defer span.Finish()
+
+	result := withVersion(r.db.WithContext(ctx).Where("ID_REVIEW = ?", reviewID), version).
+		Delete(&models.Review{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return versionMismatch(r.db.WithContext(ctx).Model(&models.Review{}).Where("ID_REVIEW = ?", reviewID), utils.ErrReviewNotFound)
+	}
+
+	return nil
+}
+
+// RestoreReviewByID brings back a soft deleted review with a new version,
+// with the same version check as DeleteProductByID.
+func (r *MySQLProductRepository) RestoreReviewByID(ctx context.Context, reviewID int, version int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.RestoreReviewByID")
+	defer span.Finish()
+
+	deleted := func() *gorm.DB {
+		return r.db.WithContext(ctx).
+			Unscoped().
+			Model(&models.Review{}).
+			Where("ID_REVIEW = ? AND DELETED_AT IS NOT NULL", reviewID)
+	}
+	result := withVersion(deleted(), version).
+		Updates(map[string]interface{}{
+			"DELETED_AT": nil,
+			"VERSION":    gorm.Expr("VERSION + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return versionMismatch(deleted(), utils.ErrReviewNotFound)
+	}
+
+	return nil
+}
+
+// withVersion restricts query to the given version, utils.AnyVersion
+// matches every version.
+func withVersion(query *gorm.DB, version int) *gorm.DB {
+	if version == utils.AnyVersion {
+		return query
+	}
+	return query.Where("VERSION = ?", version)
+}
+
+// versionMismatch tells why a conditional write changed nothing: query
+// still finding the row means its version differs, otherwise notFound.
+func versionMismatch(query *gorm.DB, notFound error) error {
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound
+	}
+	return utils.ErrVersionMismatch
+}
+
+// PurgeDeleted permanently removes reviews and products soft deleted before
+// the given time, together with their likes.
+func (r *MySQLProductRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.PurgeDeleted")
+	defer span.Finish()
+
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reviews := tx.Unscoped().
+			Where("DELETED_AT IS NOT NULL AND DELETED_AT < ?", before).
+			Delete(&models.Review{})
+		if reviews.Error != nil {
+			return reviews.Error
+		}
+
+		products := tx.Unscoped().
+			Where("DELETED_AT IS NOT NULL AND DELETED_AT < ?", before).
+			Delete(&models.Product{})
+		if products.Error != nil {
+			return products.Error
+		}
+
+		purged = reviews.RowsAffected + products.RowsAffected
+		return nil
+	})
+
+	return purged, err
+}
+
 func (r *MySQLProductRepository) checkLikeExistence(ctx context.Context, reviewID int, userID int) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
@@ -163,3 +544,12 @@ func (r *MySQLProductRepository) checkLikeExistence(ctx context.Context, reviewI
 
 	return count > 0, nil
 }
+
+// isDuplicateKey reports whether err is a unique index violation, as told by
+// the driver behind db.
+func isDuplicateKey(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	memberRepository "social_media/internal/member/repository"
+	"social_media/internal/testutil"
+	"social_media/pkg/utils"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestMySQLProductRepository(t *testing.T) {
+	testProductRepository(t, func(t *testing.T, f testutil.Fixtures) (ProductRepository, memberRepository.MemberRepository) {
+		db := testutil.NewDatabase(t)
+		testutil.Seed(t, db, f)
+
+		return NewMySQLProductRepository(db), memberRepository.NewMemberRepository(db)
+	})
+}
+
+func TestMySQLProductRepository_LikeReviewDuplicateKey(t *testing.T) {
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		insertErr error
+		wantErr   error
+	}{
+		{name: "duplicate key", insertErr: &mysqlDriver.MySQLError{Number: 1062, Message: "Duplicate entry '2-4' for key 'idx_like_reviews_review'"}, wantErr: utils.ErrReviewAlreadyLiked},
+		{name: "database error", insertErr: dbErr, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() error = %v", err)
+			}
+			defer sqlDB.Close()
+
+			db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatalf("gorm.Open() error = %v", err)
+			}
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `members`")).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `like_reviews`")).
+				WithArgs(2, 4).
+				WillReturnError(tt.insertErr)
+			mock.ExpectRollback()
+
+			err = NewMySQLProductRepository(db).LikeReview(context.Background(), 2, 4)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LikeReview() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -2,9 +2,10 @@ package usecase
 
 import (
 	"context"
-	"errors"
 	"social_media/internal/product/models"
 	"social_media/internal/product/repository"
+	"social_media/pkg/utils"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 )
@@ -17,6 +18,11 @@ type ProductUsecaseInterface interface {
 	GetProductWithReview(ctx context.Context, productID int) (*models.ProductWithReview, error)
 	LikeReview(ctx context.Context, reviewID int, userID int) error
 	CancelLikeReview(ctx context.Context, reviewID int, userID int) error
+	DeleteProduct(ctx context.Context, productID int, version int) error
+	RestoreProduct(ctx context.Context, productID int, version int) (*models.Product, error)
+	DeleteReview(ctx context.Context, reviewID int, version int) error
+	RestoreReview(ctx context.Context, reviewID int, version int) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
 func NewProductUsecase(productRepository repository.ProductRepository) *ProductUsecase {
@@ -29,21 +35,11 @@ func (u *ProductUsecase) GetProductWithReview(ctx context.Context, productID int
 	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.GetProductWithReview")
 	defer span.Finish()
 
-	product, err := u.ProductRepository.GetProductByID(ctx, productID)
+	productWithReview, err := u.ProductRepository.GetProductWithReviews(ctx, productID)
 	if err != nil {
 		return nil, err
 	}
 
-	reviews, err := u.ProductRepository.GetReviewsByProductID(ctx, productID)
-	if err != nil {
-		return nil, err
-	}
-
-	productWithReview := &models.ProductWithReview{
-		Product: product,
-		Reviews: reviews,
-	}
-
 	return productWithReview, nil
 }
 
@@ -57,7 +53,7 @@ func (u *ProductUsecase) LikeReview(ctx context.Context, reviewID int, userID in
 		return err
 	}
 	if !exists {
-		return errors.New("review not found")
+		return utils.ErrReviewNotFound
 	}
 
 	// Like the review
@@ -79,7 +75,7 @@ func (u *ProductUsecase) CancelLikeReview(ctx context.Context, reviewID int, use
 		return err
 	}
 	if !exists {
-		return errors.New("review not found")
+		return utils.ErrReviewNotFound
 	}
 
 	// Cancel the like on the review
@@ -90,3 +86,38 @@ func (u *ProductUsecase) CancelLikeReview(ctx context.Context, reviewID int, use
 
 	return nil
 }
+
+func (u *ProductUsecase) DeleteProduct(ctx context.Context, productID int, version int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.DeleteProduct")
+	defer span.Finish()
+
+	return u.ProductRepository.DeleteProductByID(ctx, productID, version)
+}
+
+func (u *ProductUsecase) RestoreProduct(ctx context.Context, productID int, version int) (*models.Product, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.RestoreProduct")
+	defer span.Finish()
+
+	return u.ProductRepository.RestoreProductByID(ctx, productID, version)
+}
+
+func (u *ProductUsecase) DeleteReview(ctx context.Context, reviewID int, version int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.DeleteReview")
+	defer span.Finish()
+
+	return u.ProductRepository.DeleteReviewByID(ctx, reviewID, version)
+}
+
+func (u *ProductUsecase) RestoreReview(ctx context.Context, reviewID int, version int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.RestoreReview")
+	defer span.Finish()
+
+	return u.ProductRepository.RestoreReviewByID(ctx, reviewID, version)
+}
+
+func (u *ProductUsecase) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.PurgeDeleted")
+	defer span.Finish()
+
+	return u.ProductRepository.PurgeDeleted(ctx, before)
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+	"social_media/internal/search/models"
+	"social_media/internal/search/usecase"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+type SearchHandler struct {
+	SearchUsecase usecase.SearchUsecaseInterface
+	logger        zap.Logger
+}
+
+func MapSearchRoutes(apiGroup *echo.Group, logger zap.Logger, searchUsecase usecase.SearchUsecaseInterface) {
+	h := &SearchHandler{
+		logger:        logger,
+		SearchUsecase: searchUsecase,
+	}
+
+	apiGroup.GET("/search", h.Search)
+}
+
+// Search godoc
+// @Tags Search
+// @Summary Search products and reviews
+// @Description Full-text search over product names and review text, best matches first. Each hit has an HTML escaped snippet with the matched words wrapped in <em>. Skin filters keep reviews by members with that profile, and products such members reviewed.
+// @ID search
+// @Param q query string true "Words to search for"
+// @Param type query string false "Only hits of this type" Enums(product, review)
+// @Param gender query string false "Gender of the review author" Enums(Male, Female)
+// @Param skinType query string false "Skin type of the review author" Enums(Oily, Dry, Normal, Combination)
+// @Param skinColor query string false "Skin color of the review author" Enums(Fair, Medium, Dark)
+// @Param limit query int false "Maximum number of hits, 20 by default" minimum(1) maximum(100)
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.SearchResult}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /search [get]
+func (h *SearchHandler) Search(c echo.Context) error {
+	span, ctx := opentracing.StartSpanFromContext(utils.GetRequestCtx(c), "http.Search")
+	defer span.Finish()
+
+	var query models.Query
+	if err := utils.ReadRequest(c, &query); err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	result, err := h.SearchUsecase.Search(ctx, &query)
+	if err != nil {
+		return c.JSON(utils.ErrorResponse(c, err))
+	}
+
+	return c.JSON(utils.SuccessResponse(c, http.StatusOK, "ok", result))
+}
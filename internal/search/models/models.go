@@ -0,0 +1,56 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Kinds of search hits
+const (
+	KindProduct = "product"
+	KindReview  = "review"
+)
+
+// Query is a search over product names and review text. Gender, SkinType
+// and SkinColor keep review hits to authors with that skin profile, and
+// product hits to products such authors reviewed.
+type Query struct {
+	Text      string `query:"q" validate:"required,max=255"`
+	Kind      string `query:"type" validate:"omitempty,oneof=product review"`
+	Gender    string `query:"gender" validate:"omitempty,oneofci=Male Female"`
+	SkinType  string `query:"skinType" validate:"omitempty,oneofci=Oily Dry Normal Combination"`
+	SkinColor string `query:"skinColor" validate:"omitempty,oneofci=Fair Medium Dark"`
+	Limit     int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// HasSkinFilter reports whether the query filters by skin profile.
+func (q *Query) HasSkinFilter() bool {
+	return q.Gender != "" || q.SkinType != "" || q.SkinColor != ""
+}
+
+// Hit is a product or review matching a query. ReviewID is zero on product
+// hits.
+type Hit struct {
+	Kind        string  `json:"type" gorm:"column:kind"`
+	ProductID   int     `json:"productId" gorm:"column:product_id"`
+	ProductName string  `json:"productName" gorm:"column:product_name"`
+	ReviewID    int     `json:"reviewId,omitempty" gorm:"column:review_id"`
+	Score       float64 `json:"score" gorm:"column:score"`
+	// Highlight is an HTML escaped snippet of Text with the matched words
+	// wrapped in <em>
+	Highlight string `json:"highlight" gorm:"-"`
+	// Text is the matched product name or review text
+	Text string `json:"-" gorm:"column:text"`
+}
+
+type SearchResult struct {
+	Query string `json:"query"`
+	Hits  []*Hit `json:"hits"`
+}
+
+// Terms splits text into the lowercase words searches match on.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
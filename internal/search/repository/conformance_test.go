@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	memberModels "social_media/internal/member/models"
+	productModels "social_media/internal/product/models"
+	"social_media/internal/search/models"
+	"social_media/internal/testutil"
+	"testing"
+)
+
+// indexFixtures has three products with four reviews by members of
+// different skin profiles.
+var indexFixtures = testutil.Fixtures{
+	Members: []memberModels.Member{
+		{ID: 1, Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair"},
+		{ID: 2, Username: "User2", Gender: "Female", SkinType: "Dry", SkinColor: "Dark"},
+		{ID: 3, Username: "User3", Gender: "Female", SkinType: "Oily", SkinColor: "Medium"},
+	},
+	Products: []productModels.Product{
+		{ID: 1, Name: "Hydrating Serum", Price: 25},
+		{ID: 2, Name: "Matte Sunscreen", Price: 15},
+		{ID: 3, Name: "Gentle Cleanser", Price: 9.5},
+	},
+	Reviews: []productModels.ReviewData{
+		{ID: 1, MemberID: 1, ProductID: 1, Description: "This serum made my oily skin shine."},
+		{ID: 2, MemberID: 2, ProductID: 1, Description: "Lovely hydrating serum for dry winter skin."},
+		{ID: 3, MemberID: 3, ProductID: 2, Description: "Sunscreen without a white cast."},
+		{ID: 4, MemberID: 2, ProductID: 3, Description: "Too harsh for sensitive skin."},
+	},
+}
+
+// testIndex is the behaviour every Index must share. newIndex returns an
+// index over the fixtures.
+func testIndex(t *testing.T, newIndex func(t *testing.T, f testutil.Fixtures) Index) {
+	ctx := context.Background()
+	index := newIndex(t, indexFixtures)
+
+	// hits lists the hits of query as "product:ID" or "review:ID"
+	hits := func(t *testing.T, query models.Query) []string {
+		t.Helper()
+
+		if query.Limit == 0 {
+			query.Limit = 20
+		}
+		result, err := index.Search(ctx, &query)
+		if err != nil {
+			t.Fatalf("Search(%+v) error = %v", query, err)
+		}
+
+		got := make([]string, len(result))
+		for i, hit := range result {
+			id := hit.ProductID
+			if hit.Kind == models.KindReview {
+				id = hit.ReviewID
+			}
+			got[i] = fmt.Sprintf("%s:%d", hit.Kind, id)
+			if hit.Score <= 0 || hit.Text == "" || hit.ProductName == "" {
+				t.Errorf("hit %+v, want a score, text and product name", hit)
+			}
+		}
+		return got
+	}
+
+	// wantHits compares hits ignoring their order
+	wantHits := func(t *testing.T, query models.Query, want ...string) {
+		t.Helper()
+
+		got := hits(t, query)
+		seen := make(map[string]bool, len(got))
+		for _, hit := range got {
+			seen[hit] = true
+		}
+		if len(got) != len(want) {
+			t.Fatalf("Search(%+v) = %v, want %v", query, got, want)
+		}
+		for _, hit := range want {
+			if !seen[hit] {
+				t.Fatalf("Search(%+v) = %v, want %v", query, got, want)
+			}
+		}
+	}
+
+	t.Run("matches product names and review text", func(t *testing.T) {
+		wantHits(t, models.Query{Text: "serum"}, "product:1", "review:1", "review:2")
+		wantHits(t, models.Query{Text: "SUNSCREEN"}, "product:2", "review:3")
+		wantHits(t, models.Query{Text: "moisturizer"})
+	})
+
+	t.Run("ranks the best match first", func(t *testing.T) {
+		got := hits(t, models.Query{Text: "oily skin", Kind: models.KindReview})
+		if len(got) != 3 || got[0] != "review:1" {
+			t.Errorf("Search() = %v, want review:1 first of 3", got)
+		}
+	})
+
+	t.Run("filters by type", func(t *testing.T) {
+		wantHits(t, models.Query{Text: "serum", Kind: models.KindProduct}, "product:1")
+		wantHits(t, models.Query{Text: "serum", Kind: models.KindReview}, "review:1", "review:2")
+	})
+
+	t.Run("filters by skin profile", func(t *testing.T) {
+		wantHits(t, models.Query{Text: "skin", SkinType: "Dry"}, "review:2", "review:4")
+		wantHits(t, models.Query{Text: "serum", Gender: "Female"}, "product:1", "review:2")
+		wantHits(t, models.Query{Text: "serum", Gender: "Male", SkinColor: "Fair"}, "product:1", "review:1")
+		wantHits(t, models.Query{Text: "serum", SkinColor: "Medium"})
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		if got := hits(t, models.Query{Text: "serum", Limit: 2}); len(got) != 2 {
+			t.Errorf("Search() = %v, want 2 hits", got)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"social_media/internal/search/models"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+)
+
+// Index finds the live products and reviews matching a query, best first.
+// Reviews of deleted members are left out, those of anonymized members are
+// kept. query.Limit must be set.
+type Index interface {
+	Search(ctx context.Context, query *models.Query) ([]*models.Hit, error)
+}
+
+// authorJoin keeps the authors whose reviews are shown, as on product pages
+const authorJoin = "INNER JOIN members m ON m.ID_MEMBER = r.ID_MEMBER AND (m.DELETED_AT IS NULL OR m.ANONYMIZED_AT IS NOT NULL)"
+
+// FullTextIndex is an Index over the MySQL FULLTEXT indexes on
+// products.PRODUCT_NAME and review_products.DESC_REVIEW, ranked by their
+// natural language relevance.
+type FullTextIndex struct {
+	db *gorm.DB
+}
+
+func NewFullTextIndex(db *gorm.DB) *FullTextIndex {
+	return &FullTextIndex{db: db}
+}
+
+func (i *FullTextIndex) Search(ctx context.Context, query *models.Query) ([]*models.Hit, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.Search")
+	defer span.Finish()
+
+	var (
+		selects []string
+		args    []interface{}
+	)
+
+	if query.Kind != models.KindReview {
+		sql := "SELECT 'product' AS kind, p.ID_PRODUCT AS product_id, p.PRODUCT_NAME AS product_name, 0 AS review_id, p.PRODUCT_NAME AS text, " +
+			"MATCH (p.PRODUCT_NAME) AGAINST (? IN NATURAL LANGUAGE MODE) AS score " +
+			"FROM products p WHERE p.DELETED_AT IS NULL AND MATCH (p.PRODUCT_NAME) AGAINST (? IN NATURAL LANGUAGE MODE)"
+		args = append(args, query.Text, query.Text)
+		if query.HasSkinFilter() {
+			filter, filterArgs := skinFilter(query)
+			sql += " AND EXISTS (SELECT 1 FROM review_products r " + authorJoin +
+				" WHERE r.ID_PRODUCT = p.ID_PRODUCT AND r.DELETED_AT IS NULL" + filter + ")"
+			args = append(args, filterArgs...)
+		}
+		selects = append(selects, sql)
+	}
+
+	if query.Kind != models.KindProduct {
+		sql := "SELECT 'review' AS kind, p.ID_PRODUCT AS product_id, p.PRODUCT_NAME AS product_name, r.ID_REVIEW AS review_id, r.DESC_REVIEW AS text, " +
+			"MATCH (r.DESC_REVIEW) AGAINST (? IN NATURAL LANGUAGE MODE) AS score " +
+			"FROM review_products r INNER JOIN products p ON p.ID_PRODUCT = r.ID_PRODUCT AND p.DELETED_AT IS NULL " + authorJoin +
+			" WHERE r.DELETED_AT IS NULL AND MATCH (r.DESC_REVIEW) AGAINST (? IN NATURAL LANGUAGE MODE)"
+		args = append(args, query.Text, query.Text)
+		filter, filterArgs := skinFilter(query)
+		sql += filter
+		args = append(args, filterArgs...)
+		selects = append(selects, sql)
+	}
+
+	args = append(args, query.Limit)
+	hits := make([]*models.Hit, 0, query.Limit)
+	err := i.db.WithContext(ctx).
+		Raw("SELECT * FROM ("+strings.Join(selects, " UNION ALL ")+") hits ORDER BY score DESC, product_id, review_id LIMIT ?", args...).
+		Scan(&hits).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// skinFilter returns the conditions on the author m matching the skin
+// profile of query.
+func skinFilter(query *models.Query) (string, []interface{}) {
+	var (
+		sql  string
+		args []interface{}
+	)
+	for _, column := range []struct {
+		name  string
+		value string
+	}{
+		{"m.GENDER", query.Gender},
+		{"m.SKINTYPE", query.SkinType},
+		{"m.SKINCOLOR", query.SkinColor},
+	} {
+		if column.value != "" {
+			sql += " AND " + column.name + " = ?"
+			args = append(args, column.value)
+		}
+	}
+	return sql, args
+}
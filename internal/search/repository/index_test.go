@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"os"
+	"social_media/internal/testutil"
+	"testing"
+)
+
+func TestFullTextIndex(t *testing.T) {
+	if os.Getenv(testutil.MySQLDSNEnv) == "" {
+		t.Skipf("FULLTEXT indexes need MySQL, set %s", testutil.MySQLDSNEnv)
+	}
+
+	testIndex(t, func(t *testing.T, f testutil.Fixtures) Index {
+		db := testutil.NewDatabase(t)
+		testutil.Seed(t, db, f)
+
+		return NewFullTextIndex(db)
+	})
+}
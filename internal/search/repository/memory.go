@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	productRepo "social_media/internal/product/repository"
+	"social_media/internal/search/models"
+	"social_media/pkg/utils"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// BM25 parameters of MemoryIndex
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// buildPageSize is the number of products read at a time while building
+const buildPageSize = 500
+
+// MemoryIndex is an Index kept in process memory, for SQLite and the memory
+// driver. It is built from a ProductRepository by the first search after
+// maxAge has passed, a zero maxAge rebuilding it on every search, and ranks
+// with BM25.
+type MemoryIndex struct {
+	products productRepo.ProductRepository
+	maxAge   time.Duration
+
+	mu       sync.Mutex
+	builtAt  time.Time
+	built    bool
+	docs     []*document
+	postings map[string][]posting
+	avgLen   float64
+}
+
+// document is an indexed product or review. profiles holds the skin profile
+// of a review's author, or of every author who reviewed a product.
+type document struct {
+	hit      models.Hit
+	length   int
+	profiles []profile
+}
+
+type profile struct {
+	gender, skinType, skinColor string
+}
+
+type posting struct {
+	doc       int
+	frequency int
+}
+
+func NewMemoryIndex(products productRepo.ProductRepository, maxAge time.Duration) *MemoryIndex {
+	return &MemoryIndex{products: products, maxAge: maxAge}
+}
+
+func (i *MemoryIndex) Search(ctx context.Context, query *models.Query) ([]*models.Hit, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "repository.Search")
+	defer span.Finish()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.built || time.Since(i.builtAt) >= i.maxAge {
+		if err := i.build(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	scores := make(map[int]float64)
+	seen := make(map[string]bool)
+	for _, term := range models.Terms(query.Text) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := i.postings[term]
+		idf := math.Log(1 + (float64(len(i.docs))-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for _, p := range postings {
+			doc := i.docs[p.doc]
+			if !matches(doc, query) {
+				continue
+			}
+			tf := float64(p.frequency)
+			scores[p.doc] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.length)/i.avgLen))
+		}
+	}
+
+	hits := make([]*models.Hit, 0, len(scores))
+	for id, score := range scores {
+		hit := i.docs[id].hit
+		hit.Score = score
+		hits = append(hits, &hit)
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		if hits[a].ProductID != hits[b].ProductID {
+			return hits[a].ProductID < hits[b].ProductID
+		}
+		return hits[a].ReviewID < hits[b].ReviewID
+	})
+	if len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+// build replaces the index with the live products and their shown reviews.
+// Callers must hold i.mu.
+func (i *MemoryIndex) build(ctx context.Context) error {
+	var docs []*document
+	for afterID := 0; ; {
+		products, err := i.products.GetProductsAfter(ctx, afterID, buildPageSize)
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			break
+		}
+		afterID = products[len(products)-1].ID
+
+		for _, product := range products {
+			reviews, err := i.products.GetReviewsByProductID(ctx, product.ID)
+			if errors.Is(err, utils.NotFound) {
+				// Deleted since the page was read
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			productDoc := &document{hit: models.Hit{Kind: models.KindProduct, ProductID: product.ID, ProductName: product.Name, Text: product.Name}}
+			docs = append(docs, productDoc)
+			for _, review := range reviews {
+				author := profile{gender: review.Gender, skinType: review.SkinType, skinColor: review.SkinColor}
+				productDoc.profiles = append(productDoc.profiles, author)
+				docs = append(docs, &document{
+					hit:      models.Hit{Kind: models.KindReview, ProductID: product.ID, ProductName: product.Name, ReviewID: review.ID, Text: review.Description},
+					profiles: []profile{author},
+				})
+			}
+		}
+	}
+
+	postings := make(map[string][]posting)
+	totalLen := 0
+	for id, doc := range docs {
+		terms := models.Terms(doc.hit.Text)
+		doc.length = len(terms)
+		totalLen += len(terms)
+
+		frequencies := make(map[string]int, len(terms))
+		for _, term := range terms {
+			frequencies[term]++
+		}
+		for term, frequency := range frequencies {
+			postings[term] = append(postings[term], posting{doc: id, frequency: frequency})
+		}
+	}
+
+	i.docs = docs
+	i.postings = postings
+	i.avgLen = 1
+	if len(docs) > 0 && totalLen > 0 {
+		i.avgLen = float64(totalLen) / float64(len(docs))
+	}
+	i.builtAt = time.Now()
+	i.built = true
+	return nil
+}
+
+// matches reports whether doc is of the kind and, for a skin filter, has an
+// author with the skin profile query asks for.
+func matches(doc *document, query *models.Query) bool {
+	if query.Kind != "" && doc.hit.Kind != query.Kind {
+		return false
+	}
+	if !query.HasSkinFilter() {
+		return true
+	}
+	for _, author := range doc.profiles {
+		if (query.Gender == "" || author.gender == query.Gender) &&
+			(query.SkinType == "" || author.skinType == query.SkinType) &&
+			(query.SkinColor == "" || author.skinColor == query.SkinColor) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	productModels "social_media/internal/product/models"
+	productRepo "social_media/internal/product/repository"
+	"social_media/internal/search/models"
+	"social_media/internal/testutil"
+	"testing"
+	"time"
+)
+
+func TestMemoryIndex(t *testing.T) {
+	testIndex(t, func(t *testing.T, f testutil.Fixtures) Index {
+		db := testutil.NewDatabase(t)
+		testutil.Seed(t, db, f)
+
+		return NewMemoryIndex(productRepo.NewMySQLProductRepository(db), 0)
+	})
+}
+
+func TestMemoryIndex_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewDatabase(t)
+	testutil.Seed(t, db, indexFixtures)
+	products := productRepo.NewMySQLProductRepository(db)
+
+	cached := NewMemoryIndex(products, time.Hour)
+	fresh := NewMemoryIndex(products, 0)
+	query := &models.Query{Text: "toner", Limit: 10}
+	for _, index := range []*MemoryIndex{cached, fresh} {
+		if hits, err := index.Search(ctx, query); err != nil || len(hits) != 0 {
+			t.Fatalf("Search() = %v, %v, want no hits", hits, err)
+		}
+	}
+
+	if err := products.CreateProduct(ctx, &productModels.Product{Name: "Rose Toner", Price: 12}); err != nil {
+		t.Fatal(err)
+	}
+
+	if hits, _ := cached.Search(ctx, query); len(hits) != 0 {
+		t.Errorf("index within its max age found %d new hits", len(hits))
+	}
+	if hits, _ := fresh.Search(ctx, query); len(hits) != 1 {
+		t.Errorf("index rebuilt on every search found %d hits, want 1", len(hits))
+	}
+}
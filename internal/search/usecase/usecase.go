@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"html"
+	memberModels "social_media/internal/member/models"
+	"social_media/internal/search/models"
+	"social_media/internal/search/repository"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	defaultLimit = 20
+	// snippetLength is the length in bytes a highlight is cut to, before
+	// escaping and markup
+	snippetLength = 160
+)
+
+type SearchUsecase struct {
+	Index repository.Index
+}
+
+type SearchUsecaseInterface interface {
+	Search(ctx context.Context, query *models.Query) (*models.SearchResult, error)
+}
+
+func NewSearchUsecase(index repository.Index) *SearchUsecase {
+	return &SearchUsecase{Index: index}
+}
+
+// Search returns the hits of query, best first, each with a highlighted
+// snippet of the text it matched.
+func (u *SearchUsecase) Search(ctx context.Context, query *models.Query) (*models.SearchResult, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "usecase.Search")
+	defer span.Finish()
+
+	result := &models.SearchResult{Query: query.Text, Hits: make([]*models.Hit, 0)}
+
+	terms := models.Terms(query.Text)
+	if len(terms) == 0 {
+		return result, nil
+	}
+
+	normalized := *query
+	if normalized.Limit <= 0 {
+		normalized.Limit = defaultLimit
+	}
+	normalized.Gender, _ = memberModels.Normalize(normalized.Gender, memberModels.Genders)
+	normalized.SkinType, _ = memberModels.Normalize(normalized.SkinType, memberModels.SkinTypes)
+	normalized.SkinColor, _ = memberModels.Normalize(normalized.SkinColor, memberModels.SkinColors)
+
+	hits, err := u.Index.Search(ctx, &normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		hit.Highlight = highlight(hit.Text, terms)
+	}
+	result.Hits = hits
+	return result, nil
+}
+
+// word is a word of a text, by byte offsets
+type word struct {
+	start, end int
+	matched    bool
+}
+
+// highlight cuts a snippet of about snippetLength bytes of text, starting a
+// little before its first word in terms, and wraps the words in terms in
+// <em>. The snippet is HTML escaped and cut at word boundaries, with an
+// ellipsis where text goes on.
+func highlight(text string, terms []string) string {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	var words []word
+	first := -1
+	for start := 0; start < len(text); {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			start += size
+			continue
+		}
+		end := start + strings.IndexFunc(text[start:], func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if end < start {
+			end = len(text)
+		}
+		matched := wanted[strings.ToLower(text[start:end])]
+		if matched && first < 0 {
+			first = len(words)
+		}
+		words = append(words, word{start: start, end: end, matched: matched})
+		start = end
+	}
+
+	// Start a quarter of the snippet before the first match, on a word
+	from := 0
+	if first > 0 {
+		from = first
+		for from > 0 && words[first].start-words[from-1].start <= snippetLength/4 {
+			from--
+		}
+	}
+	// Take at least one word, then as many as fit
+	to := from
+	for to < len(words) && (to == from || words[to].end-words[from].start <= snippetLength) {
+		to++
+	}
+
+	var b strings.Builder
+	start, end := 0, len(text)
+	if from > 0 {
+		start = words[from].start
+		b.WriteString("…")
+	}
+	if to < len(words) {
+		end = words[to-1].end
+	}
+
+	position := start
+	for _, w := range words[from:to] {
+		b.WriteString(html.EscapeString(text[position:w.start]))
+		if w.matched {
+			b.WriteString("<em>" + html.EscapeString(text[w.start:w.end]) + "</em>")
+		} else {
+			b.WriteString(html.EscapeString(text[w.start:w.end]))
+		}
+		position = w.end
+	}
+	b.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
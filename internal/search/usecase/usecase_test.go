@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"social_media/internal/search/models"
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("filler ", 40)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{
+			name:  "wraps every match",
+			text:  "Great serum, my favourite Serum!",
+			terms: []string{"serum"},
+			want:  "Great <em>serum</em>, my favourite <em>Serum</em>!",
+		},
+		{
+			name:  "escapes html",
+			text:  "<b>serum</b> & cream",
+			terms: []string{"serum", "cream"},
+			want:  "&lt;b&gt;<em>serum</em>&lt;/b&gt; &amp; <em>cream</em>",
+		},
+		{
+			name:  "matches whole words only",
+			text:  "Serums are not a serum",
+			terms: []string{"serum"},
+			want:  "Serums are not a <em>serum</em>",
+		},
+		{
+			name:  "cuts around the first match",
+			text:  long + "the serum " + long,
+			terms: []string{"serum"},
+			want: "…" + strings.Repeat("filler ", 5) + "the <em>serum</em> " +
+				strings.TrimSuffix(strings.Repeat("filler ", 16), " ") + "…",
+		},
+		{
+			name:  "keeps text without a match",
+			text:  "Lovely texture",
+			terms: []string{"serum"},
+			want:  "Lovely texture",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.text, tt.terms); got != tt.want {
+				t.Errorf("highlight() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingIndex returns its hits and keeps the last query.
+type recordingIndex struct {
+	query *models.Query
+	hits  []*models.Hit
+}
+
+func (i *recordingIndex) Search(ctx context.Context, query *models.Query) ([]*models.Hit, error) {
+	i.query = query
+	return i.hits, nil
+}
+
+func TestSearch(t *testing.T) {
+	index := &recordingIndex{hits: []*models.Hit{{Kind: models.KindProduct, ProductID: 1, ProductName: "Hydrating Serum", Text: "Hydrating Serum", Score: 1}}}
+	u := NewSearchUsecase(index)
+
+	result, err := u.Search(context.Background(), &models.Query{Text: "serum", Gender: "female", SkinType: "OILY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.query.Limit != defaultLimit || index.query.Gender != "Female" || index.query.SkinType != "Oily" {
+		t.Errorf("index query = %+v, want the default limit and canonical skin profile", index.query)
+	}
+	if len(result.Hits) != 1 || result.Hits[0].Highlight != "Hydrating <em>Serum</em>" {
+		t.Errorf("Search() = %+v", result.Hits)
+	}
+
+	// Text without any word is not sent to the index
+	index.query = nil
+	result, err = u.Search(context.Background(), &models.Query{Text: "?!"})
+	if err != nil || index.query != nil || len(result.Hits) != 0 {
+		t.Errorf("Search() of punctuation = %+v, %v, index query %+v", result, err, index.query)
+	}
+}
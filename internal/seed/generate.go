@@ -0,0 +1,302 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DefaultBatchSize keeps a batch of the widest rows, members with six
+// columns, well under the placeholder limits of MySQL and SQLite.
+const DefaultBatchSize = 1000
+
+// zipfExponent shapes the popularity of products and reviews: a few get most
+// of the reviews and likes, most get almost none.
+const zipfExponent = 1.2
+
+// GenerateOptions sets the volumes of a synthetic dataset. The same Seed
+// always produces the same rows.
+type GenerateOptions struct {
+	Members   int
+	Products  int
+	Reviews   int
+	Likes     int
+	BatchSize int
+	Seed      int64
+	// Progress, when set, is called after every batch
+	Progress func(table string, inserted int, total int)
+}
+
+// GenerateResult counts the rows inserted. Likes can fall short of the
+// requested volume when the most popular reviews run out of members.
+type GenerateResult struct {
+	Members  int
+	Products int
+	Reviews  int
+	Likes    int
+	// HottestProductID has the most reviews, the worst case for the product page
+	HottestProductID int
+}
+
+// weighted picks values in proportion to their weights.
+type weighted struct {
+	values  []string
+	weights []float64
+}
+
+func (w weighted) pick(r *rand.Rand) string {
+	x := r.Float64()
+	for i, weight := range w.weights {
+		if x < weight {
+			return w.values[i]
+		}
+		x -= weight
+	}
+	return w.values[len(w.values)-1]
+}
+
+// Skin profiles follow the rough shares of a skincare review community.
+var (
+	genders    = weighted{[]string{"Female", "Male"}, []float64{0.7, 0.3}}
+	skinTypes  = weighted{[]string{"Combination", "Oily", "Normal", "Dry"}, []float64{0.35, 0.27, 0.2, 0.18}}
+	skinColors = weighted{[]string{"Medium", "Fair", "Dark"}, []float64{0.45, 0.35, 0.2}}
+)
+
+var (
+	productBrands = []string{"Glow", "Derma", "Pure", "Velvet", "Aqua", "Lumi", "Botanic", "Silk", "Nova", "Bare"}
+	productKinds  = []string{"Cleanser", "Toner", "Serum", "Moisturizer", "Sunscreen", "Face Oil", "Eye Cream", "Exfoliant", "Mask", "Essence"}
+	reviewOpeners = []string{"Great product!", "Average product.", "Not satisfied.", "Works well for my skin type.", "Amazing, will repurchase.", "Didn't work for me.", "Impressed with the performance.", "Disappointed."}
+	reviewDetails = []string{"Absorbs quickly.", "A bit greasy.", "Gentle on sensitive skin.", "The scent is too strong.", "Lasts a long time.", "Broke me out after a week.", "Good value for the price.", "Packaging is lovely."}
+)
+
+// Generate inserts a synthetic dataset after the rows already in db. Reviews
+// go to products and likes to reviews following a power law, and a member
+// likes a review at most once.
+func Generate(ctx context.Context, db *gorm.DB, opts GenerateOptions) (*GenerateResult, error) {
+	if opts.Members < 0 || opts.Products < 0 || opts.Reviews < 0 || opts.Likes < 0 {
+		return nil, errors.New("volumes cannot be negative")
+	}
+	if opts.Reviews > 0 && (opts.Members == 0 || opts.Products == 0) {
+		return nil, errors.New("reviews need members and products")
+	}
+	if opts.Likes > 0 && opts.Reviews == 0 {
+		return nil, errors.New("likes need reviews")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	g := &generator{db: db.WithContext(ctx), opts: opts, rand: rand.New(rand.NewSource(opts.Seed))}
+
+	var err error
+	if g.firstMember, err = g.nextID("members", "ID_MEMBER"); err != nil {
+		return nil, err
+	}
+	if g.firstProduct, err = g.nextID("products", "ID_PRODUCT"); err != nil {
+		return nil, err
+	}
+	if g.firstReview, err = g.nextID("review_products", "ID_REVIEW"); err != nil {
+		return nil, err
+	}
+
+	result := &GenerateResult{}
+	for _, step := range []func(*GenerateResult) error{g.members, g.products, g.reviews, g.likes} {
+		if err := step(result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+type generator struct {
+	db   *gorm.DB
+	opts GenerateOptions
+	rand *rand.Rand
+
+	firstMember  int
+	firstProduct int
+	firstReview  int
+}
+
+func (g *generator) members(result *GenerateResult) error {
+	inserted, err := g.insert("members", []string{"ID_MEMBER", "USERNAME", "GENDER", "SKINTYPE", "SKINCOLOR", "VERSION"}, g.opts.Members,
+		func(i int) []interface{} {
+			id := g.firstMember + i
+			return []interface{}{id, fmt.Sprintf("member_%d", id), genders.pick(g.rand), skinTypes.pick(g.rand), skinColors.pick(g.rand), 1}
+		})
+	result.Members = inserted
+	return err
+}
+
+func (g *generator) products(result *GenerateResult) error {
+	inserted, err := g.insert("products", []string{"ID_PRODUCT", "PRODUCT_NAME", "PRICE", "VERSION"}, g.opts.Products,
+		func(i int) []interface{} {
+			id := g.firstProduct + i
+			name := fmt.Sprintf("%s %s %d", productBrands[g.rand.Intn(len(productBrands))], productKinds[g.rand.Intn(len(productKinds))], id)
+			// Prices are log-normal around 20, rounded to .99
+			price := math.Max(1, math.Round(math.Exp(3+0.6*g.rand.NormFloat64()))) - 0.01
+			return []interface{}{id, name, price, 1}
+		})
+	result.Products = inserted
+	return err
+}
+
+func (g *generator) reviews(result *GenerateResult) error {
+	if g.opts.Reviews == 0 {
+		return nil
+	}
+
+	popularity := newPopularity(g.rand, g.opts.Products)
+	perProduct := make([]int32, g.opts.Products)
+
+	inserted, err := g.insert("review_products", []string{"ID_REVIEW", "ID_MEMBER", "ID_PRODUCT", "DESC_REVIEW"}, g.opts.Reviews,
+		func(i int) []interface{} {
+			product := popularity.next()
+			perProduct[product]++
+			description := reviewOpeners[g.rand.Intn(len(reviewOpeners))] + " " + reviewDetails[g.rand.Intn(len(reviewDetails))]
+			return []interface{}{g.firstReview + i, g.firstMember + g.rand.Intn(g.opts.Members), g.firstProduct + product, description}
+		})
+	result.Reviews = inserted
+
+	hottest := 0
+	for product, count := range perProduct {
+		if count > perProduct[hottest] {
+			hottest = product
+		}
+	}
+	result.HottestProductID = g.firstProduct + hottest
+
+	return err
+}
+
+// likes draws the number of likes of every review from the power law, then
+// gives each review distinct members walking the members with a stride
+// coprime to their count.
+func (g *generator) likes(result *GenerateResult) error {
+	if g.opts.Likes == 0 {
+		return nil
+	}
+
+	popularity := newPopularity(g.rand, g.opts.Reviews)
+	perReview := make([]int32, g.opts.Reviews)
+	for i := 0; i < g.opts.Likes; i++ {
+		review := popularity.next()
+		if int(perReview[review]) < g.opts.Members {
+			perReview[review]++
+		}
+	}
+
+	total := 0
+	for _, count := range perReview {
+		total += int(count)
+	}
+
+	review, liked := 0, 0
+	start, stride := 0, 1
+	inserted, err := g.insert("like_reviews", []string{"ID_REVIEW", "ID_MEMBER"}, total,
+		func(int) []interface{} {
+			for liked == int(perReview[review]) {
+				review, liked = review+1, 0
+			}
+			if liked == 0 {
+				start, stride = g.rand.Intn(g.opts.Members), coprimeStride(g.rand, g.opts.Members)
+			}
+			member := (start + liked*stride) % g.opts.Members
+			liked++
+			return []interface{}{g.firstReview + review, g.firstMember + member}
+		})
+	result.Likes = inserted
+	return err
+}
+
+// insert writes count rows built by row in multi-row INSERT statements of
+// BatchSize rows.
+func (g *generator) insert(table string, columns []string, count int, row func(i int) []interface{}) (int, error) {
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	prefix := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES "
+
+	inserted := 0
+	for inserted < count {
+		size := g.opts.BatchSize
+		if count-inserted < size {
+			size = count - inserted
+		}
+
+		values := make([]string, size)
+		args := make([]interface{}, 0, size*len(columns))
+		for i := 0; i < size; i++ {
+			values[i] = placeholders
+			args = append(args, row(inserted+i)...)
+		}
+
+		if err := g.db.Exec(prefix+strings.Join(values, ", "), args...).Error; err != nil {
+			return inserted, fmt.Errorf("insert %s: %w", table, err)
+		}
+		inserted += size
+
+		if g.opts.Progress != nil {
+			g.opts.Progress(table, inserted, count)
+		}
+	}
+	return inserted, nil
+}
+
+// nextID returns the first ID after the rows already in table.
+func (g *generator) nextID(table string, column string) (int, error) {
+	var last sql.NullInt64
+	if err := g.db.Table(table).Select("MAX(" + column + ")").Row().Scan(&last); err != nil {
+		return 0, err
+	}
+	return int(last.Int64) + 1, nil
+}
+
+// popularity draws indexes in [0, n) from a Zipf distribution. Ranks are
+// scattered over the indexes so the popular ones are not all the first IDs.
+type popularity struct {
+	zipf   *rand.Zipf
+	n      int
+	offset int
+	stride int
+}
+
+func newPopularity(r *rand.Rand, n int) *popularity {
+	p := &popularity{n: n, offset: r.Intn(n), stride: coprimeStride(r, n)}
+	if n > 1 {
+		p.zipf = rand.NewZipf(r, zipfExponent, 1, uint64(n-1))
+	}
+	return p
+}
+
+func (p *popularity) next() int {
+	if p.zipf == nil {
+		return 0
+	}
+	return int((uint64(p.offset) + p.zipf.Uint64()*uint64(p.stride)) % uint64(p.n))
+}
+
+// coprimeStride returns a step in [1, n) sharing no factor with n, so
+// multiples of it visit every index below n once.
+func coprimeStride(r *rand.Rand, n int) int {
+	if n <= 2 {
+		return 1
+	}
+	for {
+		stride := 1 + r.Intn(n-1)
+		if gcd(stride, n) == 1 {
+			return stride
+		}
+	}
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,102 @@
+package seed
+
+import (
+	"context"
+	"social_media/internal/testutil"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewDatabase(t)
+	if _, err := InsertSample(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := GenerateOptions{Members: 200, Products: 50, Reviews: 1000, Likes: 5000, BatchSize: 64, Seed: 7}
+	result, err := Generate(ctx, db, opts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if result.Members != 200 || result.Products != 50 || result.Reviews != 1000 {
+		t.Errorf("Generate() = %+v, want the requested volumes", result)
+	}
+	if result.Likes == 0 || result.Likes > opts.Likes {
+		t.Errorf("Generate() inserted %d likes, want at most %d", result.Likes, opts.Likes)
+	}
+
+	count := func(query string, args ...interface{}) int {
+		t.Helper()
+		var n int
+		if err := db.Raw(query, args...).Scan(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if got := count("SELECT COUNT(*) FROM members"); got != len(SampleMembers)+200 {
+		t.Errorf("members = %d, want the sample rows kept and 200 added", got)
+	}
+	if got := count("SELECT COUNT(*) FROM like_reviews"); got != len(SampleLikes)+result.Likes {
+		t.Errorf("like_reviews = %d, want %d", got, len(SampleLikes)+result.Likes)
+	}
+	if got := count("SELECT COUNT(*) FROM (SELECT ID_REVIEW, ID_MEMBER FROM like_reviews GROUP BY ID_REVIEW, ID_MEMBER HAVING COUNT(*) > 1) duplicates"); got != 0 {
+		t.Errorf("%d members like the same review twice", got)
+	}
+
+	// Popularity follows a power law: the hottest product gets far more than
+	// its even share of reviews
+	hottest := count("SELECT COUNT(*) FROM review_products WHERE ID_PRODUCT = ?", result.HottestProductID)
+	if hottest < 4*opts.Reviews/opts.Products {
+		t.Errorf("product %d has %d reviews, want a power law", result.HottestProductID, hottest)
+	}
+	top := count("SELECT SUM(n) FROM (SELECT COUNT(*) AS n FROM review_products WHERE ID_REVIEW > ? GROUP BY ID_PRODUCT ORDER BY n DESC LIMIT 5) top", len(SampleReviews))
+	if top < 4*opts.Reviews/10 {
+		t.Errorf("the top 10%% of products have %d of %d reviews, want a long tail", top, opts.Reviews)
+	}
+
+	women := count("SELECT COUNT(*) FROM members WHERE GENDER = 'Female' AND ID_MEMBER > ?", len(SampleMembers))
+	if women < 100 || women > 180 {
+		t.Errorf("%d of 200 members are women, want about 70%%", women)
+	}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	opts := GenerateOptions{Members: 20, Products: 5, Reviews: 40, Likes: 60, Seed: 3}
+
+	var dumps [2][]string
+	for i := range dumps {
+		db := testutil.NewDatabase(t)
+		if _, err := Generate(ctx, db, opts); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Raw("SELECT ID_REVIEW || '/' || ID_MEMBER FROM like_reviews ORDER BY ID_LIKE").Scan(&dumps[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(dumps[0]) == 0 || len(dumps[0]) != len(dumps[1]) {
+		t.Fatalf("runs inserted %d and %d likes", len(dumps[0]), len(dumps[1]))
+	}
+	for i := range dumps[0] {
+		if dumps[0][i] != dumps[1][i] {
+			t.Fatalf("like %d differs between runs with the same seed: %s and %s", i, dumps[0][i], dumps[1][i])
+		}
+	}
+}
+
+func TestGenerateRejectsOrphans(t *testing.T) {
+	db := testutil.NewDatabase(t)
+
+	for _, opts := range []GenerateOptions{
+		{Reviews: 10, Products: 1},
+		{Reviews: 10, Members: 1},
+		{Likes: 10, Members: 1},
+		{Members: -1},
+	} {
+		if _, err := Generate(context.Background(), db, opts); err == nil {
+			t.Errorf("Generate(%+v) returned no error", opts)
+		}
+	}
+}
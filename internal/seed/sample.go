@@ -0,0 +1,104 @@
+// Package seed fills a database with data for development and demos.
+package seed
+
+import (
+	"context"
+	memberModels "social_media/internal/member/models"
+	productModels "social_media/internal/product/models"
+
+	"gorm.io/gorm"
+)
+
+// The sample rows of config/db/db.sql, shared by the seed command and the
+// in-memory repositories.
+var (
+	SampleMembers = []memberModels.Member{
+		{ID: 1, Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair"},
+		{ID: 2, Username: "User2", Gender: "Female", SkinType: "Combination", SkinColor: "Medium"},
+		{ID: 3, Username: "User3", Gender: "Male", SkinType: "Dry", SkinColor: "Dark"},
+		{ID: 4, Username: "User4", Gender: "Female", SkinType: "Normal", SkinColor: "Fair"},
+		{ID: 5, Username: "User5", Gender: "Male", SkinType: "Oily", SkinColor: "Medium"},
+		{ID: 6, Username: "User6", Gender: "Female", SkinType: "Combination", SkinColor: "Fair"},
+		{ID: 7, Username: "User7", Gender: "Male", SkinType: "Dry", SkinColor: "Dark"},
+		{ID: 8, Username: "User8", Gender: "Female", SkinType: "Oily", SkinColor: "Medium"},
+		{ID: 9, Username: "User9", Gender: "Male", SkinType: "Combination", SkinColor: "Fair"},
+		{ID: 10, Username: "User10", Gender: "Female", SkinType: "Normal", SkinColor: "Dark"},
+	}
+
+	SampleProducts = []productModels.Product{
+		{ID: 1, Name: "Product1", Price: 9.99},
+		{ID: 2, Name: "Product2", Price: 19.99},
+		{ID: 3, Name: "Product3", Price: 14.99},
+		{ID: 4, Name: "Product4", Price: 24.99},
+		{ID: 5, Name: "Product5", Price: 29.99},
+	}
+
+	SampleReviews = []productModels.ReviewData{
+		{ID: 1, MemberID: 1, ProductID: 1, Description: "Great product! Highly recommended."},
+		{ID: 2, MemberID: 2, ProductID: 1, Description: "Average product. Could be better."},
+		{ID: 3, MemberID: 3, ProductID: 2, Description: "Excellent quality and value."},
+		{ID: 4, MemberID: 4, ProductID: 2, Description: "Not satisfied with the product."},
+		{ID: 5, MemberID: 5, ProductID: 3, Description: "Works well for my skin type."},
+		{ID: 6, MemberID: 6, ProductID: 3, Description: "Didn't see any noticeable results."},
+		{ID: 7, MemberID: 7, ProductID: 4, Description: "Amazing product! Will repurchase."},
+		{ID: 8, MemberID: 8, ProductID: 4, Description: "Didn't work for me."},
+		{ID: 9, MemberID: 9, ProductID: 5, Description: "Impressed with the packaging and performance."},
+		{ID: 10, MemberID: 10, ProductID: 5, Description: "Disappointed with the product."},
+	}
+
+	SampleLikes = []productModels.LikeReview{
+		{MemberID: 1, ReviewID: 1},
+		{MemberID: 2, ReviewID: 1},
+		{MemberID: 3, ReviewID: 2},
+		{MemberID: 4, ReviewID: 3},
+		{MemberID: 5, ReviewID: 3},
+		{MemberID: 6, ReviewID: 4},
+		{MemberID: 7, ReviewID: 5},
+		{MemberID: 8, ReviewID: 6},
+		{MemberID: 9, ReviewID: 6},
+		{MemberID: 10, ReviewID: 7},
+	}
+)
+
+// InsertSample inserts the sample rows in one transaction. A database that
+// already has members is left untouched and false is returned.
+func InsertSample(ctx context.Context, db *gorm.DB) (bool, error) {
+	var members int64
+	if err := db.WithContext(ctx).Table("members").Count(&members).Error; err != nil {
+		return false, err
+	}
+	if members > 0 {
+		return false, nil
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, m := range SampleMembers {
+			if err := tx.Exec("INSERT INTO members (ID_MEMBER, USERNAME, GENDER, SKINTYPE, SKINCOLOR, VERSION) VALUES (?, ?, ?, ?, ?, 1)",
+				m.ID, m.Username, m.Gender, m.SkinType, m.SkinColor).Error; err != nil {
+				return err
+			}
+		}
+		for _, p := range SampleProducts {
+			if err := tx.Exec("INSERT INTO products (ID_PRODUCT, PRODUCT_NAME, PRICE, VERSION) VALUES (?, ?, ?, 1)",
+				p.ID, p.Name, p.Price).Error; err != nil {
+				return err
+			}
+		}
+		for _, r := range SampleReviews {
+			if err := tx.Exec("INSERT INTO review_products (ID_REVIEW, ID_MEMBER, ID_PRODUCT, DESC_REVIEW) VALUES (?, ?, ?, ?)",
+				r.ID, r.MemberID, r.ProductID, r.Description).Error; err != nil {
+				return err
+			}
+		}
+		for _, l := range SampleLikes {
+			if err := tx.Exec("INSERT INTO like_reviews (ID_REVIEW, ID_MEMBER) VALUES (?, ?)", l.ReviewID, l.MemberID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
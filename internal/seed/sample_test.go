@@ -0,0 +1,37 @@
+package seed
+
+import (
+	"context"
+	"social_media/internal/testutil"
+	"testing"
+)
+
+func TestInsertSample(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewDatabase(t)
+
+	inserted, err := InsertSample(ctx, db)
+	if err != nil || !inserted {
+		t.Fatalf("InsertSample() = %v, %v, want true", inserted, err)
+	}
+
+	for table, want := range map[string]int{
+		"members":         len(SampleMembers),
+		"products":        len(SampleProducts),
+		"review_products": len(SampleReviews),
+		"like_reviews":    len(SampleLikes),
+	} {
+		var count int64
+		if err := db.Table(table).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if int(count) != want {
+			t.Errorf("%s has %d rows, want %d", table, count, want)
+		}
+	}
+
+	inserted, err = InsertSample(ctx, db)
+	if err != nil || inserted {
+		t.Errorf("InsertSample() into a seeded database = %v, %v, want false", inserted, err)
+	}
+}
@@ -1,13 +1,26 @@
 package server
 
 import (
+	"social_media/config"
+	adminHttp "social_media/internal/admin/delivery/http"
+	adminRepo "social_media/internal/admin/repository"
+	adminUsecase "social_media/internal/admin/usecase"
+	bulkHttp "social_media/internal/bulk/delivery/http"
+	bulkUsecase "social_media/internal/bulk/usecase"
 	memberHttp "social_media/internal/member/delivery/http"
 	memberRepo "social_media/internal/member/repository"
 	memberUsecase "social_media/internal/member/usecase"
 	"social_media/internal/middleware"
+	privacyHttp "social_media/internal/privacy/delivery/http"
+	privacyRepo "social_media/internal/privacy/repository"
+	privacyUsecase "social_media/internal/privacy/usecase"
 	productHttp "social_media/internal/product/delivery/http"
 	productRepo "social_media/internal/product/repository"
 	productUsecase "social_media/internal/product/usecase"
+	searchHttp "social_media/internal/search/delivery/http"
+	searchRepo "social_media/internal/search/repository"
+	searchUsecase "social_media/internal/search/usecase"
+	"social_media/pkg/cache"
 
 	docs "social_media/docs"
 
@@ -25,19 +38,97 @@ func (s *Server) MapHandlers(e *echo.Echo) error {
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
-	mw := middleware.NewMiddlewareManager(s.cfg, []string{"*"}, s.logger)
-	apiGroup := e.Group("/api/v1", mw.RequestLoggerMiddleware)
+	origins := s.cfg.Server.AllowOrigins
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+
+	mw := middleware.NewMiddlewareManager(s.cfg, origins, s.logger)
+
+	e.Pre(mw.RequestIDMiddleware)
+	e.Use(mw.CORS(), mw.SecurityHeaders())
+	if s.cfg.Server.CSRF {
+		e.Use(mw.CSRF())
+	}
+
+	apiGroup := e.Group("/api/v1", mw.RequestContextMiddleware, mw.RequestLoggerMiddleware, mw.RateLimitMiddleware, mw.IdempotencyMiddleware)
 
 	memberGroup := apiGroup.Group("/members")
 	productsGroup := apiGroup.Group("/products")
+	// Admin routes delete, restore and export everyone's data, they are
+	// only served behind admin keys
+	var adminGroup *echo.Group
+	if s.cfg.AdminAuthEnabled() {
+		adminUC := adminUsecase.NewAdminUsecase(adminRepo.NewAdminRepository(s.db))
+		adminGroup = apiGroup.Group("/admin", mw.AdminAuth(adminUC))
+	} else {
+		s.logger.Warn("server.AdminAuth is disabled, /admin routes are not served")
+	}
 
-	memberRepo := memberRepo.NewMemberRepository(s.db)
-	memberUC := memberUsecase.NewMemberUsecase(memberRepo)
+	var (
+		memberRepository  memberRepo.MemberRepository
+		productRepository productRepo.ProductRepository
+		exportStore       privacyRepo.ExportStore
+	)
+	if s.cfg.MySQL.Driver == config.DriverMemory {
+		memberRepository, productRepository = newMemoryRepositories()
+		exportStore = privacyRepo.NewMemoryExportStore()
+	} else {
+		memberRepository = memberRepo.NewMemberRepository(s.db)
+		productRepository = productRepo.NewMySQLProductRepository(s.db)
+		exportStore = privacyRepo.NewMySQLExportStore(s.db)
+	}
 
-	productRepo := productRepo.NewMySQLProductRepository(s.db)
-	productUC := productUsecase.NewProductUsecase(productRepo)
+	searchIndex := s.cfg.Search.Index
+	if searchIndex == "" {
+		searchIndex = config.SearchIndexFullText
+		if s.cfg.MySQL.Driver == config.DriverMemory {
+			searchIndex = config.SearchIndexMemory
+		}
+	}
+	var index searchRepo.Index
+	if searchIndex == config.SearchIndexMemory {
+		index = searchRepo.NewMemoryIndex(productRepository, s.cfg.Search.RefreshInterval)
+	} else {
+		index = searchRepo.NewFullTextIndex(s.db)
+	}
+
+	// The search index reads past the cache, a rebuild would only churn it
+	var memberPages productRepo.MemberPageCache
+	if s.cfg.ProductCache.Enabled {
+		// Each product takes an entry for itself and one for its page
+		backend := cache.NewLRU(2 * s.cfg.ProductCache.Size)
+		cached := productRepo.NewCachedProductRepository(productRepository, backend, s.cfg.ProductCache.TTL)
+		productRepository, memberPages = cached, cached
+	}
+
+	memberUC := memberUsecase.NewMemberUsecase(memberRepository)
+	productUC := productUsecase.NewProductUsecase(productRepository)
+	searchUC := searchUsecase.NewSearchUsecase(index)
+	bulkUC := bulkUsecase.NewBulkUsecase(memberRepository, productRepository)
+	if memberPages != nil {
+		memberUC.WithProductCache(memberPages)
+		bulkUC.WithProductCache(memberPages)
+	}
+	privacyUC := privacyUsecase.NewPrivacyUsecase(memberRepository, productRepository, exportStore, s.logger, privacyUsecase.ExportOptions{
+		SyncLimit: s.cfg.DataExport.SyncLimit,
+		Workers:   s.cfg.DataExport.Workers,
+		Timeout:   s.cfg.DataExport.Timeout,
+		TTL:       s.cfg.DataExport.TTL,
+	})
 
 	memberHttp.MapMemberRoute(memberGroup, s.logger, memberUC)
 	productHttp.MapProductRoutes(productsGroup, s.logger, productUC)
+	searchHttp.MapSearchRoutes(apiGroup, s.logger, searchUC)
+	if adminGroup != nil {
+		memberHttp.MapMemberAdminRoute(adminGroup, s.logger, memberUC)
+		productHttp.MapProductAdminRoutes(adminGroup, s.logger, productUC)
+		bulkHttp.MapBulkAdminRoutes(adminGroup, s.logger, bulkUC)
+		privacyHttp.MapPrivacyAdminRoutes(adminGroup, s.logger, privacyUC)
+		adminHttp.MapAdminRoutes(adminGroup, s.logger)
+	}
+
+	s.purgers = []purger{memberUC.PurgeDeletedMembers, productUC.PurgeDeleted}
+	s.drainers = []drainer{privacyUC.Shutdown}
 	return nil
 }
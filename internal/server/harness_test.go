@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"social_media/config"
+	adminRepo "social_media/internal/admin/repository"
+	adminUsecase "social_media/internal/admin/usecase"
+	memberModels "social_media/internal/member/models"
+	productModels "social_media/internal/product/models"
+	"social_media/internal/testutil"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// harnessFixtures has two products. Review 1 is liked by members 2 and 3,
+// review 2 by member 1 and review 3 by nobody.
+var harnessFixtures = testutil.Fixtures{
+	Members: []memberModels.Member{
+		{ID: 1, Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair"},
+		{ID: 2, Username: "User2", Gender: "Female", SkinType: "Combination", SkinColor: "Medium"},
+		{ID: 3, Username: "User3", Gender: "Male", SkinType: "Dry", SkinColor: "Dark"},
+	},
+	Products: []productModels.Product{
+		{ID: 1, Name: "Product1", Price: 9.99},
+		{ID: 2, Name: "Product2", Price: 19.99},
+	},
+	Reviews: []productModels.ReviewData{
+		{ID: 1, MemberID: 1, ProductID: 1, Description: "Great product! Highly recommended."},
+		{ID: 2, MemberID: 2, ProductID: 1, Description: "Average product. Could be better."},
+		{ID: 3, MemberID: 3, ProductID: 2, Description: "Excellent quality and value."},
+	},
+	Likes: []productModels.LikeReview{
+		{ReviewID: 1, MemberID: 2},
+		{ReviewID: 1, MemberID: 3},
+		{ReviewID: 2, MemberID: 1},
+	},
+}
+
+// harness is a Server with every route mapped, backed by a seeded embedded
+// database and reached over real HTTP.
+type harness struct {
+	t       *testing.T
+	db      *gorm.DB
+	echo    *echo.Echo
+	url     string
+	logFile string
+	// adminKey is sent on /admin requests that set no Authorization
+	adminKey string
+}
+
+// newHarness starts a server, configure functions adjust its config first.
+func newHarness(t *testing.T, configure ...func(*config.Config)) *harness {
+	t.Helper()
+
+	db := testutil.NewDatabase(t)
+	testutil.Seed(t, db, harnessFixtures)
+
+	logFile := filepath.Join(t.TempDir(), "application.log")
+
+	cfg := &config.Config{}
+	cfg.Server.QueryTimeout = 5 * time.Second
+	cfg.Logger.Level = "debug"
+	cfg.Logger.Encoding = "json"
+	cfg.Logger.Outputs = []string{zap.OutputFile}
+	cfg.Logger.File = logFile
+	cfg.RequestLogger.RedactFields = []string{"username"}
+	// SQLite has no FULLTEXT indexes
+	cfg.Search.Index = config.SearchIndexMemory
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	logger := zap.NewAppLogger(cfg)
+	logger.InitLogger()
+
+	s := NewServer(cfg, logger, db)
+	if err := s.MapHandlers(s.echo); err != nil {
+		t.Fatalf("MapHandlers() error = %v", err)
+	}
+
+	server := httptest.NewServer(s.echo)
+	t.Cleanup(server.Close)
+
+	h := &harness{t: t, db: db, echo: s.echo, url: server.URL, logFile: logFile}
+	if cfg.AdminAuthEnabled() {
+		var err error
+		_, h.adminKey, err = adminUsecase.NewAdminUsecase(adminRepo.NewAdminRepository(db)).CreateAdmin(context.Background(), "harness")
+		if err != nil {
+			t.Fatalf("CreateAdmin() error = %v", err)
+		}
+	}
+	return h
+}
+
+// response is an HTTP response with its utils.Response envelope decoded
+// when the body is JSON.
+type response struct {
+	*http.Response
+	body     []byte
+	envelope utils.Response
+}
+
+// data decodes the envelope's data field into v.
+func (r *response) data(t *testing.T, v interface{}) {
+	t.Helper()
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(r.body, &envelope); err != nil {
+		t.Fatalf("decode data: %v, body %s", err, r.body)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		t.Fatalf("decode data: %v, body %s", err, r.body)
+	}
+}
+
+// do sends a request under /api/v1. A body is sent as JSON unless headers
+// set another Content-Type.
+func (h *harness) do(method, path, body string, headers map[string]string) *response {
+	h.t.Helper()
+
+	req, err := http.NewRequest(method, h.url+"/api/v1"+path, strings.NewReader(body))
+	if err != nil {
+		h.t.Fatalf("new request: %v", err)
+	}
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	if _, ok := headers[echo.HeaderAuthorization]; !ok && h.adminKey != "" && strings.HasPrefix(path, "/admin/") {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+h.adminKey)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer res.Body.Close()
+
+	result := &response{Response: res}
+	result.body, err = ioutil.ReadAll(res.Body)
+	if err != nil {
+		h.t.Fatalf("%s %s: read body: %v", method, path, err)
+	}
+	if len(result.body) > 0 && result.isJSON() {
+		if err := json.Unmarshal(result.body, &result.envelope); err != nil {
+			h.t.Fatalf("%s %s: body is not a utils.Response: %v, body %s", method, path, err, result.body)
+		}
+	}
+
+	return result
+}
+
+// isJSON reports whether the body is a utils.Response. Downloads, such as
+// exports, are sent as files instead.
+func (r *response) isJSON() bool {
+	return strings.HasPrefix(r.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) &&
+		r.Header.Get(echo.HeaderContentDisposition) == ""
+}
+
+// route returns the route path that serves a request, e.g. "/api/v1/members/:id".
+func (h *harness) route(method, path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	c := h.echo.NewContext(nil, nil)
+	h.echo.Router().Find(method, "/api/v1"+path, c)
+	return c.Path()
+}
+
+// logLines returns the JSON lines written to the log file so far.
+func (h *harness) logLines() []map[string]interface{} {
+	h.t.Helper()
+
+	content, err := ioutil.ReadFile(h.logFile)
+	if err != nil {
+		h.t.Fatalf("read log: %v", err)
+	}
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			h.t.Fatalf("log line is not JSON: %v, line %s", err, line)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	memberModels "social_media/internal/member/models"
+	memberRepo "social_media/internal/member/repository"
+	productRepo "social_media/internal/product/repository"
+	"social_media/internal/seed"
+)
+
+// newMemoryRepositories returns in-memory repositories holding the sample
+// rows of config/db/db.sql, used when MySQL.Driver is "memory".
+func newMemoryRepositories() (*memberRepo.MemoryRepository, *productRepo.MemoryProductRepository) {
+	sampleMembers := make([]*memberModels.Member, len(seed.SampleMembers))
+	for i := range seed.SampleMembers {
+		sampleMembers[i] = &seed.SampleMembers[i]
+	}
+	members := memberRepo.NewMemoryMemberRepository(sampleMembers...)
+
+	products := productRepo.NewMemoryProductRepository(members)
+	for i := range seed.SampleProducts {
+		products.AddProduct(&seed.SampleProducts[i])
+	}
+	for i := range seed.SampleReviews {
+		products.AddReview(&seed.SampleReviews[i])
+	}
+	for _, like := range seed.SampleLikes {
+		products.AddLike(like)
+	}
+
+	return members, products
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultPurgeInterval = 24 * time.Hour
+)
+
+// purger permanently removes rows soft deleted before the given time.
+type purger func(ctx context.Context, before time.Time) (int64, error)
+
+// runPurge removes soft deleted rows older than the configured retention
+// window every PurgeInterval until ctx is cancelled.
+func (s *Server) runPurge(ctx context.Context) {
+	retention := s.cfg.SoftDelete.Retention
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(durationWithDefault(s.cfg.SoftDelete.PurgeInterval, defaultPurgeInterval))
+	defer ticker.Stop()
+
+	for {
+		s.purge(ctx, time.Now().Add(-retention))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) purge(ctx context.Context, before time.Time) {
+	for _, purge := range s.purgers {
+		purged, err := purge(ctx, before)
+		if err != nil {
+			s.logger.Errorf("Error purging soft deleted rows: %v", err)
+			continue
+		}
+		if purged > 0 {
+			s.logger.Infof("Purged %d soft deleted rows older than %s", purged, before.Format(time.RFC3339))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"social_media/config"
+)
+
+// recordingPurger records the cutoffs it is called with.
+type recordingPurger struct {
+	mu      sync.Mutex
+	cutoffs []time.Time
+	err     error
+}
+
+func (p *recordingPurger) purge(ctx context.Context, before time.Time) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cutoffs = append(p.cutoffs, before)
+	return 1, p.err
+}
+
+func (p *recordingPurger) calls() []time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]time.Time(nil), p.cutoffs...)
+}
+
+func TestServerRunPurge(t *testing.T) {
+	t.Run("does nothing without a retention window", func(t *testing.T) {
+		s := newUnstartedServer(t, func(cfg *config.Config) {})
+		recorder := &recordingPurger{}
+		s.purgers = []purger{recorder.purge}
+
+		done := make(chan struct{})
+		go func() {
+			s.runPurge(context.Background())
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("runPurge() kept running without a retention window")
+		}
+		if calls := recorder.calls(); len(calls) != 0 {
+			t.Errorf("purged %d times, want none", len(calls))
+		}
+	})
+
+	t.Run("purges rows older than the retention window until cancelled", func(t *testing.T) {
+		s := newUnstartedServer(t, func(cfg *config.Config) {
+			cfg.SoftDelete.Retention = time.Hour
+			cfg.SoftDelete.PurgeInterval = 10 * time.Millisecond
+		})
+		failing := &recordingPurger{err: errors.New("purge failed")}
+		recorder := &recordingPurger{}
+		s.purgers = []purger{failing.purge, recorder.purge}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		start := time.Now()
+		go func() {
+			s.runPurge(ctx)
+			close(done)
+		}()
+
+		deadline := time.Now().Add(time.Second)
+		for len(recorder.calls()) < 2 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("runPurge() did not stop when cancelled")
+		}
+
+		calls := recorder.calls()
+		if len(calls) < 2 {
+			t.Fatalf("purged %d times, want a purge every interval after a failing purger", len(calls))
+		}
+		if cutoff := calls[0]; cutoff.After(start.Add(-time.Hour+time.Second)) || cutoff.Before(start.Add(-time.Hour-time.Second)) {
+			t.Errorf("first cutoff = %v, want an hour before %v", cutoff, start)
+		}
+	})
+}
@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,6 +11,7 @@ import (
 	"time"
 
 	"social_media/config"
+	"social_media/pkg/utils"
 	"social_media/pkg/zap"
 
 	"github.com/labstack/echo/v4"
@@ -18,53 +21,164 @@ import (
 
 const (
 	MaxHeaderBytes = 1 << 20
+
+	defaultPort            = ":8080"
+	defaultReadTimeout     = 30 * time.Second
+	defaultWriteTimeout    = 15 * time.Second
+	defaultShutdownTimeout = 30 * time.Second
 )
 
 type Server struct {
-	cfg    *config.Config
-	logger zap.Logger
-	db     *gorm.DB
-	echo   *echo.Echo
+	cfg      *config.Config
+	logger   zap.Logger
+	db       *gorm.DB
+	echo     *echo.Echo
+	purgers  []purger
+	drainers []drainer
 }
 
+// drainer waits for background work started by requests, until ctx is done.
+type drainer func(ctx context.Context) error
+
 func NewServer(cfg *config.Config, logger zap.Logger, db *gorm.DB) *Server {
-	return &Server{cfg: cfg, logger: logger, db: db, echo: echo.New()}
+	e := echo.New()
+	e.Validator = utils.NewValidator()
+	e.IPExtractor = ipExtractor(cfg.Server.TrustedProxies)
+
+	return &Server{cfg: cfg, logger: logger, db: db, echo: e}
+}
+
+// ipExtractor finds the client IP for c.RealIP(). Forwarding headers are
+// set by anyone, so X-Forwarded-For is only read behind trusted proxies and
+// the connection's address is used otherwise.
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		if _, ipRange, err := net.ParseCIDR(proxy); err == nil {
+			options = append(options, echo.TrustIPRange(ipRange))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
 }
 
 func (s *Server) Run() error {
 
+	if s.cfg.Server.SSL && (s.cfg.Server.CertFile == "" || s.cfg.Server.KeyFile == "") {
+		return errors.New("server.SSL is enabled but CertFile or KeyFile is not set")
+	}
+
 	s.echo.HideBanner = true
 	s.echo.HidePort = true
 
 	s.echo.Use(middleware.Logger())
 	s.echo.Use(middleware.Recover())
 
+	// Routes must be in place before the listener starts accepting.
+	if err := s.MapHandlers(s.echo); err != nil {
+		return err
+	}
+
 	server := &http.Server{
 		Handler:        s.echo,
-		Addr:           "localhost:8080",
-		ReadTimeout:    time.Second * 30,
-		WriteTimeout:   time.Second * 15,
+		Addr:           withDefault(s.cfg.Server.Port, defaultPort),
+		ReadTimeout:    durationWithDefault(s.cfg.Server.ReadTimeout, defaultReadTimeout),
+		WriteTimeout:   durationWithDefault(s.cfg.Server.WriteTimeout, defaultWriteTimeout),
 		MaxHeaderBytes: MaxHeaderBytes,
 	}
 
+	var redirectServer *http.Server
+	if s.cfg.Server.SSL && s.cfg.Server.RedirectHTTP != "" {
+		redirectServer = &http.Server{
+			Handler:        http.HandlerFunc(s.redirectToHTTPS),
+			Addr:           s.cfg.Server.RedirectHTTP,
+			ReadTimeout:    server.ReadTimeout,
+			WriteTimeout:   server.WriteTimeout,
+			MaxHeaderBytes: MaxHeaderBytes,
+		}
+	}
+
+	serverErr := make(chan error, 2)
+
 	go func() {
-		s.logger.Infof("Server is listening on PORT: 8080")
-		if err := server.ListenAndServe(); err != nil {
-			s.logger.Fatalf("Error starting server: %v", err)
+		if s.cfg.Server.SSL {
+			s.logger.Infof("Server is listening on PORT: %s (TLS)", server.Addr)
+			serverErr <- server.ListenAndServeTLS(s.cfg.Server.CertFile, s.cfg.Server.KeyFile)
+			return
 		}
+		s.logger.Infof("Server is listening on PORT: %s", server.Addr)
+		serverErr <- server.ListenAndServe()
 	}()
 
-	if err := s.MapHandlers(s.echo); err != nil {
-		return err
+	if redirectServer != nil {
+		go func() {
+			s.logger.Infof("Redirecting HTTP on PORT: %s to HTTPS", redirectServer.Addr)
+			serverErr <- redirectServer.ListenAndServe()
+		}()
 	}
 
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go s.runPurge(purgeCtx)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
-	<-quit
+	select {
+	case <-quit:
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Errorf("Error starting server: %v", err)
+			return err
+		}
+	}
 
-	ctx, shutdown := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, shutdown := context.WithTimeout(context.Background(), durationWithDefault(s.cfg.Server.ShutdownTimeout, defaultShutdownTimeout))
 	defer shutdown()
 
-	return server.Shutdown(ctx)
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(ctx); err != nil {
+			s.logger.Errorf("Error shutting down redirect server: %v", err)
+		}
+	}
+
+	err := server.Shutdown(ctx)
+	// Requests are over, background work they started can finish
+	for _, drain := range s.drainers {
+		if err := drain(ctx); err != nil {
+			s.logger.Errorf("Error draining background work: %v", err)
+		}
+	}
+	return err
+}
+
+func (s *Server) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+
+	_, port, err := net.SplitHostPort(withDefault(s.cfg.Server.Port, defaultPort))
+	if err == nil && port != "" && port != "443" {
+		host = net.JoinHostPort(host, port)
+	}
+
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
+
+func withDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func durationWithDefault(value, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return value
 }
@@ -0,0 +1,1054 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"social_media/config"
+	adminRepo "social_media/internal/admin/repository"
+	adminUsecase "social_media/internal/admin/usecase"
+	bulkModels "social_media/internal/bulk/models"
+	memberModels "social_media/internal/member/models"
+	"social_media/internal/middleware"
+	privacyModels "social_media/internal/privacy/models"
+	productModels "social_media/internal/product/models"
+	searchModels "social_media/internal/search/models"
+	"social_media/internal/testutil"
+	"social_media/pkg/utils"
+	"social_media/pkg/zap"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// step is one request of a scenario and what its response must hold.
+type step struct {
+	method  string
+	path    string
+	body    string
+	headers map[string]string
+	status  int
+	// errorCode is the expected Response.ErrorCode, empty on success
+	errorCode string
+	check     func(t *testing.T, res *response)
+}
+
+func ifMatch(etag string) map[string]string {
+	return map[string]string{utils.HeaderIfMatch: etag}
+}
+
+func wantHeader(name, value string) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		if got := res.Header.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func wantMember(want memberModels.Member) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		var got memberModels.Member
+		res.data(t, &got)
+		if got != want {
+			t.Errorf("member = %+v, want %+v", got, want)
+		}
+	}
+}
+
+// wantLikeCounts checks the reviews on a product page and their likes.
+func wantLikeCounts(want map[int]int) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		var page struct {
+			Reviews []productModels.ReviewData `json:"review"`
+		}
+		res.data(t, &page)
+
+		got := make(map[int]int, len(page.Reviews))
+		for _, review := range page.Reviews {
+			got[review.ID] = review.LikeCount
+		}
+		if len(got) != len(want) {
+			t.Fatalf("like counts = %v, want %v", got, want)
+		}
+		for id, count := range want {
+			if got[id] != count {
+				t.Fatalf("like counts = %v, want %v", got, want)
+			}
+		}
+	}
+}
+
+// wantReviewAuthors checks the username shown on reviews of a product page.
+func wantReviewAuthors(want map[int]string) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		var page struct {
+			Reviews []productModels.ReviewData `json:"review"`
+		}
+		res.data(t, &page)
+
+		for _, review := range page.Reviews {
+			if username, ok := want[review.ID]; ok && review.Username != username {
+				t.Errorf("review %d by %q, want %q", review.ID, review.Username, username)
+			}
+		}
+	}
+}
+
+// wantErasures checks the modes of a member's erasure audit trail.
+func wantErasures(modes ...string) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		var erasures []memberModels.Erasure
+		res.data(t, &erasures)
+
+		got := make([]string, len(erasures))
+		for i, erasure := range erasures {
+			got[i] = erasure.Mode
+		}
+		if fmt.Sprint(got) != fmt.Sprint(modes) {
+			t.Errorf("erasure modes = %v, want %v", got, modes)
+		}
+	}
+}
+
+// wantSearchHits checks the review IDs of the hits of a search, in order,
+// and that every highlight marks a match.
+func wantSearchHits(reviewIDs ...int) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		var result searchModels.SearchResult
+		res.data(t, &result)
+
+		got := make([]int, len(result.Hits))
+		for i, hit := range result.Hits {
+			got[i] = hit.ReviewID
+			if !strings.Contains(hit.Highlight, "<em>") {
+				t.Errorf("highlight %q marks no match", hit.Highlight)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(reviewIDs) {
+			t.Errorf("hits on reviews %v, want %v", got, reviewIDs)
+		}
+	}
+}
+
+// wantImportReport checks the counts of an import report.
+func wantImportReport(want bulkModels.ImportReport) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		var got bulkModels.ImportReport
+		res.data(t, &got)
+		if got.Rows != want.Rows || got.Created != want.Created || got.Updated != want.Updated ||
+			got.Unchanged != want.Unchanged || got.Failed != want.Failed {
+			t.Errorf("report = %+v, want counts %+v", got, want)
+		}
+	}
+}
+
+func wantBody(want string) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		if string(res.body) != want {
+			t.Errorf("body = %q, want %q", res.body, want)
+		}
+	}
+}
+
+// memberImportCSV creates User4, changes the skin type of User2 and has an
+// invalid gender on its last row.
+const memberImportCSV = "username,gender,skinType,skinColor\n" +
+	"User4,female,NORMAL,Fair\n" +
+	"User2,Female,Oily,Medium\n" +
+	"User5,robot,Dry,Dark\n"
+
+// productImportNDJSON reprices Product1 and creates Product3.
+const productImportNDJSON = `{"id":1,"productName":"Product1","price":12.5}` + "\n" +
+	`{"productName":"Product3","price":5}` + "\n"
+
+// wantArchive checks the member, review IDs and liked review IDs of a data
+// export and that it may not be cached.
+func wantArchive(username string, reviewIDs []int, likedReviewIDs []int) func(t *testing.T, res *response) {
+	return func(t *testing.T, res *response) {
+		t.Helper()
+
+		if got := res.Header.Get("Cache-Control"); got != "no-store" {
+			t.Errorf("Cache-Control = %q, want no-store", got)
+		}
+
+		var archive struct {
+			Data struct {
+				Profile memberModels.Member          `json:"profile"`
+				Reviews []productModels.MemberReview `json:"reviews"`
+				Likes   []productModels.MemberLike   `json:"likes"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(res.body, &archive); err != nil {
+			t.Fatalf("decode archive: %v, body %s", err, res.body)
+		}
+
+		var reviews, likes []int
+		for _, review := range archive.Data.Reviews {
+			reviews = append(reviews, review.ID)
+		}
+		for _, like := range archive.Data.Likes {
+			likes = append(likes, like.ReviewID)
+		}
+		if archive.Data.Profile.Username != username || fmt.Sprint(reviews) != fmt.Sprint(reviewIDs) || fmt.Sprint(likes) != fmt.Sprint(likedReviewIDs) {
+			t.Errorf("archive of %s reviews %v likes %v, want %s reviews %v likes %v",
+				archive.Data.Profile.Username, reviews, likes, username, reviewIDs, likedReviewIDs)
+		}
+	}
+}
+
+const newMemberBody = `{"username":"User4","gender":"female","skinType":"NORMAL","skinColor":"Fair"}`
+
+var routeScenarios = []struct {
+	name  string
+	steps []step
+}{
+	{
+		name: "list members",
+		steps: []step{{
+			method: http.MethodGet, path: "/members/all", status: http.StatusOK,
+			check: func(t *testing.T, res *response) {
+				var members []memberModels.Member
+				res.data(t, &members)
+				if len(members) != 3 {
+					t.Errorf("got %d members, want 3", len(members))
+				}
+			},
+		}},
+	},
+	{
+		name: "list member attributes",
+		steps: []step{{
+			method: http.MethodGet, path: "/members/attributes", status: http.StatusOK,
+			check: func(t *testing.T, res *response) {
+				var attributes memberModels.MemberAttributes
+				res.data(t, &attributes)
+				if len(attributes.SkinType) != len(memberModels.SkinTypes) {
+					t.Errorf("skin types = %v, want %v", attributes.SkinType, memberModels.SkinTypes)
+				}
+			},
+		}},
+	},
+	{
+		name: "get member with conditional request",
+		steps: []step{
+			{
+				method: http.MethodGet, path: "/members/1", status: http.StatusOK,
+				check: wantMember(memberModels.Member{ID: 1, Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair", Version: 1}),
+			},
+			{
+				method: http.MethodGet, path: "/members/1", headers: map[string]string{utils.HeaderIfNoneMatch: `"1"`},
+				status: http.StatusNotModified, check: wantHeader(utils.HeaderETag, `"1"`),
+			},
+		},
+	},
+	{
+		name: "get member errors",
+		steps: []step{
+			{method: http.MethodGet, path: "/members/42", status: http.StatusNotFound, errorCode: utils.MemberNotFoundCode},
+			{method: http.MethodGet, path: "/members/abc", status: http.StatusBadRequest, errorCode: utils.InvalidParameterCode},
+			{method: http.MethodGet, path: "/members/0", status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+		},
+	},
+	{
+		name: "add member normalizes attributes",
+		steps: []step{
+			{method: http.MethodPost, path: "/members/", body: newMemberBody, status: http.StatusOK},
+			{
+				method: http.MethodGet, path: "/members/4", status: http.StatusOK,
+				check: wantMember(memberModels.Member{ID: 4, Username: "User4", Gender: "Female", SkinType: "Normal", SkinColor: "Fair", Version: 1}),
+			},
+		},
+	},
+	{
+		name: "add member errors",
+		steps: []step{
+			{
+				method: http.MethodPost, path: "/members/", body: `{"username":"User1","gender":"Male","skinType":"Oily","skinColor":"Fair"}`,
+				status: http.StatusConflict, errorCode: utils.UsernameTakenCode,
+			},
+			{
+				method: http.MethodPost, path: "/members/", body: `{"username":"User9","gender":"other"}`,
+				status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode,
+				check: func(t *testing.T, res *response) {
+					var envelope struct {
+						Errors utils.ValidationErrors `json:"errors"`
+					}
+					if err := json.Unmarshal(res.body, &envelope); err != nil || len(envelope.Errors) != 3 {
+						t.Errorf("errors = %+v, want gender, skinType and skinColor", envelope.Errors)
+					}
+				},
+			},
+		},
+	},
+	{
+		name: "replace member",
+		steps: []step{
+			{
+				method: http.MethodPut, path: "/members/1", body: newMemberBody, headers: ifMatch(`"1"`), status: http.StatusOK,
+				check: wantMember(memberModels.Member{ID: 1, Username: "User4", Gender: "Female", SkinType: "Normal", SkinColor: "Fair", Version: 2}),
+			},
+			{method: http.MethodPut, path: "/members/1", body: newMemberBody, headers: ifMatch(`"1"`), status: http.StatusPreconditionFailed, errorCode: utils.VersionMismatchCode},
+			{method: http.MethodPut, path: "/members/1", body: newMemberBody, status: http.StatusPreconditionRequired, errorCode: utils.PreconditionRequiredCode},
+			{method: http.MethodPut, path: "/members/42", body: newMemberBody, headers: ifMatch(`"1"`), status: http.StatusNotFound, errorCode: utils.MemberNotFoundCode},
+		},
+	},
+	{
+		name: "patch member",
+		steps: []step{
+			{
+				method: http.MethodPatch, path: "/members/2", body: `{"skinType":"dry"}`,
+				headers: map[string]string{utils.HeaderIfMatch: `"1"`, "Content-Type": utils.MIMEApplicationMergePatchJSON},
+				status:  http.StatusOK,
+				check:   wantMember(memberModels.Member{ID: 2, Username: "User2", Gender: "Female", SkinType: "Dry", SkinColor: "Medium", Version: 2}),
+			},
+			{
+				method: http.MethodPatch, path: "/members/2", body: `{"username":null}`, headers: ifMatch(`"2"`),
+				status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode,
+			},
+		},
+	},
+	{
+		name: "delete and restore member",
+		steps: []step{
+			{method: http.MethodDelete, path: "/members/1", headers: ifMatch(`"2"`), status: http.StatusPreconditionFailed, errorCode: utils.VersionMismatchCode},
+			{method: http.MethodDelete, path: "/members/1", headers: ifMatch(`"1"`), status: http.StatusOK},
+			{method: http.MethodGet, path: "/members/1", status: http.StatusNotFound, errorCode: utils.MemberNotFoundCode},
+			{method: http.MethodGet, path: "/products/1", status: http.StatusOK, check: wantLikeCounts(map[int]int{2: 0})},
+			{
+				method: http.MethodPost, path: "/admin/members/1/restore", status: http.StatusOK,
+				check: wantMember(memberModels.Member{ID: 1, Username: "User1", Gender: "Male", SkinType: "Oily", SkinColor: "Fair", Version: 2}),
+			},
+			{method: http.MethodPost, path: "/admin/members/42/restore", status: http.StatusNotFound, errorCode: utils.MemberNotFoundCode},
+		},
+	},
+	{
+		name: "anonymize member",
+		steps: []step{
+			{method: http.MethodDelete, path: "/members/1?mode=wipe", headers: ifMatch(`"1"`), status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+			{method: http.MethodDelete, path: "/members/1?mode=anonymize", headers: ifMatch(`"1"`), status: http.StatusOK},
+			{method: http.MethodGet, path: "/members/1", status: http.StatusNotFound, errorCode: utils.MemberNotFoundCode},
+			{
+				method: http.MethodGet, path: "/products/1", status: http.StatusOK,
+				check: func(t *testing.T, res *response) {
+					wantLikeCounts(map[int]int{1: 2, 2: 1})(t, res)
+					wantReviewAuthors(map[int]string{1: memberModels.FormerMemberUsername, 2: "User2"})(t, res)
+				},
+			},
+			{method: http.MethodPost, path: "/admin/members/1/restore", status: http.StatusConflict, errorCode: utils.MemberAnonymizedCode},
+			{method: http.MethodGet, path: "/admin/members/1/erasures", status: http.StatusOK, check: wantErasures(memberModels.ErasureAnonymize)},
+			{method: http.MethodGet, path: "/admin/members/2/erasures", status: http.StatusOK, check: wantErasures()},
+		},
+	},
+	{
+		name: "get product page",
+		steps: []step{
+			{
+				method: http.MethodGet, path: "/products/1", status: http.StatusOK,
+				check: func(t *testing.T, res *response) {
+					wantLikeCounts(map[int]int{1: 2, 2: 1})(t, res)
+					if !strings.HasPrefix(res.Header.Get(utils.HeaderETag), `W/"`) {
+						t.Errorf("ETag = %q, want a weak ETag", res.Header.Get(utils.HeaderETag))
+					}
+				},
+			},
+			{method: http.MethodGet, path: "/products/42", status: http.StatusNotFound, errorCode: utils.ProductNotFoundCode},
+		},
+	},
+	{
+		name: "like and cancel like",
+		steps: []step{
+			{method: http.MethodPost, path: "/products/reviews/3/2/like", status: http.StatusOK},
+			{method: http.MethodGet, path: "/products/1", status: http.StatusOK, check: wantLikeCounts(map[int]int{1: 2, 2: 2})},
+			{method: http.MethodPost, path: "/products/reviews/3/2/like", status: http.StatusConflict, errorCode: utils.ReviewAlreadyLikedCode},
+			{method: http.MethodPost, path: "/products/reviews/3/42/like", status: http.StatusNotFound, errorCode: utils.ReviewNotFoundCode},
+			{method: http.MethodPost, path: "/products/reviews/42/2/like", status: http.StatusNotFound, errorCode: utils.MemberNotFoundCode},
+			{method: http.MethodDelete, path: "/products/reviews/3/2/like", status: http.StatusOK},
+			{method: http.MethodDelete, path: "/products/reviews/3/2/like", status: http.StatusNotFound, errorCode: utils.ReviewNotLikedCode},
+		},
+	},
+	{
+		name: "delete and restore product",
+		steps: []step{
+			{method: http.MethodDelete, path: "/admin/products/1", status: http.StatusPreconditionRequired, errorCode: utils.PreconditionRequiredCode},
+			{method: http.MethodDelete, path: "/admin/products/1", headers: ifMatch(`"2"`), status: http.StatusPreconditionFailed, errorCode: utils.VersionMismatchCode},
+			{method: http.MethodDelete, path: "/admin/products/1", headers: ifMatch(`"1"`), status: http.StatusOK},
+			{method: http.MethodGet, path: "/products/1", status: http.StatusNotFound, errorCode: utils.ProductNotFoundCode},
+			{method: http.MethodDelete, path: "/admin/products/1", headers: ifMatch(`"1"`), status: http.StatusNotFound, errorCode: utils.ProductNotFoundCode},
+			{method: http.MethodPost, path: "/admin/products/1/restore", headers: ifMatch(`"2"`), status: http.StatusPreconditionFailed, errorCode: utils.VersionMismatchCode},
+			{method: http.MethodPost, path: "/admin/products/1/restore", headers: ifMatch(`"1"`), status: http.StatusOK, check: wantHeader(utils.HeaderETag, `"2"`)},
+			{method: http.MethodGet, path: "/products/1", status: http.StatusOK},
+			{method: http.MethodPost, path: "/admin/products/1/restore", headers: ifMatch("*"), status: http.StatusNotFound, errorCode: utils.ProductNotFoundCode},
+		},
+	},
+	{
+		name: "delete and restore review",
+		steps: []step{
+			{method: http.MethodDelete, path: "/admin/reviews/1", status: http.StatusPreconditionRequired, errorCode: utils.PreconditionRequiredCode},
+			{method: http.MethodDelete, path: "/admin/reviews/1", headers: ifMatch(`"2"`), status: http.StatusPreconditionFailed, errorCode: utils.VersionMismatchCode},
+			{method: http.MethodDelete, path: "/admin/reviews/1", headers: ifMatch(`"1"`), status: http.StatusOK},
+			{method: http.MethodGet, path: "/products/1", status: http.StatusOK, check: wantLikeCounts(map[int]int{2: 1})},
+			{method: http.MethodDelete, path: "/admin/reviews/1", headers: ifMatch("*"), status: http.StatusNotFound, errorCode: utils.ReviewNotFoundCode},
+			{method: http.MethodPost, path: "/admin/reviews/1/restore", headers: ifMatch(`"1"`), status: http.StatusOK},
+			{method: http.MethodGet, path: "/products/1", status: http.StatusOK, check: wantLikeCounts(map[int]int{1: 2, 2: 1})},
+			{method: http.MethodPost, path: "/admin/reviews/42/restore", headers: ifMatch("*"), status: http.StatusNotFound, errorCode: utils.ReviewNotFoundCode},
+		},
+	},
+	{
+		name: "search",
+		steps: []step{
+			{method: http.MethodGet, path: "/search?q=product", status: http.StatusOK, check: wantSearchHits(1, 2)},
+			{method: http.MethodGet, path: "/search?q=product&gender=female", status: http.StatusOK, check: wantSearchHits(2)},
+			{method: http.MethodGet, path: "/search?q=quality&type=product", status: http.StatusOK, check: wantSearchHits()},
+			{method: http.MethodGet, path: "/search", status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+			{method: http.MethodGet, path: "/search?q=product&skinType=scaly", status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+		},
+	},
+	{
+		name: "change log level",
+		steps: []step{
+			{method: http.MethodGet, path: "/admin/log-level", status: http.StatusOK},
+			{method: http.MethodPut, path: "/admin/log-level", body: `{"level":"warn"}`, status: http.StatusOK},
+			{method: http.MethodPut, path: "/admin/log-level", body: `{"level":"loud"}`, status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+		},
+	},
+	{
+		name: "import and export members",
+		steps: []step{
+			{
+				method: http.MethodPost, path: "/admin/members/import?dryRun=true", body: memberImportCSV,
+				headers: map[string]string{echo.HeaderContentType: "text/csv"}, status: http.StatusOK,
+				check: wantImportReport(bulkModels.ImportReport{Rows: 3, Created: 1, Updated: 1, Failed: 1}),
+			},
+			{
+				method: http.MethodPost, path: "/admin/members/import", body: memberImportCSV,
+				headers: map[string]string{echo.HeaderContentType: "text/csv"}, status: http.StatusOK,
+				check: wantImportReport(bulkModels.ImportReport{Rows: 3, Created: 1, Updated: 1, Failed: 1}),
+			},
+			{
+				method: http.MethodGet, path: "/admin/members/export", status: http.StatusOK,
+				check: wantBody("id,username,gender,skinType,skinColor,version\n" +
+					"1,User1,Male,Oily,Fair,1\n" +
+					"2,User2,Female,Oily,Medium,2\n" +
+					"3,User3,Male,Dry,Dark,1\n" +
+					"4,User4,Female,Normal,Fair,1\n"),
+			},
+			{method: http.MethodPost, path: "/admin/members/import?format=xml", body: memberImportCSV, status: http.StatusBadRequest, errorCode: utils.UnsupportedFormatCode},
+			{method: http.MethodGet, path: "/admin/members/export?format=xml", status: http.StatusBadRequest, errorCode: utils.UnsupportedFormatCode},
+		},
+	},
+	{
+		name: "import and export products",
+		steps: []step{
+			{
+				method: http.MethodPost, path: "/admin/products/import", body: productImportNDJSON,
+				headers: map[string]string{echo.HeaderContentType: "application/x-ndjson"}, status: http.StatusOK,
+				check: wantImportReport(bulkModels.ImportReport{Rows: 2, Created: 1, Updated: 1}),
+			},
+			{
+				method: http.MethodGet, path: "/admin/products/export?format=ndjson", status: http.StatusOK,
+				check: wantBody(`{"id":1,"productName":"Product1","price":12.5,"version":2}` + "\n" +
+					`{"id":2,"productName":"Product2","price":19.99,"version":1}` + "\n" +
+					`{"id":3,"productName":"Product3","price":5,"version":1}` + "\n"),
+			},
+			{
+				method: http.MethodPost, path: "/admin/products/import?key=name", body: productImportNDJSON,
+				headers: map[string]string{echo.HeaderContentType: "application/x-ndjson"}, status: http.StatusBadRequest, errorCode: utils.InvalidParameterCode,
+			},
+		},
+	},
+	{
+		name: "export member data",
+		steps: []step{
+			{
+				method: http.MethodGet, path: "/admin/members/1/export", status: http.StatusOK,
+				check: wantArchive("User1", []int{1}, []int{2}),
+			},
+			{method: http.MethodGet, path: "/admin/members/42/export", status: http.StatusNotFound, errorCode: utils.MemberNotFoundCode},
+			{method: http.MethodGet, path: "/admin/members/1/export/" + strings.Repeat("ab", 16), status: http.StatusNotFound, errorCode: utils.ExportNotFoundCode},
+			{method: http.MethodGet, path: "/admin/members/1/export/latest", status: http.StatusBadRequest, errorCode: utils.ValidationFailedCode},
+		},
+	},
+	{
+		name: "replay idempotent request",
+		steps: []step{
+			{
+				method: http.MethodPost, path: "/members/", body: newMemberBody,
+				headers: map[string]string{middleware.HeaderIdempotencyKey: "signup-1"}, status: http.StatusOK,
+			},
+			{
+				method: http.MethodPost, path: "/members/", body: newMemberBody,
+				headers: map[string]string{middleware.HeaderIdempotencyKey: "signup-1"}, status: http.StatusOK,
+				check: wantHeader(middleware.HeaderIdempotentReplayed, "true"),
+			},
+		},
+	},
+}
+
+func TestServerRoutes(t *testing.T) {
+	covered := runScenarios(t)
+
+	// Every API route must be exercised by a scenario. Groups with
+	// middleware also route everything else to echo.NotFoundHandler.
+	notFound := runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+
+	h := newHarness(t)
+	for _, route := range h.echo.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/v1/") || route.Name == notFound {
+			continue
+		}
+		if !covered[route.Method+" "+route.Path] {
+			t.Errorf("route %s %s has no scenario", route.Method, route.Path)
+		}
+	}
+}
+
+// TestServerRoutesProductCache runs the scenarios with product pages cached,
+// every write they read back must invalidate the cache.
+func TestServerRoutesProductCache(t *testing.T) {
+	runScenarios(t, func(cfg *config.Config) {
+		cfg.ProductCache = config.ProductCacheConfig{Enabled: true, Size: 100, TTL: time.Hour}
+	})
+}
+
+// runScenarios runs routeScenarios, each on a new harness, and returns the
+// routes they covered as "METHOD /path".
+func runScenarios(t *testing.T, configure ...func(*config.Config)) map[string]bool {
+	covered := make(map[string]bool)
+
+	for _, scenario := range routeScenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			h := newHarness(t, configure...)
+
+			for i, s := range scenario.steps {
+				covered[s.method+" "+h.route(s.method, s.path)] = true
+
+				res := h.do(s.method, s.path, s.body, s.headers)
+				if res.StatusCode != s.status {
+					t.Fatalf("step %d: %s %s status = %d, want %d, body %s", i, s.method, s.path, res.StatusCode, s.status, res.body)
+				}
+				if res.StatusCode != http.StatusNotModified && res.isJSON() {
+					if res.envelope.ResponseCode != s.status {
+						t.Errorf("step %d: response.code = %d, want %d", i, res.envelope.ResponseCode, s.status)
+					}
+					if res.envelope.ErrorCode != s.errorCode {
+						t.Errorf("step %d: response.errorCode = %q, want %q", i, res.envelope.ErrorCode, s.errorCode)
+					}
+				}
+				if s.check != nil {
+					s.check(t, res)
+				}
+			}
+		})
+	}
+	return covered
+}
+
+func TestServerRequestIDAndLogging(t *testing.T) {
+	h := newHarness(t)
+
+	res := h.do(http.MethodGet, "/members/42", "", map[string]string{echo.HeaderXRequestID: "trace-me-42"})
+	if res.Header.Get(echo.HeaderXRequestID) != "trace-me-42" {
+		t.Errorf("X-Request-ID = %q, want the client's ID", res.Header.Get(echo.HeaderXRequestID))
+	}
+	if res.envelope.RequestID != "trace-me-42" {
+		t.Errorf("response.requestId = %q, want the client's ID", res.envelope.RequestID)
+	}
+
+	generated := h.do(http.MethodPost, "/members/", newMemberBody, nil).Header.Get(echo.HeaderXRequestID)
+	if generated == "" {
+		t.Fatal("no X-Request-ID generated")
+	}
+
+	logged := make(map[string]map[string]interface{})
+	for _, line := range h.logLines() {
+		if line["MESSAGE"] == "request" {
+			if id, ok := line["requestId"].(string); ok {
+				logged[id] = line
+			}
+		}
+	}
+
+	failed, ok := logged["trace-me-42"]
+	if !ok {
+		t.Fatal("request with a client ID was not logged")
+	}
+	if failed["LEVEL"] != "warn" || failed["route"] != "/api/v1/members/:id" {
+		t.Errorf("logged %v, want a warning for /api/v1/members/:id", failed)
+	}
+
+	created, ok := logged[generated]
+	if !ok {
+		t.Fatal("request with a generated ID was not logged")
+	}
+	if body, _ := created["request"].(string); strings.Contains(body, "User4") {
+		t.Errorf("logged request body %s, want the username redacted", body)
+	}
+}
+
+func TestServerRequestLogRedactsEveryFormat(t *testing.T) {
+	h := newHarness(t)
+
+	requests := []struct {
+		id          string
+		method      string
+		path        string
+		body        string
+		contentType string
+		field       string
+	}{
+		{"form", http.MethodPost, "/members/", "username=User9&gender=Female&skinType=Oily&skinColor=Fair", echo.MIMEApplicationForm, "request"},
+		{"csv", http.MethodPost, "/admin/members/import?dryRun=true", memberImportCSV, "text/csv", "request"},
+		{"ndjson", http.MethodPost, "/admin/members/import?dryRun=true", `{"username":"User9","gender":"Female","skinType":"Oily","skinColor":"Fair"}` + "\n", "application/x-ndjson", "request"},
+		{"export", http.MethodGet, "/admin/members/export", "", "", "response"},
+	}
+	for _, r := range requests {
+		headers := map[string]string{echo.HeaderXRequestID: r.id}
+		if r.contentType != "" {
+			headers[echo.HeaderContentType] = r.contentType
+		}
+		h.do(r.method, r.path, r.body, headers)
+	}
+
+	logged := make(map[string]map[string]interface{})
+	for _, line := range h.logLines() {
+		if line["MESSAGE"] == "request" {
+			if id, ok := line["requestId"].(string); ok {
+				logged[id] = line
+			}
+		}
+	}
+	for _, r := range requests {
+		line, ok := logged[r.id]
+		if !ok {
+			t.Errorf("%s request was not logged", r.id)
+			continue
+		}
+		body, _ := line[r.field].(string)
+		if strings.Contains(body, "User") || !strings.Contains(body, "REDACTED") {
+			t.Errorf("%s: logged %s %s, want the usernames redacted", r.id, r.field, body)
+		}
+	}
+}
+
+func TestServerHandlerErrorsLogAtTheirStatusLevel(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(http.MethodGet, "/members/42", "", map[string]string{echo.HeaderXRequestID: "client-error"})
+
+	if err := h.db.Exec("ALTER TABLE members RENAME TO members_gone").Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.db.Exec("ALTER TABLE members_gone RENAME TO members") })
+	h.do(http.MethodGet, "/members/1", "", map[string]string{echo.HeaderXRequestID: "server-error"})
+
+	levels := make(map[string]interface{})
+	for _, line := range h.logLines() {
+		if message, _ := line["MESSAGE"].(string); strings.HasPrefix(message, "http.GetMemberByID: ") {
+			if id, ok := line["requestId"].(string); ok {
+				levels[id] = line["LEVEL"]
+			}
+		}
+	}
+	if levels["client-error"] != "warn" || levels["server-error"] != "error" {
+		t.Errorf("handler error levels = %v, want warn for the 404 and error for the 500", levels)
+	}
+}
+
+func TestServerAdminAuth(t *testing.T) {
+	h := newHarness(t)
+
+	_, key, err := adminUsecase.NewAdminUsecase(adminRepo.NewAdminRepository(h.db)).CreateAdmin(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("CreateAdmin() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"no key", "", http.StatusUnauthorized},
+		{"not a bearer token", "Basic " + key, http.StatusUnauthorized},
+		{"unknown key", "Bearer " + strings.Repeat("0", len(key)), http.StatusUnauthorized},
+		{"valid key", "Bearer " + key, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{echo.HeaderAuthorization: tt.authorization}
+
+			res := h.do(http.MethodGet, "/admin/log-level", "", headers)
+			if res.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", res.StatusCode, tt.status, res.body)
+			}
+			if tt.status == http.StatusUnauthorized && res.envelope.ErrorCode != utils.AdminKeyInvalidCode {
+				t.Errorf("errorCode = %q, want %s", res.envelope.ErrorCode, utils.AdminKeyInvalidCode)
+			}
+		})
+	}
+
+	// Public routes do not need a key
+	if res := h.do(http.MethodGet, "/products/1", "", nil); res.StatusCode != http.StatusOK {
+		t.Errorf("GET /products/1 status = %d, want 200", res.StatusCode)
+	}
+}
+
+func TestServerLogLevelNeedsAdminKey(t *testing.T) {
+	h := newHarness(t)
+
+	noKey := map[string]string{echo.HeaderAuthorization: ""}
+	if res := h.do(http.MethodPut, "/admin/log-level", `{"level":"error"}`, noKey); res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("PUT /admin/log-level without a key status = %d, want 401", res.StatusCode)
+	}
+
+	var level struct {
+		Level string `json:"level"`
+	}
+	h.do(http.MethodGet, "/admin/log-level", "", nil).data(t, &level)
+	if level.Level != "debug" {
+		t.Errorf("log level = %s, want it unchanged by a request without a key", level.Level)
+	}
+}
+
+func TestServerBulkRoutesNeedAdminKey(t *testing.T) {
+	h := newHarness(t)
+
+	noKey := map[string]string{echo.HeaderAuthorization: "", echo.HeaderContentType: "text/csv"}
+	for _, route := range []struct{ method, path, body string }{
+		{http.MethodPost, "/admin/members/import", memberImportCSV},
+		{http.MethodGet, "/admin/members/export", ""},
+		{http.MethodPost, "/admin/products/import", "id,productName,price\n1,Renamed,1\n"},
+		{http.MethodGet, "/admin/products/export", ""},
+		{http.MethodGet, "/admin/members/1/export", ""},
+	} {
+		if res := h.do(route.method, route.path, route.body, noKey); res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s %s without a key status = %d, want 401", route.method, route.path, res.StatusCode)
+		}
+	}
+
+	var members []memberModels.Member
+	h.do(http.MethodGet, "/members/all", "", nil).data(t, &members)
+	for _, member := range members {
+		if member.Username == "User4" {
+			t.Errorf("member %+v was imported without a key", member)
+		}
+	}
+}
+
+func TestServerAdminRoutesNeedAdminAuth(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+	}{
+		{"disabled", func(cfg *config.Config) { cfg.Server.AdminAuth = &disabled }},
+		{"memory driver", func(cfg *config.Config) { cfg.MySQL.Driver = config.DriverMemory }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t, tt.configure)
+
+			for _, path := range []string{"/admin/log-level", "/admin/members/export"} {
+				if res := h.do(http.MethodGet, path, "", nil); res.StatusCode != http.StatusNotFound {
+					t.Errorf("GET %s status = %d, want 404", path, res.StatusCode)
+				}
+			}
+			if res := h.do(http.MethodDelete, "/admin/products/1", "", nil); res.StatusCode != http.StatusNotFound {
+				t.Errorf("DELETE /admin/products/1 status = %d, want 404", res.StatusCode)
+			}
+			if res := h.do(http.MethodPut, "/admin/log-level", `{"level":"debug"}`, nil); res.StatusCode != http.StatusNotFound {
+				t.Errorf("PUT /admin/log-level status = %d, want 404", res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestServerFailedImportReportsRowsSoFar(t *testing.T) {
+	h := newHarness(t)
+
+	// Every row now fails on the storage rather than on its own data
+	if err := h.db.Exec("ALTER TABLE members RENAME TO members_gone").Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.db.Exec("ALTER TABLE members_gone RENAME TO members") })
+
+	res := h.do(http.MethodPost, "/admin/members/import", memberImportCSV, map[string]string{echo.HeaderContentType: "text/csv"})
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500, body %s", res.StatusCode, res.body)
+	}
+
+	var report bulkModels.ImportReport
+	res.data(t, &report)
+	if report.Entity != "members" || report.Rows != 1 || report.Created+report.Updated != 0 {
+		t.Errorf("report = %+v, want the first row read and nothing stored, body %s", report, res.body)
+	}
+}
+
+func TestServerBackgroundMemberExport(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) { cfg.DataExport.SyncLimit = 1 })
+
+	res := h.do(http.MethodGet, "/admin/members/1/export", "", nil)
+	if res.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202, body %s", res.StatusCode, res.body)
+	}
+	var job privacyModels.ExportJob
+	res.data(t, &job)
+	if job.Status != privacyModels.ExportPending || res.Header.Get(echo.HeaderLocation) != job.DownloadURL {
+		t.Fatalf("job = %+v, Location %q", job, res.Header.Get(echo.HeaderLocation))
+	}
+	download := strings.TrimPrefix(job.DownloadURL, "/api/v1")
+
+	// A second request while the first job is active gets the same job
+	res = h.do(http.MethodGet, "/admin/members/1/export", "", nil)
+	var again privacyModels.ExportJob
+	res.data(t, &again)
+	if again.ID != job.ID {
+		t.Errorf("second export started job %s, want %s", again.ID, job.ID)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		res = h.do(http.MethodGet, download, "", nil)
+		if res.StatusCode != http.StatusAccepted || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("download status = %d, want 200, body %s", res.StatusCode, res.body)
+	}
+	wantArchive("User1", []int{1}, []int{2})(t, res)
+
+	// The link only works for the member it was made for
+	other := strings.Replace(download, "/members/1/", "/members/2/", 1)
+	if res := h.do(http.MethodGet, other, "", nil); res.StatusCode != http.StatusNotFound {
+		t.Errorf("download as another member's export status = %d, want 404", res.StatusCode)
+	}
+}
+
+func TestServerProductPageCache(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.ProductCache = config.ProductCacheConfig{Enabled: true, Size: 100, TTL: time.Hour}
+	})
+
+	productName := func(t *testing.T) string {
+		t.Helper()
+
+		res := h.do(http.MethodGet, "/products/1", "", nil)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("GET /products/1 status = %d, body %s", res.StatusCode, res.body)
+		}
+		var page struct {
+			Product productModels.Product `json:"product"`
+		}
+		res.data(t, &page)
+		return page.Product.Name
+	}
+
+	if got := productName(t); got != "Product1" {
+		t.Fatalf("product name = %q, want Product1", got)
+	}
+
+	// Changes made past the API are served from the cache until it expires
+	if err := h.db.Exec("UPDATE products SET PRODUCT_NAME = 'Renamed' WHERE ID_PRODUCT = 1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := productName(t); got != "Product1" {
+		t.Errorf("product name = %q, want the cached Product1", got)
+	}
+
+	// A like through the API drops the page
+	if res := h.do(http.MethodPost, "/products/reviews/3/2/like", "", nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("like status = %d, body %s", res.StatusCode, res.body)
+	}
+	res := h.do(http.MethodGet, "/products/1", "", nil)
+	wantLikeCounts(map[int]int{1: 2, 2: 2})(t, res)
+	if got := productName(t); got != "Renamed" {
+		t.Errorf("product name = %q, want Renamed", got)
+	}
+}
+
+func TestServerProductPageCacheFollowsMembers(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.ProductCache = config.ProductCacheConfig{Enabled: true, Size: 100, TTL: time.Hour}
+	})
+
+	page := func(t *testing.T, check func(t *testing.T, res *response)) {
+		t.Helper()
+
+		res := h.do(http.MethodGet, "/products/1", "", nil)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("GET /products/1 status = %d, body %s", res.StatusCode, res.body)
+		}
+		check(t, res)
+	}
+	write := func(t *testing.T, method, path, body string, headers map[string]string) {
+		t.Helper()
+
+		if res := h.do(method, path, body, headers); res.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s status = %d, body %s", method, path, res.StatusCode, res.body)
+		}
+	}
+
+	page(t, wantReviewAuthors(map[int]string{1: "User1", 2: "User2"}))
+
+	write(t, http.MethodPatch, "/members/2", `{"username":"Renamed"}`,
+		map[string]string{utils.HeaderIfMatch: "*", echo.HeaderContentType: utils.MIMEApplicationMergePatchJSON})
+	page(t, wantReviewAuthors(map[int]string{2: "Renamed"}))
+
+	write(t, http.MethodPost, "/admin/members/import?key=id", "id,username,gender,skinType,skinColor\n2,Imported,Female,Dry,Medium\n",
+		map[string]string{echo.HeaderContentType: "text/csv"})
+	page(t, wantReviewAuthors(map[int]string{2: "Imported"}))
+
+	write(t, http.MethodDelete, "/members/1?mode=anonymize", "", ifMatch("*"))
+	page(t, wantReviewAuthors(map[int]string{1: memberModels.FormerMemberUsername}))
+
+	// Member 3 only liked a review on the page
+	write(t, http.MethodDelete, "/members/3", "", ifMatch("*"))
+	page(t, wantLikeCounts(map[int]int{1: 1, 2: 1}))
+
+	write(t, http.MethodPost, "/admin/members/3/restore", "", nil)
+	page(t, wantLikeCounts(map[int]int{1: 2, 2: 1}))
+}
+
+func TestServerRateLimitIgnoresSpoofableHeaders(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Default = config.RateLimitPolicy{Requests: 1, Per: time.Minute}
+	})
+
+	// A new member or forwarded IP on every request is still the same client
+	for i, status := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		headers := map[string]string{
+			utils.HeaderXMemberID:    strconv.Itoa(i + 1),
+			echo.HeaderXForwardedFor: fmt.Sprintf("203.0.113.%d", i+1),
+			echo.HeaderXRealIP:       fmt.Sprintf("198.51.100.%d", i+1),
+		}
+		if res := h.do(http.MethodGet, "/products/1", "", headers); res.StatusCode != status {
+			t.Fatalf("request %d status = %d, want %d", i, res.StatusCode, status)
+		}
+	}
+}
+
+func TestServerRateLimitBehindTrustedProxy(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.Server.TrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Default = config.RateLimitPolicy{Requests: 1, Per: time.Minute}
+	})
+
+	// The proxy forwards each client, who get a bucket of their own
+	for _, client := range []string{"203.0.113.1", "203.0.113.2"} {
+		headers := map[string]string{echo.HeaderXForwardedFor: client}
+		if res := h.do(http.MethodGet, "/products/1", "", headers); res.StatusCode != http.StatusOK {
+			t.Fatalf("first request of %s status = %d, want 200", client, res.StatusCode)
+		}
+	}
+	headers := map[string]string{echo.HeaderXForwardedFor: "203.0.113.1"}
+	if res := h.do(http.MethodGet, "/products/1", "", headers); res.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("second request of 203.0.113.1 status = %d, want 429", res.StatusCode)
+	}
+}
+
+// newUnstartedServer returns a Server over a seeded database whose Run has
+// not been called, configure functions adjust its config first.
+func newUnstartedServer(t *testing.T, configure func(*config.Config)) *Server {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	cfg.Logger.Outputs = []string{zap.OutputFile}
+	cfg.Logger.File = filepath.Join(t.TempDir(), "application.log")
+	cfg.Search.Index = config.SearchIndexMemory
+	configure(cfg)
+
+	logger := zap.NewAppLogger(cfg)
+	logger.InitLogger()
+
+	db := testutil.NewDatabase(t)
+	testutil.Seed(t, db, harnessFixtures)
+	return NewServer(cfg, logger, db)
+}
+
+func TestServerRunNeedsCertificatesForSSL(t *testing.T) {
+	s := newUnstartedServer(t, func(cfg *config.Config) {
+		cfg.Server.SSL = true
+		cfg.Server.CertFile = "server.crt"
+	})
+
+	if err := s.Run(); err == nil || !strings.Contains(err.Error(), "KeyFile") {
+		t.Errorf("Run() error = %v, want the missing KeyFile reported", err)
+	}
+}
+
+func TestServerRunListensOnConfiguredPort(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	s := newUnstartedServer(t, func(cfg *config.Config) {
+		cfg.Server.Port = taken.Addr().String()
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run() }()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), taken.Addr().String()) {
+			t.Errorf("Run() error = %v, want the configured address in use", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not fail on a port already in use")
+	}
+}
+
+func TestServerRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		port string
+		want string
+	}{
+		{"", "https://shop.example:8080/api/v1/products/1?x=1"},
+		{":443", "https://shop.example/api/v1/products/1?x=1"},
+		{":8443", "https://shop.example:8443/api/v1/products/1?x=1"},
+	}
+	for _, tt := range tests {
+		s := &Server{cfg: &config.Config{}}
+		s.cfg.Server.Port = tt.port
+
+		req := httptest.NewRequest(http.MethodGet, "http://shop.example:80/api/v1/products/1?x=1", nil)
+		rec := httptest.NewRecorder()
+		s.redirectToHTTPS(rec, req)
+
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != tt.want {
+			t.Errorf("port %q: redirect = %d %s, want 301 %s", tt.port, rec.Code, rec.Header().Get("Location"), tt.want)
+		}
+	}
+}
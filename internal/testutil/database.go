@@ -0,0 +1,91 @@
+// Package testutil provides the databases and fixtures shared by tests.
+package testutil
+
+import (
+	_ "embed"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// MySQLDSNEnv names the environment variable holding the DSN of a MySQL
+// database created from config/db/db.sql. When set, tests run against it
+// instead of SQLite; every table in it is emptied first.
+const MySQLDSNEnv = "TEST_MYSQL_DSN"
+
+//go:embed schema.sql
+var sqliteSchema string
+
+// tables lists the application tables, children first.
+var tables = []string{"like_reviews", "review_products", "products", "member_exports", "members", "member_erasures", "admins"}
+
+// NewDatabase returns an empty database with the application schema, a
+// private in-memory SQLite database unless MySQLDSNEnv is set.
+func NewDatabase(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	if dsn := os.Getenv(MySQLDSNEnv); dsn != "" {
+		return newMySQL(t, dsn)
+	}
+	return newSQLite(t)
+}
+
+func newSQLite(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:?_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	// Every connection to :memory: opens a new database, keep a single one
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, statement := range strings.Split(sqliteSchema, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("create sqlite schema: %v", err)
+		}
+	}
+
+	return db
+}
+
+func newMySQL(t testing.TB, dsn string) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open mysql: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open mysql: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, table := range tables {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("empty %s: %v", table, err)
+		}
+	}
+
+	return db
+}
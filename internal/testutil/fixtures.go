@@ -0,0 +1,53 @@
+package testutil
+
+import (
+	memberModels "social_media/internal/member/models"
+	productModels "social_media/internal/product/models"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// Fixtures are rows seeded before a test. Every row carries its ID.
+type Fixtures struct {
+	Members  []memberModels.Member
+	Products []productModels.Product
+	Reviews  []productModels.ReviewData
+	Likes    []productModels.LikeReview
+}
+
+// Seed inserts the fixtures into db, a zero version is stored as 1.
+func Seed(t testing.TB, db *gorm.DB, f Fixtures) {
+	t.Helper()
+
+	for _, m := range f.Members {
+		exec(t, db, "INSERT INTO members (ID_MEMBER, USERNAME, GENDER, SKINTYPE, SKINCOLOR, VERSION) VALUES (?, ?, ?, ?, ?, ?)",
+			m.ID, m.Username, m.Gender, m.SkinType, m.SkinColor, versionOrOne(m.Version))
+	}
+	for _, p := range f.Products {
+		exec(t, db, "INSERT INTO products (ID_PRODUCT, PRODUCT_NAME, PRICE, VERSION) VALUES (?, ?, ?, ?)",
+			p.ID, p.Name, p.Price, versionOrOne(p.Version))
+	}
+	for _, r := range f.Reviews {
+		exec(t, db, "INSERT INTO review_products (ID_REVIEW, ID_MEMBER, ID_PRODUCT, DESC_REVIEW, VERSION) VALUES (?, ?, ?, ?, ?)",
+			r.ID, r.MemberID, r.ProductID, r.Description, versionOrOne(r.Version))
+	}
+	for _, l := range f.Likes {
+		exec(t, db, "INSERT INTO like_reviews (ID_REVIEW, ID_MEMBER) VALUES (?, ?)", l.ReviewID, l.MemberID)
+	}
+}
+
+func exec(t testing.TB, db *gorm.DB, query string, args ...interface{}) {
+	t.Helper()
+
+	if err := db.Exec(query, args...).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+}
+
+func versionOrOne(version int) int {
+	if version == 0 {
+		return 1
+	}
+	return version
+}
@@ -0,0 +1,66 @@
+-- SQLite version of config/db/db.sql, without the seed rows
+CREATE TABLE members (
+  ID_MEMBER INTEGER PRIMARY KEY AUTOINCREMENT,
+  USERNAME VARCHAR(255) NOT NULL COLLATE NOCASE,
+  GENDER VARCHAR(16) NULL CHECK (GENDER IN ('Male', 'Female')),
+  SKINTYPE VARCHAR(16) NULL CHECK (SKINTYPE IN ('Oily', 'Dry', 'Normal', 'Combination')),
+  SKINCOLOR VARCHAR(16) NULL CHECK (SKINCOLOR IN ('Fair', 'Medium', 'Dark')),
+  VERSION INT NOT NULL DEFAULT 1,
+  DELETED_AT DATETIME NULL,
+  ANONYMIZED_AT DATETIME NULL
+);
+
+CREATE TABLE products (
+  ID_PRODUCT INTEGER PRIMARY KEY AUTOINCREMENT,
+  PRODUCT_NAME VARCHAR(255) NOT NULL,
+  PRICE DECIMAL(10, 2) NOT NULL,
+  VERSION INT NOT NULL DEFAULT 1,
+  DELETED_AT DATETIME NULL
+);
+
+CREATE TABLE review_products (
+  ID_REVIEW INTEGER PRIMARY KEY AUTOINCREMENT,
+  ID_MEMBER INT NOT NULL REFERENCES members (ID_MEMBER) ON DELETE CASCADE,
+  ID_PRODUCT INT NOT NULL REFERENCES products (ID_PRODUCT) ON DELETE CASCADE,
+  DESC_REVIEW TEXT,
+  VERSION INT NOT NULL DEFAULT 1,
+  DELETED_AT DATETIME NULL
+);
+
+CREATE TABLE like_reviews (
+  ID_LIKE INTEGER PRIMARY KEY AUTOINCREMENT,
+  ID_REVIEW INT NOT NULL REFERENCES review_products (ID_REVIEW) ON DELETE CASCADE,
+  ID_MEMBER INT NOT NULL REFERENCES members (ID_MEMBER) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_review_products_product ON review_products (ID_PRODUCT, DELETED_AT);
+CREATE UNIQUE INDEX idx_like_reviews_review ON like_reviews (ID_REVIEW, ID_MEMBER);
+
+CREATE TABLE admins (
+  ID_ADMIN INTEGER PRIMARY KEY AUTOINCREMENT,
+  USERNAME VARCHAR(255) NOT NULL UNIQUE,
+  KEY_HASH CHAR(64) NOT NULL UNIQUE,
+  CREATED_AT DATETIME NOT NULL
+);
+
+CREATE TABLE member_erasures (
+  ID_ERASURE INTEGER PRIMARY KEY AUTOINCREMENT,
+  ID_MEMBER INT NOT NULL,
+  MODE VARCHAR(16) NOT NULL CHECK (MODE IN ('delete', 'anonymize')),
+  REQUESTED_BY INT NULL,
+  REQUEST_ID VARCHAR(255) NOT NULL DEFAULT '',
+  CREATED_AT DATETIME NOT NULL
+);
+
+CREATE TABLE member_exports (
+  ID_EXPORT CHAR(32) PRIMARY KEY,
+  ID_MEMBER INT NOT NULL REFERENCES members (ID_MEMBER) ON DELETE CASCADE,
+  STATUS VARCHAR(16) NOT NULL CHECK (STATUS IN ('pending', 'ready', 'failed')),
+  CREATED_AT DATETIME NOT NULL,
+  COMPLETED_AT DATETIME NULL,
+  EXPIRES_AT DATETIME NOT NULL,
+  ARCHIVE BLOB NULL
+);
+
+CREATE INDEX idx_member_exports_member ON member_exports (ID_MEMBER, CREATED_AT);
+CREATE INDEX idx_member_exports_expires_at ON member_exports (EXPIRES_AT);
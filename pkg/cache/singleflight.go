@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+)
+
+// errLoadPanicked is shared with the waiting callers when a call panics
+var errLoadPanicked = errors.New("cache: load panicked")
+
+// Group runs one call per key at a time: callers asking for a key that is
+// already being loaded wait for that load and share its result, so a
+// missing entry reaches the database once however many requests want it.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	wg    sync.WaitGroup
+	value []byte
+	err   error
+}
+
+// Do runs fn for key unless a call for key is in flight, and returns its
+// result. shared reports whether the result came from another caller's call.
+func (g *Group) Do(key string, fn func() ([]byte, error)) (value []byte, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.value, true, c.err
+	}
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.err = errLoadPanicked
+	c.value, c.err = fn()
+	return c.value, false, c.err
+}
+
+// Forget makes the next Do for key run a new call rather than join the one
+// in flight, whose result may predate a write.
+func (g *Group) Forget(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.calls, key)
+}
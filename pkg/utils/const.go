@@ -3,4 +3,84 @@ package utils
 const (
 	UsernameAlreadyExists = "Username already exists"
 	MemberNotFound        = "Member not Found"
+	ProductNotFound       = "product not found"
+	ReviewNotFound        = "review not found"
+	ReviewAlreadyLiked    = "user has already liked the review"
+	ReviewNotLiked        = "user has not liked the review"
+	VersionMismatch       = "resource was modified by another request, fetch it again and retry"
+	IfMatchRequired       = "If-Match header is required"
+	IdempotencyKeyInvalid = "Idempotency-Key must be at most 255 characters"
+	IdempotencyKeyReused  = "Idempotency-Key was already used for a different request"
+	IdempotencyInProgress = "a request with this Idempotency-Key is still being processed"
+	IdempotentBodyTooBig  = "request body is too large to be kept for Idempotency-Key, retry without the header"
+	RateLimitExceeded     = "rate limit exceeded, retry later"
+	CSRFTokenInvalid      = "missing or invalid CSRF token"
+	AdminKeyInvalid       = "missing or invalid admin API key"
+	AdminAlreadyExists    = "admin already exists"
+	UnsupportedFormat     = "format must be csv or ndjson"
+	ExportNotFound        = "data export not found"
+	ExportExpired         = "data export has expired, request a new one"
+	ExportFailed          = "data export failed, request a new one"
+	MemberAnonymized      = "member was anonymized and cannot be restored"
+)
+
+// Machine-readable error codes returned in Response.ErrorCode
+const (
+	BadRequestCode            = "BAD_REQUEST"
+	InvalidParameterCode      = "INVALID_PARAMETER"
+	ValidationFailedCode      = "VALIDATION_FAILED"
+	UnauthorizedCode          = "UNAUTHORIZED"
+	ForbiddenCode             = "FORBIDDEN"
+	NotFoundCode              = "NOT_FOUND"
+	ConflictCode              = "CONFLICT"
+	VersionMismatchCode       = "VERSION_MISMATCH"
+	PreconditionRequiredCode  = "PRECONDITION_REQUIRED"
+	IdempotencyKeyInvalidCode = "IDEMPOTENCY_KEY_INVALID"
+	IdempotencyKeyReusedCode  = "IDEMPOTENCY_KEY_REUSED"
+	IdempotencyInProgressCode = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	IdempotentBodyTooBigCode  = "IDEMPOTENT_BODY_TOO_LARGE"
+	RateLimitedCode           = "RATE_LIMITED"
+	CSRFTokenInvalidCode      = "CSRF_TOKEN_INVALID"
+	AdminKeyInvalidCode       = "ADMIN_KEY_INVALID"
+	AdminAlreadyExistsCode    = "ADMIN_ALREADY_EXISTS"
+	UnsupportedFormatCode     = "UNSUPPORTED_FORMAT"
+	InvalidHeaderCode         = "INVALID_HEADER"
+	InvalidRowCode            = "INVALID_ROW"
+	DuplicateKeyCode          = "DUPLICATE_KEY"
+	ExportNotFoundCode        = "EXPORT_NOT_FOUND"
+	ExportExpiredCode         = "EXPORT_EXPIRED"
+	ExportFailedCode          = "EXPORT_FAILED"
+	TimeoutCode               = "TIMEOUT"
+	InternalErrorCode         = "INTERNAL_ERROR"
+	MemberNotFoundCode        = "MEMBER_NOT_FOUND"
+	MemberAnonymizedCode      = "MEMBER_ANONYMIZED"
+	UsernameTakenCode         = "USERNAME_ALREADY_EXISTS"
+	ProductNotFoundCode       = "PRODUCT_NOT_FOUND"
+	ReviewNotFoundCode        = "REVIEW_NOT_FOUND"
+	ReviewAlreadyLikedCode    = "REVIEW_ALREADY_LIKED"
+	ReviewNotLikedCode        = "REVIEW_NOT_LIKED"
+)
+
+var (
+	ErrMemberNotFound           = NewNotFoundError(MemberNotFoundCode, MemberNotFound)
+	ErrMemberAnonymized         = NewConflictError(MemberAnonymizedCode, MemberAnonymized)
+	ErrUsernameAlreadyExists    = NewConflictError(UsernameTakenCode, UsernameAlreadyExists)
+	ErrProductNotFound          = NewNotFoundError(ProductNotFoundCode, ProductNotFound)
+	ErrReviewNotFound           = NewNotFoundError(ReviewNotFoundCode, ReviewNotFound)
+	ErrReviewAlreadyLiked       = NewConflictError(ReviewAlreadyLikedCode, ReviewAlreadyLiked)
+	ErrReviewNotLiked           = NewNotFoundError(ReviewNotLikedCode, ReviewNotLiked)
+	ErrVersionMismatch          = NewPreconditionFailedError(VersionMismatchCode, VersionMismatch)
+	ErrPreconditionRequired     = NewDomainError(PreconditionRequired, PreconditionRequiredCode, IfMatchRequired)
+	ErrIdempotencyKeyInvalid    = NewValidationError(IdempotencyKeyInvalidCode, IdempotencyKeyInvalid)
+	ErrIdempotencyKeyReused     = NewDomainError(UnprocessableEntity, IdempotencyKeyReusedCode, IdempotencyKeyReused)
+	ErrIdempotencyKeyInProgress = NewConflictError(IdempotencyInProgressCode, IdempotencyInProgress)
+	ErrIdempotentBodyTooBig     = NewDomainError(RequestTooLarge, IdempotentBodyTooBigCode, IdempotentBodyTooBig)
+	ErrTooManyRequests          = NewDomainError(TooManyRequests, RateLimitedCode, RateLimitExceeded)
+	ErrCSRFTokenInvalid         = NewForbiddenError(CSRFTokenInvalidCode, CSRFTokenInvalid)
+	ErrAdminKeyInvalid          = NewUnauthorizedError(AdminKeyInvalidCode, AdminKeyInvalid)
+	ErrAdminAlreadyExists       = NewConflictError(AdminAlreadyExistsCode, AdminAlreadyExists)
+	ErrUnsupportedFormat        = NewValidationError(UnsupportedFormatCode, UnsupportedFormat)
+	ErrExportNotFound           = NewNotFoundError(ExportNotFoundCode, ExportNotFound)
+	ErrExportExpired            = NewDomainError(Gone, ExportExpiredCode, ExportExpired)
+	ErrExportFailed             = NewDomainError(InternalServerError, ExportFailedCode, ExportFailed)
 )
@@ -1,28 +1,92 @@
 package utils
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 var (
-	BadRequest          = errors.New("Bad Request")
-	InternalServerError = errors.New("Internal Server Error")
-	NotFound            = errors.New("Not Found")
+	BadRequest           = errors.New("Bad Request")
+	Unauthorized         = errors.New("Unauthorized")
+	Forbidden            = errors.New("Forbidden")
+	NotFound             = errors.New("Not Found")
+	Conflict             = errors.New("Conflict")
+	Gone                 = errors.New("Gone")
+	PreconditionFailed   = errors.New("Precondition Failed")
+	PreconditionRequired = errors.New("Precondition Required")
+	RequestTooLarge      = errors.New("Request Entity Too Large")
+	UnprocessableEntity  = errors.New("Unprocessable Entity")
+	TooManyRequests      = errors.New("Too Many Requests")
+	InternalServerError  = errors.New("Internal Server Error")
+	GatewayTimeout       = errors.New("Gateway Timeout")
 )
 
+// DomainError is returned by repositories and usecases. Kind is one of the
+// sentinel errors above and decides the HTTP status, Code is a stable
+// machine-readable identifier for clients.
+type DomainError struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Kind
+}
+
+func NewDomainError(kind error, code string, message string) error {
+	return &DomainError{Kind: kind, Code: code, Message: message}
+}
+
+func NewValidationError(code string, message string) error {
+	return NewDomainError(BadRequest, code, message)
+}
+
+func NewUnauthorizedError(code string, message string) error {
+	return NewDomainError(Unauthorized, code, message)
+}
+
+func NewForbiddenError(code string, message string) error {
+	return NewDomainError(Forbidden, code, message)
+}
+
+func NewNotFoundError(code string, message string) error {
+	return NewDomainError(NotFound, code, message)
+}
+
+func NewConflictError(code string, message string) error {
+	return NewDomainError(Conflict, code, message)
+}
+
+func NewPreconditionFailedError(code string, message string) error {
+	return NewDomainError(PreconditionFailed, code, message)
+}
+
 type RestErr interface {
 	StatusCode() int
+	ErrorCode() string
 	Error() string
 	Cause() interface{}
+	Details() interface{}
 }
 
 type RestError struct {
-	ErrStatus int         `json:"status,omitempty"`
-	ErrError  string      `json:"error,omitempty"`
-	ErrCause  interface{} `json:"-"`
+	ErrStatus  int         `json:"status,omitempty"`
+	ErrError   string      `json:"error,omitempty"`
+	ErrCode    string      `json:"code,omitempty"`
+	ErrDetails interface{} `json:"details,omitempty"`
+	ErrCause   interface{} `json:"-"`
 }
 
 func (e RestError) Error() string {
@@ -33,15 +97,24 @@ func (e RestError) StatusCode() int {
 	return e.ErrStatus
 }
 
+func (e RestError) ErrorCode() string {
+	return e.ErrCode
+}
+
 func (e RestError) Cause() interface{} {
 	return e.ErrCause
 }
 
+func (e RestError) Details() interface{} {
+	return e.ErrDetails
+}
+
 func NewRestError(status int, err string, causes interface{}) RestErr {
 
 	return RestError{
 		ErrStatus: status,
 		ErrError:  err,
+		ErrCode:   defaultErrorCodes[status],
 		ErrCause:  causes,
 	}
 }
@@ -50,21 +123,92 @@ func NewInternalServerError(causes interface{}) RestErr {
 	result := RestError{
 		ErrStatus: http.StatusInternalServerError,
 		ErrError:  InternalServerError.Error(),
+		ErrCode:   InternalErrorCode,
 		ErrCause:  causes,
 	}
 	return result
 }
 
+var kindStatus = map[error]int{
+	BadRequest:           http.StatusBadRequest,
+	Unauthorized:         http.StatusUnauthorized,
+	Forbidden:            http.StatusForbidden,
+	NotFound:             http.StatusNotFound,
+	Conflict:             http.StatusConflict,
+	Gone:                 http.StatusGone,
+	PreconditionFailed:   http.StatusPreconditionFailed,
+	PreconditionRequired: http.StatusPreconditionRequired,
+	RequestTooLarge:      http.StatusRequestEntityTooLarge,
+	UnprocessableEntity:  http.StatusUnprocessableEntity,
+	TooManyRequests:      http.StatusTooManyRequests,
+	GatewayTimeout:       http.StatusGatewayTimeout,
+}
+
+var defaultErrorCodes = map[int]string{
+	http.StatusBadRequest:           BadRequestCode,
+	http.StatusUnauthorized:         UnauthorizedCode,
+	http.StatusForbidden:            ForbiddenCode,
+	http.StatusNotFound:             NotFoundCode,
+	http.StatusConflict:             ConflictCode,
+	http.StatusPreconditionFailed:   VersionMismatchCode,
+	http.StatusPreconditionRequired: PreconditionRequiredCode,
+	http.StatusGatewayTimeout:       TimeoutCode,
+	http.StatusInternalServerError:  InternalErrorCode,
+}
+
 func ParseError(err error) RestErr {
+	var (
+		restErr   RestError
+		fieldErrs ValidationErrors
+		domainErr *DomainError
+		httpErr   *echo.HTTPError
+		numErr    *strconv.NumError
+	)
+
 	switch {
 
-	case errors.Is(err, sql.ErrNoRows):
+	case errors.As(err, &restErr):
+		return restErr
+
+	case errors.As(err, &fieldErrs):
+		return RestError{
+			ErrStatus:  http.StatusBadRequest,
+			ErrError:   BadRequest.Error(),
+			ErrCode:    ValidationFailedCode,
+			ErrDetails: fieldErrs,
+			ErrCause:   "validation failed",
+		}
+
+	case errors.As(err, &domainErr):
+		status, ok := kindStatus[domainErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		return RestError{
+			ErrStatus: status,
+			ErrError:  http.StatusText(status),
+			ErrCode:   domainErr.Code,
+			ErrCause:  domainErr.Message,
+		}
+
+	case errors.As(err, &numErr):
+		return RestError{
+			ErrStatus: http.StatusBadRequest,
+			ErrError:  BadRequest.Error(),
+			ErrCode:   InvalidParameterCode,
+			ErrCause:  fmt.Sprintf("invalid number %q", numErr.Num),
+		}
+
+	case errors.As(err, &httpErr):
+		return NewRestError(httpErr.Code, http.StatusText(httpErr.Code), httpErr.Message)
+
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, gorm.ErrRecordNotFound):
 		return NewRestError(http.StatusNotFound, NotFound.Error(), err)
 
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewRestError(http.StatusGatewayTimeout, GatewayTimeout.Error(), err)
+
 	default:
-		if restErr, ok := err.(RestError); ok {
-			return restErr
-		}
 		return NewInternalServerError(err)
 	}
 }
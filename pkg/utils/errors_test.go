@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func TestParseError(t *testing.T) {
+	_, numErr := strconv.Atoi("abc")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"not found", ErrMemberNotFound, http.StatusNotFound, MemberNotFoundCode},
+		{"wrapped domain error", fmt.Errorf("usecase: %w", ErrUsernameAlreadyExists), http.StatusConflict, UsernameTakenCode},
+		{"precondition failed", ErrVersionMismatch, http.StatusPreconditionFailed, VersionMismatchCode},
+		{"precondition required", ErrPreconditionRequired, http.StatusPreconditionRequired, PreconditionRequiredCode},
+		{"unprocessable", ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, IdempotencyKeyReusedCode},
+		{"too many requests", ErrTooManyRequests, http.StatusTooManyRequests, RateLimitedCode},
+		{"gone", ErrExportExpired, http.StatusGone, ExportExpiredCode},
+		{"unknown kind", NewDomainError(errors.New("odd"), "ODD", "odd"), http.StatusInternalServerError, "ODD"},
+		{"validation", ValidationErrors{{Field: "username", Message: "is required"}}, http.StatusBadRequest, ValidationFailedCode},
+		{"bad number", numErr, http.StatusBadRequest, InvalidParameterCode},
+		{"echo error", echo.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, ""},
+		{"no rows", sql.ErrNoRows, http.StatusNotFound, NotFoundCode},
+		{"record not found", gorm.ErrRecordNotFound, http.StatusNotFound, NotFoundCode},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, TimeoutCode},
+		{"anything else", errors.New("connection refused"), http.StatusInternalServerError, InternalErrorCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restErr := ParseError(tt.err)
+			if restErr.StatusCode() != tt.wantStatus || restErr.ErrorCode() != tt.wantCode {
+				t.Errorf("ParseError() = %d %q, want %d %q", restErr.StatusCode(), restErr.ErrorCode(), tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/members/42", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+
+	status, body := ErrorResponse(c, ErrMemberNotFound)
+	response, ok := body.(Response)
+	if !ok {
+		t.Fatalf("ErrorResponse() body = %T, want Response", body)
+	}
+	want := Response{
+		ResponseCode: http.StatusNotFound,
+		Message:      MemberNotFound,
+		Status:       "failed",
+		ErrorCode:    MemberNotFoundCode,
+		RequestID:    "req-1",
+	}
+	if status != http.StatusNotFound || response != want {
+		t.Errorf("ErrorResponse() = %d %+v, want %d %+v", status, response, http.StatusNotFound, want)
+	}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// VersionETag is the strong ETag of a row carrying a version column.
+func VersionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ContentETag is a weak ETag derived from the JSON encoding of v, for
+// responses assembled from several rows.
+func ContentETag(v interface{}) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(body)
+	return fmt.Sprintf("W/%q", hex.EncodeToString(sum[:])), nil
+}
+
+// NotModified sets the ETag header and reports whether the client's
+// If-None-Match already holds it, in which case a 304 should be sent.
+func NotModified(c echo.Context, etag string) bool {
+	c.Response().Header().Set(HeaderETag, etag)
+
+	ifNoneMatch := c.Request().Header.Get(HeaderIfNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || weakEqual(candidate, etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnyVersion is returned by IfMatchVersion for "If-Match: *", which matches
+// whatever version is current. Stored versions start at 1.
+const AnyVersion = 0
+
+// IfMatchVersion reads the version the client expects from If-Match. Writes
+// without the header are rejected so concurrent edits cannot overwrite each
+// other silently. If-Match compares strongly, so weak tags never match.
+func IfMatchVersion(c echo.Context) (int, error) {
+	ifMatch := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if ifMatch == "" {
+		return 0, ErrPreconditionRequired
+	}
+	if ifMatch == "*" {
+		return AnyVersion, nil
+	}
+
+	version, err := strconv.Unquote(ifMatch)
+	if err != nil || strings.HasPrefix(ifMatch, "W/") {
+		return 0, ErrVersionMismatch
+	}
+	result, err := strconv.Atoi(version)
+	if err != nil || result < 1 {
+		return 0, ErrVersionMismatch
+	}
+	return result, nil
+}
+
+func weakEqual(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func etagContext(header, value string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if value != "" {
+		req.Header.Set(header, value)
+	}
+	rec := httptest.NewRecorder()
+	return echo.New().NewContext(req, rec), rec
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		ifMatch string
+		want    int
+		wantErr error
+	}{
+		{"", 0, ErrPreconditionRequired},
+		{`"3"`, 3, nil},
+		{` "3" `, 3, nil},
+		{"*", AnyVersion, nil},
+		{`W/"3"`, 0, ErrVersionMismatch},
+		{"3", 0, ErrVersionMismatch},
+		{`"abc"`, 0, ErrVersionMismatch},
+		{`"0"`, 0, ErrVersionMismatch},
+	}
+	for _, tt := range tests {
+		c, _ := etagContext(HeaderIfMatch, tt.ifMatch)
+		got, err := IfMatchVersion(c)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("IfMatchVersion(%q) = %d, %v, want %d, %v", tt.ifMatch, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`"2"`, true},
+		{`W/"2"`, true},
+		{`"1", "2"`, true},
+		{"*", true},
+		{`"1"`, false},
+	}
+	for _, tt := range tests {
+		c, rec := etagContext(HeaderIfNoneMatch, tt.ifNoneMatch)
+		if got := NotModified(c, VersionETag(2)); got != tt.want {
+			t.Errorf("NotModified(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+		if got := rec.Header().Get(HeaderETag); got != `"2"` {
+			t.Errorf("NotModified(%q) set ETag %q, want \"2\"", tt.ifNoneMatch, got)
+		}
+	}
+}
+
+func TestContentETag(t *testing.T) {
+	first, err := ContentETag(map[string]int{"id": 1})
+	if err != nil {
+		t.Fatalf("ContentETag() error = %v", err)
+	}
+	second, _ := ContentETag(map[string]int{"id": 1})
+	other, _ := ContentETag(map[string]int{"id": 2})
+
+	if first != second || first == other {
+		t.Errorf("ContentETag() = %s, %s, %s, want stable tags that differ by content", first, second, other)
+	}
+	if first[:3] != `W/"` {
+		t.Errorf("ContentETag() = %s, want a weak tag", first)
+	}
+}
@@ -2,24 +2,67 @@ package utils
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	HeaderXMemberID = "X-Member-ID"
+)
+
 func GetRequestID(c echo.Context) string {
 	return c.Request().Header.Get(echo.HeaderXRequestID)
 }
 
 type ReqIDCtxKey struct{}
 
+type PrincipalCtxKey struct{}
+
+// GetRequestCtx returns the request-scoped context. RequestContextMiddleware
+// already attaches the request ID, principal and deadline; the request ID is
+// added here as well so handlers mounted outside the middleware still get one.
 func GetRequestCtx(c echo.Context) context.Context {
-	return context.WithValue(c.Request().Context(), ReqIDCtxKey{}, GetRequestID(c))
+	ctx := c.Request().Context()
+	if _, ok := ctx.Value(ReqIDCtxKey{}).(string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, ReqIDCtxKey{}, GetRequestID(c))
+}
+
+// GetPrincipal reads the calling member from the X-Member-ID header.
+// There is no authentication yet, so the header is trusted as-is.
+func GetPrincipal(c echo.Context) (int, bool) {
+	memberID, err := strconv.Atoi(c.Request().Header.Get(HeaderXMemberID))
+	if err != nil {
+		return 0, false
+	}
+	return memberID, true
+}
+
+func RequestIDFromCtx(ctx context.Context) string {
+	requestID, _ := ctx.Value(ReqIDCtxKey{}).(string)
+	return requestID
+}
+
+func PrincipalFromCtx(ctx context.Context) (int, bool) {
+	memberID, ok := ctx.Value(PrincipalCtxKey{}).(int)
+	return memberID, ok
 }
 
 // func GetIPAddress(c *echo.Context) string {
 // 	return c.Request().RemoteAddr
 // }
 
+// ReadPathParams binds and validates only the path parameters, leaving the
+// request body unread.
+func ReadPathParams(c echo.Context, request interface{}) error {
+	if err := (&echo.DefaultBinder{}).BindPathParams(c, request); err != nil {
+		return err
+	}
+	return c.Validate(request)
+}
+
 func ReadRequest(c echo.Context, request interface{}) error {
 	if err := c.Bind(request); err != nil {
 		return err
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+const (
+	MIMEApplicationMergePatchJSON = "application/merge-patch+json"
+)
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) document to target, which
+// must be a pointer to a JSON-serializable struct. Keys set to null are
+// removed, so the matching field ends up with its zero value.
+func MergePatch(target interface{}, patch []byte) error {
+	var patchDoc map[string]interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil || patchDoc == nil {
+		return NewValidationError(BadRequestCode, "merge patch body must be a JSON object")
+	}
+
+	current, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(mergeObjects(doc, patchDoc))
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(target).Elem()
+	value.Set(reflect.Zero(value.Type()))
+
+	if err := json.Unmarshal(merged, target); err != nil {
+		return NewValidationError(BadRequestCode, err.Error())
+	}
+	return nil
+}
+
+func mergeObjects(doc, patch map[string]interface{}) map[string]interface{} {
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	for key, patchValue := range patch {
+		if patchValue == nil {
+			delete(doc, key)
+			continue
+		}
+		patchObject, ok := patchValue.(map[string]interface{})
+		if !ok {
+			doc[key] = patchValue
+			continue
+		}
+		docObject, _ := doc[key].(map[string]interface{})
+		doc[key] = mergeObjects(docObject, patchObject)
+	}
+	return doc
+}
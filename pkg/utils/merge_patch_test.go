@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type patchedAddress struct {
+	City string `json:"city,omitempty"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type patchedDoc struct {
+	Name    string          `json:"name,omitempty"`
+	Tags    []string        `json:"tags,omitempty"`
+	Address *patchedAddress `json:"address,omitempty"`
+}
+
+func TestMergePatch(t *testing.T) {
+	current := func() *patchedDoc {
+		return &patchedDoc{Name: "alice", Tags: []string{"a", "b"}, Address: &patchedAddress{City: "Paris", Zip: "75001"}}
+	}
+
+	tests := []struct {
+		name  string
+		patch string
+		want  *patchedDoc
+	}{
+		{
+			name:  "replaces a field",
+			patch: `{"name":"bob"}`,
+			want:  &patchedDoc{Name: "bob", Tags: []string{"a", "b"}, Address: &patchedAddress{City: "Paris", Zip: "75001"}},
+		},
+		{
+			name:  "replaces arrays whole",
+			patch: `{"tags":["c"]}`,
+			want:  &patchedDoc{Name: "alice", Tags: []string{"c"}, Address: &patchedAddress{City: "Paris", Zip: "75001"}},
+		},
+		{
+			name:  "merges nested objects",
+			patch: `{"address":{"zip":"75002"}}`,
+			want:  &patchedDoc{Name: "alice", Tags: []string{"a", "b"}, Address: &patchedAddress{City: "Paris", Zip: "75002"}},
+		},
+		{
+			name:  "removes null keys",
+			patch: `{"name":null,"address":{"city":null}}`,
+			want:  &patchedDoc{Tags: []string{"a", "b"}, Address: &patchedAddress{Zip: "75001"}},
+		},
+		{
+			name:  "keeps everything for an empty patch",
+			patch: `{}`,
+			want:  current(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := current()
+			if err := MergePatch(got, []byte(tt.patch)); err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergePatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchRejectsInvalidDocuments(t *testing.T) {
+	for _, patch := range []string{`["name"]`, `null`, `{"name":`, `{"tags":"a"}`} {
+		err := MergePatch(&patchedDoc{}, []byte(patch))
+		if !errors.Is(err, BadRequest) {
+			t.Errorf("MergePatch(%s) error = %v, want a validation error", patch, err)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package utils
 
 import (
-	"net/http"
-
 	"github.com/labstack/echo/v4"
 )
 
@@ -11,6 +9,9 @@ type Response struct {
 	Message      string      `json:"message"`
 	Status       string      `json:"status"`
 	Data         interface{} `json:"data"`
+	ErrorCode    string      `json:"errorCode,omitempty"`
+	Errors       interface{} `json:"errors,omitempty"`
+	RequestID    string      `json:"requestId,omitempty"`
 }
 
 func SuccessResponse(c echo.Context, code int, message string, data interface{}) (int, interface{}) {
@@ -25,17 +26,23 @@ func SuccessResponse(c echo.Context, code int, message string, data interface{})
 }
 
 func ErrorResponse(c echo.Context, err error) (int, interface{}) {
-	statusCode := http.StatusInternalServerError
-	if he, ok := err.(*echo.HTTPError); ok {
-		statusCode = he.Code
-	}
+	return ErrorResponseWithData(c, err, nil)
+}
+
+// ErrorResponseWithData is ErrorResponse for a request that failed part way,
+// with what it did before failing in data.
+func ErrorResponseWithData(c echo.Context, err error, data interface{}) (int, interface{}) {
+	restErr := ParseError(err)
 
 	response := Response{
-		ResponseCode: statusCode,
-		Message:      err.Error(),
+		ResponseCode: restErr.StatusCode(),
+		Message:      restErr.Error(),
 		Status:       "failed",
-		Data:         nil,
+		Data:         data,
+		ErrorCode:    restErr.ErrorCode(),
+		Errors:       restErr.Details(),
+		RequestID:    GetRequestID(c),
 	}
 
-	return statusCode, response
+	return restErr.StatusCode(), response
 }
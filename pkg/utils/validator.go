@@ -2,6 +2,10 @@ package utils
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -10,8 +14,113 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	validate.RegisterTagNameFunc(fieldName)
+	_ = validate.RegisterValidation("oneofci", oneOfCaseInsensitive)
+	_ = validate.RegisterValidation("neci", notEqualCaseInsensitive)
+}
+
+// FieldError describes why a single request field failed validation.
+type FieldError struct {
+	Field   string   `json:"field"`
+	Message string   `json:"message"`
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+// ValidationErrors is returned by ValidateStruct and the Echo validator,
+// ParseError maps it to a 400 carrying every FieldError.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, fe := range v {
+		messages[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Validator adapts the package validator to echo.Validator.
+type Validator struct{}
+
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+func (cv *Validator) Validate(i interface{}) error {
+	return toValidationErrors(validate.Struct(i))
 }
 
 func ValidateStruct(ctx context.Context, s interface{}) error {
-	return validate.StructCtx(ctx,s)
-}
\ No newline at end of file
+	return toValidationErrors(validate.StructCtx(ctx, s))
+}
+
+func toValidationErrors(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	result := make(ValidationErrors, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		result[i] = FieldError{
+			Field:   fe.Field(),
+			Message: fieldMessage(fe),
+		}
+		if fe.Tag() == "oneof" || fe.Tag() == "oneofci" {
+			result[i].Allowed = strings.Fields(fe.Param())
+		}
+	}
+	return result
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof", "oneofci":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "neci":
+		return "must not be " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+	}
+}
+
+// oneOfCaseInsensitive is "oneof" ignoring case and surrounding whitespace,
+// for enumerated values that are normalized before they are stored.
+func oneOfCaseInsensitive(fl validator.FieldLevel) bool {
+	value := strings.TrimSpace(fl.Field().String())
+	for _, allowed := range strings.Fields(fl.Param()) {
+		if strings.EqualFold(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// notEqualCaseInsensitive is "ne" ignoring case and surrounding whitespace,
+// for values reserved by the server.
+func notEqualCaseInsensitive(fl validator.FieldLevel) bool {
+	return !strings.EqualFold(strings.TrimSpace(fl.Field().String()), fl.Param())
+}
+
+// fieldName reports fields by the name the client used: the json key for
+// bodies, the param name for path parameters.
+func fieldName(field reflect.StructField) string {
+	if name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]; name != "" && name != "-" {
+		return name
+	}
+	if name := field.Tag.Get("param"); name != "" {
+		return name
+	}
+	return field.Name
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type validatedRequest struct {
+	ID       int    `param:"id" validate:"required,min=1"`
+	Username string `json:"username" validate:"required,max=8"`
+	Bio      string `json:"bio,omitempty" validate:"min=3"`
+	Price    int    `json:"-" validate:"max=10"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	tests := []struct {
+		name    string
+		request validatedRequest
+		want    ValidationErrors
+	}{
+		{
+			name:    "valid",
+			request: validatedRequest{ID: 1, Username: "alice", Bio: "hello", Price: 10},
+		},
+		{
+			name:    "every field names the key the client sent",
+			request: validatedRequest{Bio: "hi", Price: 11},
+			want: ValidationErrors{
+				{Field: "id", Message: "is required"},
+				{Field: "username", Message: "is required"},
+				{Field: "bio", Message: "must be at least 3 characters"},
+				{Field: "Price", Message: "must be at most 10"},
+			},
+		},
+		{
+			name:    "string lengths",
+			request: validatedRequest{ID: 1, Username: "a-long-name", Bio: "hello"},
+			want:    ValidationErrors{{Field: "username", Message: "must be at most 8 characters"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStruct(context.Background(), &tt.request)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ValidateStruct() error = %v, want nil", err)
+				}
+				return
+			}
+
+			got, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("ValidateStruct() error = %T %v, want ValidationErrors", err, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateStruct() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorReturnsValidationErrors(t *testing.T) {
+	err := NewValidator().Validate(&validatedRequest{ID: 1, Bio: "hello"})
+	if _, ok := err.(ValidationErrors); !ok {
+		t.Fatalf("Validate() error = %T %v, want ValidationErrors", err, err)
+	}
+	if got := err.Error(); got != "username is required" {
+		t.Errorf("Error() = %q, want %q", got, "username is required")
+	}
+}
+
+type attributeRequest struct {
+	Gender string `json:"gender" validate:"required,oneofci=Male Female"`
+}
+
+func TestValidateStructOneOfCaseInsensitive(t *testing.T) {
+	for _, gender := range []string{"Male", "female", " FEMALE "} {
+		if err := ValidateStruct(context.Background(), &attributeRequest{Gender: gender}); err != nil {
+			t.Errorf("ValidateStruct(%q) error = %v, want nil", gender, err)
+		}
+	}
+
+	err := ValidateStruct(context.Background(), &attributeRequest{Gender: "other"})
+	want := ValidationErrors{{Field: "gender", Message: "must be one of: Male, Female", Allowed: []string{"Male", "Female"}}}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("ValidateStruct(other) error = %+v, want %+v", err, want)
+	}
+}
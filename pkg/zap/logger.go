@@ -1,17 +1,36 @@
 package zap
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"social_media/config"
+	"social_media/pkg/utils"
 
+	"github.com/opentracing/opentracing-go"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+
+	defaultLogFile       = "logs/application.log"
+	defaultLogMaxSize    = 50 // MB
+	defaultLogMaxBackups = 3
+	defaultLogMaxAge     = 30 // Days
+)
+
 // Logger methods interface
 type Logger interface {
 	InitLogger()
+	With(keysAndValues ...interface{}) Logger
+	FromContext(ctx context.Context) Logger
+	Level() string
+	SetLevel(level string) error
 	Debug(args ...interface{})
 	Debugf(template string, args ...interface{})
 	Info(args ...interface{})
@@ -20,6 +39,10 @@ type Logger interface {
 	Warnf(template string, args ...interface{})
 	Error(args ...interface{})
 	Errorf(template string, args ...interface{})
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
 	DPanic(args ...interface{})
 	DPanicf(template string, args ...interface{})
 	Fatal(args ...interface{})
@@ -29,12 +52,13 @@ type Logger interface {
 // Logger
 type appLogger struct {
 	cfg         *config.Config
+	level       zap.AtomicLevel
 	sugarLogger *zap.SugaredLogger
 }
 
 // App Logger constructor
 func NewAppLogger(cfg *config.Config) *appLogger {
-	return &appLogger{cfg: cfg}
+	return &appLogger{cfg: cfg, level: zap.NewAtomicLevel()}
 }
 
 // For mapping config logger to app logger levels
@@ -56,20 +80,38 @@ func (l *appLogger) getLoggerLevel(cfg *config.Config) zapcore.Level {
 
 	return level
 }
-func (l *appLogger) InitLogger() {
-	logLevel := l.getLoggerLevel(l.cfg)
 
-	// Create a file writer for the log file
-	fileWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   "logs/application.log",
-		MaxSize:    50, // MB
-		MaxBackups: 3,
-		MaxAge:     30,   // Days
-		Compress:   true, // Enable log compression
-	})
+// getWriters builds one sink per configured output, stdout and the log
+// file when none are configured.
+func (l *appLogger) getWriters(cfg *config.Config) []zapcore.WriteSyncer {
+	outputs := cfg.Logger.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{OutputFile, OutputStdout}
+	}
+
+	writers := make([]zapcore.WriteSyncer, 0, len(outputs))
+	for _, output := range outputs {
+		switch output {
+		case OutputStdout:
+			writers = append(writers, zapcore.Lock(os.Stdout))
+		case OutputStderr:
+			writers = append(writers, zapcore.Lock(os.Stderr))
+		case OutputFile:
+			writers = append(writers, zapcore.AddSync(&lumberjack.Logger{
+				Filename:   withDefault(cfg.Logger.File, defaultLogFile),
+				MaxSize:    intWithDefault(cfg.Logger.MaxSize, defaultLogMaxSize),
+				MaxBackups: intWithDefault(cfg.Logger.MaxBackups, defaultLogMaxBackups),
+				MaxAge:     intWithDefault(cfg.Logger.MaxAge, defaultLogMaxAge),
+				Compress:   !cfg.Logger.DisableCompress,
+			}))
+		}
+	}
+
+	return writers
+}
 
-	// Create a writer for console output
-	consoleWriter := zapcore.Lock(os.Stdout)
+func (l *appLogger) InitLogger() {
+	l.level.SetLevel(l.getLoggerLevel(l.cfg))
 
 	var encoderCfg zapcore.EncoderConfig
 	if l.cfg.Server.Mode == "Development" {
@@ -83,6 +125,7 @@ func (l *appLogger) InitLogger() {
 	encoderCfg.TimeKey = "TIME"
 	encoderCfg.NameKey = "NAME"
 	encoderCfg.MessageKey = "MESSAGE"
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
 
 	var encoder zapcore.Encoder
 	if l.cfg.Logger.Encoding == "console" {
@@ -91,14 +134,15 @@ func (l *appLogger) InitLogger() {
 		encoder = zapcore.NewJSONEncoder(encoderCfg)
 	}
 
-	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
-
-	// Create separate cores for file and console output
-	fileCore := zapcore.NewCore(encoder, fileWriter, zap.NewAtomicLevelAt(logLevel))
-	consoleCore := zapcore.NewCore(encoder, consoleWriter, zap.NewAtomicLevelAt(logLevel))
+	// Create one core per sink, all sharing the same runtime level
+	writers := l.getWriters(l.cfg)
+	cores := make([]zapcore.Core, len(writers))
+	for i, writer := range writers {
+		cores[i] = zapcore.NewCore(encoder, writer, l.level)
+	}
 
 	// Combine the cores into a multi-core
-	core := zapcore.NewTee(fileCore, consoleCore)
+	core := zapcore.NewTee(cores...)
 
 	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 
@@ -108,6 +152,67 @@ func (l *appLogger) InitLogger() {
 	}
 }
 
+// With returns a logger that adds the given key-value pairs to every line.
+func (l *appLogger) With(keysAndValues ...interface{}) Logger {
+	return &appLogger{cfg: l.cfg, level: l.level, sugarLogger: l.sugarLogger.With(keysAndValues...)}
+}
+
+// FromContext returns a logger that adds the request ID, member ID and trace
+// ID carried by ctx to every line.
+func (l *appLogger) FromContext(ctx context.Context) Logger {
+	var fields []interface{}
+
+	if requestID := utils.RequestIDFromCtx(ctx); requestID != "" {
+		fields = append(fields, "requestId", requestID)
+	}
+	if memberID, ok := utils.PrincipalFromCtx(ctx); ok {
+		fields = append(fields, "memberId", memberID)
+	}
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		// opentracing has no portable trace ID, tracers such as Jaeger
+		// render it through String()
+		if spanContext, ok := span.Context().(fmt.Stringer); ok {
+			fields = append(fields, "traceId", spanContext.String())
+		}
+	}
+
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
+
+// Level reports the current minimum level.
+func (l *appLogger) Level() string {
+	return l.level.Level().String()
+}
+
+// SetLevel changes the minimum level at runtime for every logger derived
+// from this one.
+func (l *appLogger) SetLevel(level string) error {
+	zapLevel, exist := loggerLevelMap[level]
+	if !exist {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+
+	l.level.SetLevel(zapLevel)
+	return nil
+}
+
+func withDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func intWithDefault(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
 // Logger methods
 
 func (l *appLogger) Debug(args ...interface{}) {
@@ -142,6 +247,22 @@ func (l *appLogger) Errorf(template string, args ...interface{}) {
 	l.sugarLogger.Errorf(template, args...)
 }
 
+func (l *appLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.sugarLogger.Debugw(msg, keysAndValues...)
+}
+
+func (l *appLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.sugarLogger.Infow(msg, keysAndValues...)
+}
+
+func (l *appLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.sugarLogger.Warnw(msg, keysAndValues...)
+}
+
+func (l *appLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.sugarLogger.Errorw(msg, keysAndValues...)
+}
+
 func (l *appLogger) DPanic(args ...interface{}) {
 	l.sugarLogger.DPanic(args...)
 }
@@ -165,3 +286,14 @@ func (l *appLogger) Fatal(args ...interface{}) {
 func (l *appLogger) Fatalf(template string, args ...interface{}) {
 	l.sugarLogger.Fatalf(template, args...)
 }
+
+// HandlerError logs the error a handler is about to answer with: client
+// errors (4xx) at warn, as they are expected and not actionable, the rest
+// at error.
+func HandlerError(logger Logger, handler string, err error) {
+	if status := utils.ParseError(err).StatusCode(); status >= 400 && status < 500 {
+		logger.Warnf("%s: %v", handler, err)
+		return
+	}
+	logger.Errorf("%s: %v", handler, err)
+}
@@ -0,0 +1,143 @@
+package zap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"social_media/config"
+	"social_media/pkg/utils"
+	"testing"
+)
+
+// levelLogger records the level of the lines logged through it.
+type levelLogger struct {
+	Logger
+	levels []string
+}
+
+func (l *levelLogger) Warnf(template string, args ...interface{}) {
+	l.levels = append(l.levels, "warn")
+}
+
+func (l *levelLogger) Errorf(template string, args ...interface{}) {
+	l.levels = append(l.levels, "error")
+}
+
+func TestHandlerError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{utils.ErrMemberNotFound, "warn"},
+		{utils.ErrVersionMismatch, "warn"},
+		{utils.ErrTooManyRequests, "warn"},
+		{utils.ErrExportFailed, "error"},
+		{errors.New("connection refused"), "error"},
+	}
+	for _, tt := range tests {
+		logger := &levelLogger{}
+		HandlerError(logger, "http.Test", tt.err)
+		if len(logger.levels) != 1 || logger.levels[0] != tt.want {
+			t.Errorf("HandlerError(%v) logged at %v, want %s", tt.err, logger.levels, tt.want)
+		}
+	}
+}
+
+// newFileLogger returns a logger at level writing JSON lines to a file, and
+// a function reading the lines back.
+func newFileLogger(t *testing.T, level string) (Logger, func() []map[string]interface{}) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Logger.Level = level
+	cfg.Logger.Outputs = []string{OutputFile}
+	cfg.Logger.File = filepath.Join(t.TempDir(), "logs", "test.log")
+
+	logger := NewAppLogger(cfg)
+	logger.InitLogger()
+
+	return logger, func() []map[string]interface{} {
+		t.Helper()
+
+		file, err := os.Open(cfg.Logger.File)
+		if err != nil {
+			t.Fatalf("open log file: %v", err)
+		}
+		defer file.Close()
+
+		var lines []map[string]interface{}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("log line %q is not JSON: %v", scanner.Text(), err)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+}
+
+func TestAppLoggerWritesToTheConfiguredFile(t *testing.T) {
+	logger, lines := newFileLogger(t, "info")
+
+	logger.Debug("hidden")
+	logger.With("memberId", 7).Infow("created", "productId", 3)
+
+	got := lines()
+	if len(got) != 1 {
+		t.Fatalf("logged %d lines, want 1: %v", len(got), got)
+	}
+	line := got[0]
+	if line["MESSAGE"] != "created" || line["LEVEL"] != "info" || line["memberId"] != float64(7) || line["productId"] != float64(3) {
+		t.Errorf("log line = %v, want the message at info with its fields", line)
+	}
+}
+
+func TestAppLoggerSetLevel(t *testing.T) {
+	logger, lines := newFileLogger(t, "info")
+	derived := logger.With("component", "test")
+
+	if err := logger.SetLevel("loud"); err == nil {
+		t.Error("SetLevel(loud) error = nil, want an unknown level")
+	}
+	if got := logger.Level(); got != "info" {
+		t.Errorf("Level() = %q, want info", got)
+	}
+
+	if err := logger.SetLevel("error"); err != nil {
+		t.Fatalf("SetLevel(error) error = %v", err)
+	}
+	if got := derived.Level(); got != "error" {
+		t.Errorf("derived Level() = %q, want error", got)
+	}
+	derived.Warn("hidden")
+	derived.Error("shown")
+
+	if got := lines(); len(got) != 1 || got[0]["MESSAGE"] != "shown" {
+		t.Errorf("logged %v, want only the error", got)
+	}
+}
+
+func TestAppLoggerFromContext(t *testing.T) {
+	logger, lines := newFileLogger(t, "info")
+
+	ctx := context.WithValue(context.Background(), utils.ReqIDCtxKey{}, "req-1")
+	ctx = context.WithValue(ctx, utils.PrincipalCtxKey{}, 7)
+	logger.FromContext(ctx).Info("with request")
+	logger.FromContext(context.Background()).Info("without request")
+
+	got := lines()
+	if len(got) != 2 {
+		t.Fatalf("logged %d lines, want 2", len(got))
+	}
+	if got[0]["requestId"] != "req-1" || got[0]["memberId"] != float64(7) {
+		t.Errorf("log line = %v, want the request and member IDs", got[0])
+	}
+	if _, ok := got[1]["requestId"]; ok {
+		t.Errorf("log line = %v, want no request ID", got[1])
+	}
+}